@@ -0,0 +1,140 @@
+/*****************************************************************************
+*
+*	File			: abtest.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: A/B run mode, runs the same batch once per variant with different
+*					: parameters (eg. chunk sizes). Every metric carries a "variant" label
+*					: and a comparison report is printed at the end, for tuning experiments.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ABVariant struct {
+	Name       string `yaml:"name"`
+	Iterations int    `yaml:"iterations"`
+	ChunkSize  int    `yaml:"chunk_size"`
+}
+
+type ABTestConfig struct {
+	Enabled  bool        `yaml:"enabled"`
+	Variants []ABVariant `yaml:"variants"`
+}
+
+type abResult struct {
+	variant  ABVariant
+	duration time.Duration
+	records  int64
+	err      error
+}
+
+func (r abResult) throughput() float64 {
+
+	if r.duration <= 0 {
+		return 0
+
+	}
+
+	return float64(r.records) / r.duration.Seconds()
+}
+
+// runABTest runs every variant in turn. The variants share one registry of their own,
+// registered through a "variant" label wrapper, so the main registry (whose series
+// don't have the label) is left alone.
+func runABTest(cfg Config) error {
+
+	if len(cfg.ABTest.Variants) < 2 {
+		return fmt.Errorf("abtest needs at least 2 variants, got %d", len(cfg.ABTest.Variants))
+
+	}
+
+	abReg := prometheus.NewRegistry()
+	abPusher, err := promwrap.NewPushRouter(cfg.Pushgateway, abReg)
+	if err != nil {
+		return err
+
+	}
+	defer abPusher.Close()
+
+	// mRun works against the package m and pusher, swap them per variant
+	mainM, mainPusher := m, pusher
+	defer func() { m, pusher = mainM, mainPusher }()
+	pusher = abPusher
+
+	seen := make(map[string]bool)
+	var results []abResult
+	for _, v := range cfg.ABTest.Variants {
+		if v.Name == "" || seen[v.Name] {
+			return fmt.Errorf("abtest variant names must be set and unique, got %q", v.Name)
+
+		}
+		seen[v.Name] = true
+
+		pm := promwrap.NewMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"variant": v.Name}, abReg))
+		pm.Strict, pm.RawLabels = mainM.Strict, mainM.RawLabels
+		m = fsetl.NewMetrics(pm)
+
+		params := RunConfig{Batch: cfg.Run.Batch, Iterations: v.Iterations, ChunkSize: v.ChunkSize, PushInterval: cfg.Run.PushInterval}.withDefaults()
+		fmt.Printf("A/B variant %s, %d iterations of %d...\n", v.Name, params.Iterations, params.ChunkSize)
+
+		start := time.Now()
+		resources := fsetl.SampleResources()
+		job := fsetl.NewJob(m, params.Batch)
+		job.Transition(fsetl.StateRunning)
+		result := mRun(job, params)
+		job.Complete(result.Outcome)
+		m.AttributeResources(params.Batch, resources)
+		finalPush(context.Background()) // the variant's last metrics, before m moves on
+		results = append(results, abResult{v, time.Since(start), result.Records, result.Err})
+	}
+
+	printABReport(results)
+
+	return nil
+}
+
+// printABReport prints each variant against the first (baseline) one.
+func printABReport(results []abResult) {
+
+	base := results[0].throughput()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "variant\titerations\tchunk_size\tduration\trecords\trecords/s\tvs baseline\terror")
+	for _, r := range results {
+		diff := "-"
+		if base > 0 {
+			diff = fmt.Sprintf("%+.1f%%", (r.throughput()/base-1)*100)
+		}
+
+		errText := ""
+		if r.err != nil {
+			errText = r.err.Error()
+		}
+
+		p := RunConfig{Iterations: r.variant.Iterations, ChunkSize: r.variant.ChunkSize}.withDefaults()
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\t%.2f\t%s\t%s\n",
+			r.variant.Name, p.Iterations, p.ChunkSize, r.duration.Round(time.Millisecond), r.records, r.throughput(), diff, errText)
+	}
+	w.Flush()
+}
@@ -0,0 +1,373 @@
+/*****************************************************************************
+*
+*	File			: archive.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Local snapshot archive, archive.dir. After every batch the registry is
+*					: written to <dir>/<batch>-<timestamp>.prom, the last archive.keep of them
+*					: are kept. Batch telemetry can carry sensitive table names and volumes, so
+*					: the snapshots can be zstd compressed (.zst) and AES-256-GCM encrypted
+*					: (.enc, the nonce followed by the sealed data) at rest.
+*
+*					: The key is a base64 encoded 32 byte key (openssl rand -base64 32), read
+*					: from archive.key.file, the archive.key.env environment variable, or a
+*					: Vault KV v2 secret, archive.key.vault_path / vault_field, using the usual
+*					: VAULT_ADDR and VAULT_TOKEN.
+*
+*					:   myapp archive cat <file>
+*
+*					: decrypts and decompresses a snapshot back to the exposition text.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"myapp/promwrap"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const defaultArchiveKeep = 100
+
+type ArchiveConfig struct {
+	Dir      string           `yaml:"dir"`  // empty disables the archive
+	Keep     int              `yaml:"keep"` // snapshots kept, default 100
+	Compress bool             `yaml:"compress"`
+	Encrypt  bool             `yaml:"encrypt"`
+	Key      ArchiveKeyConfig `yaml:"key"`
+}
+
+// ArchiveKeyConfig is where the encryption key comes from, the first one set wins.
+type ArchiveKeyConfig struct {
+	File       string `yaml:"file"`
+	Env        string `yaml:"env"`
+	VaultPath  string `yaml:"vault_path"` // eg. secret/data/fs_etl
+	VaultField string `yaml:"vault_field"`
+}
+
+// load returns the AES-256 key.
+func (c ArchiveKeyConfig) load(ctx context.Context) ([]byte, error) {
+
+	var encoded string
+	switch {
+	case c.File != "":
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return nil, err
+
+		}
+		encoded = string(data)
+
+	case c.Env != "":
+		encoded = os.Getenv(c.Env)
+		if encoded == "" {
+			return nil, fmt.Errorf("archive key environment variable %s is not set", c.Env)
+
+		}
+
+	case c.VaultPath != "":
+		var err error
+		if encoded, err = vaultSecret(ctx, c.VaultPath, c.VaultField); err != nil {
+			return nil, fmt.Errorf("archive key from vault: %w", err)
+
+		}
+
+	default:
+		return nil, errors.New("archive.encrypt needs archive.key file, env or vault_path")
+
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("archive key: %w", err)
+
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("archive key is %d bytes, expected 32", len(key))
+
+	}
+
+	return key, nil
+}
+
+// vaultSecret reads field of the KV v2 secret at path, from VAULT_ADDR with VAULT_TOKEN.
+func vaultSecret(ctx context.Context, path, field string) (string, error) {
+
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN are required")
+
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", path, resp.Status)
+
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+
+	}
+
+	value, ok := secret.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %q", path, field)
+
+	}
+
+	return value, nil
+}
+
+type archive struct {
+	cfg ArchiveConfig
+	key []byte // nil unless encrypting
+}
+
+// newArchive returns nil when the archive is disabled, the key is loaded once at startup.
+func newArchive(c ArchiveConfig) (*archive, error) {
+
+	if c.Dir == "" {
+		return nil, nil
+	}
+	if c.Keep <= 0 {
+		c.Keep = defaultArchiveKeep
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return nil, err
+
+	}
+
+	a := &archive{cfg: c}
+	if c.Encrypt {
+		var err error
+		if a.key, err = c.Key.load(context.Background()); err != nil {
+			return nil, err
+
+		}
+	}
+
+	return a, nil
+}
+
+// Write archives the current contents of g as a snapshot of batch.
+func (a *archive) Write(batch string, g prometheus.Gatherer) error {
+
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+
+	}
+
+	var buf bytes.Buffer
+	if err := promwrap.WriteExposition(&buf, mfs, expfmt.NewFormat(expfmt.TypeTextPlain)); err != nil {
+		return err
+
+	}
+	data := buf.Bytes()
+
+	name := fmt.Sprintf("%s-%s.prom", batch, time.Now().UTC().Format("20060102T150405.000Z"))
+	if a.cfg.Compress {
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return err
+
+		}
+		data = enc.EncodeAll(data, nil)
+		enc.Close()
+		name += ".zst"
+	}
+
+	if a.key != nil {
+		if data, err = seal(a.key, data); err != nil {
+			return err
+
+		}
+		name += ".enc"
+	}
+
+	path := filepath.Join(a.cfg.Dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+
+	}
+
+	return a.prune(batch)
+}
+
+// prune removes the oldest snapshots of batch beyond keep, the names sort by time.
+func (a *archive) prune(batch string) error {
+
+	files, err := filepath.Glob(filepath.Join(a.cfg.Dir, batch+"-*.prom*"))
+	if err != nil {
+		return err
+
+	}
+	sort.Strings(files)
+
+	for len(files) > a.cfg.Keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+
+		}
+		files = files[1:]
+	}
+
+	return nil
+}
+
+func seal(key, data []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func unseal(key, data []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted snapshot too short")
+
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// readArchive returns the exposition text of the snapshot at path, as per its extensions.
+func readArchive(path string, c ArchiveKeyConfig) ([]byte, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+
+	}
+
+	if strings.HasSuffix(path, ".enc") {
+		key, err := c.load(context.Background())
+		if err != nil {
+			return nil, err
+
+		}
+		if data, err = unseal(key, data); err != nil {
+			return nil, fmt.Errorf("decrypting %s: %w", path, err)
+
+		}
+		path = strings.TrimSuffix(path, ".enc")
+	}
+
+	if strings.HasSuffix(path, ".zst") {
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+
+		}
+		defer dec.Close()
+
+		if data, err = dec.DecodeAll(data, nil); err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", path, err)
+
+		}
+	}
+
+	return data, nil
+}
+
+// runArchive is the archive subcommand, archive cat <file>...
+func runArchive(args []string, c ArchiveConfig) error {
+
+	if len(args) < 2 || args[0] != "cat" {
+		return errors.New("usage: archive cat <file>...")
+
+	}
+
+	for _, path := range args[1:] {
+		data, err := readArchive(path, c.Key)
+		if err != nil {
+			return err
+
+		}
+		os.Stdout.Write(data)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/*****************************************************************************
+*
+*	File			: audit.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Run audit rows in fs_etl_run_audit, one per batch run. These feed the
+*					: backfill subcommand, see backfill.go.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"myapp/fsetl"
+)
+
+// Run status as recorded in fs_etl_run_audit.status
+const (
+	statusSucceeded = string(fsetl.StateSucceeded)
+	statusFailed    = string(fsetl.StateFailed)
+	statusPartial   = string(fsetl.StatePartial)
+	statusSkipped   = string(fsetl.StateSkipped) // never written to the audit table
+)
+
+type runAudit struct {
+	Job      string
+	Batch    string
+	Started  time.Time
+	Finished time.Time
+	Status   string
+	Records  int64
+	Err      string
+}
+
+func (a runAudit) Duration() time.Duration {
+
+	return a.Finished.Sub(a.Started)
+}
+
+func writeRunAudit(ctx context.Context, db *sql.DB, a runAudit) error {
+
+	var errText sql.NullString
+	if a.Err != "" {
+		errText = sql.NullString{String: a.Err, Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, `INSERT INTO fs_etl_run_audit
+		(job, batch, started_at, finished_at, status, records, duration_seconds, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		a.Job, a.Batch, a.Started, a.Finished, a.Status, a.Records, a.Duration().Seconds(), errText)
+
+	return err
+}
+
+// readRunAudit returns the completed runs finished since, oldest first.
+func readRunAudit(ctx context.Context, db *sql.DB, since time.Time) ([]runAudit, error) {
+
+	rows, err := db.QueryContext(ctx, `SELECT job, batch, started_at, finished_at, status, records, COALESCE(error, '')
+		FROM fs_etl_run_audit
+		WHERE finished_at IS NOT NULL AND finished_at >= $1
+		ORDER BY finished_at`, since)
+	if err != nil {
+		return nil, err
+
+	}
+	defer rows.Close()
+
+	var runs []runAudit
+	for rows.Next() {
+		var a runAudit
+		if err := rows.Scan(&a.Job, &a.Batch, &a.Started, &a.Finished, &a.Status, &a.Records, &a.Err); err != nil {
+			return nil, err
+
+		}
+		runs = append(runs, a)
+	}
+
+	return runs, rows.Err()
+}
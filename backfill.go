@@ -0,0 +1,112 @@
+/*****************************************************************************
+*
+*	File			: backfill.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: backfill subcommand, reads historical runs from fs_etl_run_audit and
+*					: writes them as timestamped samples through remote_write, so newly
+*					: created dashboards have history.
+*
+*					: Note Prometheus only accepts samples older than its head block when
+*					: storage.tsdb.out_of_order_time_window is set to cover the backfill.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Runs per remote_write request
+const backfillChunk = 500
+
+func runBackfill(ctx context.Context, args []string, db *sql.DB, c RemoteWriteConfig) error {
+
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	since := fs.Duration("since", 30*24*time.Hour, "backfill runs that finished within this long ago")
+	if err := fs.Parse(args); err != nil {
+		return err
+
+	}
+
+	if db == nil {
+		return fmt.Errorf("backfill needs database.dsn")
+
+	}
+	if c.URL == "" {
+		return fmt.Errorf("backfill needs remote_write.url")
+
+	}
+
+	runs, err := readRunAudit(ctx, db, time.Now().Add(-*since))
+	if err != nil {
+		return fmt.Errorf("reading fs_etl_run_audit: %w", err)
+
+	}
+
+	rw := NewRemoteWriter(c)
+	for start := 0; start < len(runs); start += backfillChunk {
+		end := start + backfillChunk
+		if end > len(runs) {
+			end = len(runs)
+		}
+
+		if err := rw.Write(ctx, runSeries(runs[start:end])); err != nil {
+			return fmt.Errorf("after %d of %d runs: %w", start, len(runs), err)
+
+		}
+	}
+
+	fmt.Printf("Backfilled %d runs...\n", len(runs))
+
+	return nil
+}
+
+// runSeries turns audit rows, oldest first, into the same series a live run pushes,
+// timestamped at the run's finish.
+func runSeries(runs []runAudit) []rwSeries {
+
+	index := make(map[string]int)
+	var series []rwSeries
+
+	add := func(name string, a runAudit, v float64) {
+		key := name + "\xff" + a.Job + "\xff" + a.Batch
+		i, ok := index[key]
+		if !ok {
+			i = len(series)
+			index[key] = i
+			series = append(series, rwSeries{Labels: []rwLabel{
+				{"__name__", name},
+				{"job", a.Job},
+				{"batch", a.Batch},
+			}})
+		}
+		series[i].Samples = append(series[i].Samples, rwSample{v, a.Finished})
+	}
+
+	for _, a := range runs {
+		finished := float64(a.Finished.UnixNano()) / 1e9
+
+		add("fs_etl_duration_seconds", a, a.Duration().Seconds())
+		add("fs_etl_records_processed", a, float64(a.Records))
+		add("fs_etl_complete_timestamp_seconds", a, finished)
+		if a.Status == statusSucceeded {
+			add("fs_etl_success_timestamp_seconds", a, finished)
+
+		}
+	}
+
+	return series
+}
@@ -0,0 +1,309 @@
+/*****************************************************************************
+*
+*	File			: baseline.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Performance regression detection at job end, baseline.enabled. The p95
+*					: duration of every phase (sql, api, work, wait) of the batch is compared
+*					: against the median p95 of the last baseline.window runs, a phase more
+*					: than baseline.threshold times slower is a regression. Regressions are
+*					: logged, listed in the job report and exported as
+*
+*					:   fs_etl_perf_regression{phase}	1 regressed, 0 not
+*
+*					: The baseline lives in fs_etl_perf_baseline when database.dsn is set,
+*					: in the baseline.file json file otherwise. Only succeeded runs are added
+*					: to it, a failing run doesn't drag the baseline along.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	defaultBaselineFile      = "promwrap_baseline.json"
+	defaultBaselineWindow    = 10
+	defaultBaselineMinRuns   = 3
+	defaultBaselineThreshold = 1.5
+)
+
+type BaselineConfig struct {
+	Enabled   bool    `yaml:"enabled"`
+	File      string  `yaml:"file"`      // without a database, default promwrap_baseline.json
+	Window    int     `yaml:"window"`    // runs in the rolling baseline, default 10
+	MinRuns   int     `yaml:"min_runs"`  // runs needed before we compare, default 3
+	Threshold float64 `yaml:"threshold"` // p95 over threshold times the baseline regressed, default 1.5
+}
+
+func (c BaselineConfig) withDefaults() BaselineConfig {
+
+	if c.File == "" {
+		c.File = defaultBaselineFile
+	}
+	if c.Window <= 0 {
+		c.Window = defaultBaselineWindow
+	}
+	if c.MinRuns <= 0 {
+		c.MinRuns = defaultBaselineMinRuns
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = defaultBaselineThreshold
+	}
+
+	return c
+}
+
+// phaseTimings collects the batch's durations per phase.
+type phaseTimings map[string][]time.Duration
+
+func (t phaseTimings) Observe(phase string, d time.Duration) {
+
+	t[phase] = append(t[phase], d)
+}
+
+// P95 returns the 95th percentile per phase in seconds, nearest rank.
+func (t phaseTimings) P95() map[string]float64 {
+
+	p95 := make(map[string]float64, len(t))
+	for phase, ds := range t {
+		if len(ds) == 0 {
+			continue
+
+		}
+		sorted := append([]time.Duration(nil), ds...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+		p95[phase] = sorted[rank].Seconds()
+	}
+
+	return p95
+}
+
+// baselineStore keeps the p95 per phase of past runs.
+type baselineStore interface {
+	// Load returns the p95s of the last window runs per phase.
+	Load(ctx context.Context, job, batch string, window int) (map[string][]float64, error)
+	Save(ctx context.Context, job, batch string, at time.Time, p95 map[string]float64) error
+}
+
+func newBaselineStore(c BaselineConfig, db *sql.DB) baselineStore {
+
+	if !c.Enabled {
+		return nil
+	}
+	if db != nil {
+		return pgBaseline{db}
+	}
+	return &fileBaseline{path: c.File, window: c.Window}
+}
+
+// regression is a phase slower than its baseline.
+type regression struct {
+	Phase    string
+	P95      float64 // seconds
+	Baseline float64
+}
+
+// compareBaseline compares the run's p95s against the baseline, sets fs_etl_perf_regression
+// and adds the run to the baseline if it succeeded.
+func compareBaseline(ctx context.Context, c BaselineConfig, store baselineStore, a runAudit, p95 map[string]float64) ([]regression, error) {
+
+	history, err := store.Load(ctx, a.Job, a.Batch, c.Window)
+	if err != nil {
+		return nil, err
+
+	}
+
+	phases := make([]string, 0, len(p95))
+	for phase := range p95 {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	var regressions []regression
+	for _, phase := range phases {
+		past := history[phase]
+		regressed := 0.0
+		if len(past) >= c.MinRuns {
+			base := median(past)
+			if base > 0 && p95[phase] > base*c.Threshold {
+				regressed = 1
+				regressions = append(regressions, regression{Phase: phase, P95: p95[phase], Baseline: base})
+				fmt.Printf("Performance regression in phase %s: p95 %.3fs, baseline %.3fs over %d runs\n", phase, p95[phase], base, len(past))
+			}
+		}
+		m.Set(m.PerfRegression, regressed, phase)
+	}
+
+	if a.Status == statusSucceeded {
+		if err := store.Save(ctx, a.Job, a.Batch, a.Finished, p95); err != nil {
+			return regressions, err
+
+		}
+	}
+
+	return regressions, nil
+}
+
+func median(vs []float64) float64 {
+
+	sorted := append([]float64(nil), vs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// pgBaseline keeps the baseline in fs_etl_perf_baseline.
+type pgBaseline struct {
+	db *sql.DB
+}
+
+func (s pgBaseline) Load(ctx context.Context, job, batch string, window int) (map[string][]float64, error) {
+
+	rows, err := s.db.QueryContext(ctx, `SELECT phase, p95_seconds FROM (
+			SELECT phase, p95_seconds, row_number() OVER (PARTITION BY phase ORDER BY finished_at DESC) AS n
+			FROM fs_etl_perf_baseline
+			WHERE job = $1 AND batch = $2) recent
+		WHERE n <= $3`, job, batch, window)
+	if err != nil {
+		return nil, err
+
+	}
+	defer rows.Close()
+
+	history := make(map[string][]float64)
+	for rows.Next() {
+		var phase string
+		var p95 float64
+		if err := rows.Scan(&phase, &p95); err != nil {
+			return nil, err
+
+		}
+		history[phase] = append(history[phase], p95)
+	}
+
+	return history, rows.Err()
+}
+
+func (s pgBaseline) Save(ctx context.Context, job, batch string, at time.Time, p95 map[string]float64) error {
+
+	for phase, v := range p95 {
+		if _, err := s.db.ExecContext(ctx, `INSERT INTO fs_etl_perf_baseline
+			(job, batch, phase, finished_at, p95_seconds)
+			VALUES ($1, $2, $3, $4, $5)`,
+			job, batch, phase, at, v); err != nil {
+			return err
+
+		}
+	}
+
+	return nil
+}
+
+// fileBaseline keeps the baseline in a json file, the last window runs per job and batch.
+type fileBaseline struct {
+	path   string
+	window int
+}
+
+type baselineRun struct {
+	Finished time.Time          `json:"finished"`
+	P95      map[string]float64 `json:"p95_seconds"`
+}
+
+func (s *fileBaseline) read() (map[string][]baselineRun, error) {
+
+	runs := make(map[string][]baselineRun)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return runs, nil
+
+	} else if err != nil {
+		return nil, err
+
+	}
+
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", s.path, err)
+
+	}
+
+	return runs, nil
+}
+
+func (s *fileBaseline) Load(ctx context.Context, job, batch string, window int) (map[string][]float64, error) {
+
+	runs, err := s.read()
+	if err != nil {
+		return nil, err
+
+	}
+
+	recent := runs[job+"/"+batch]
+	if len(recent) > window {
+		recent = recent[len(recent)-window:]
+	}
+
+	history := make(map[string][]float64)
+	for _, run := range recent {
+		for phase, v := range run.P95 {
+			history[phase] = append(history[phase], v)
+		}
+	}
+
+	return history, nil
+}
+
+func (s *fileBaseline) Save(ctx context.Context, job, batch string, at time.Time, p95 map[string]float64) error {
+
+	runs, err := s.read()
+	if err != nil {
+		return err
+
+	}
+
+	key := job + "/" + batch
+	recent := append(runs[key], baselineRun{Finished: at, P95: p95})
+	if len(recent) > s.window {
+		recent = recent[len(recent)-s.window:]
+	}
+	runs[key] = recent
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+
+	}
+
+	// write and rename, a run killed halfway doesn't lose the baseline
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+
+	}
+
+	return os.Rename(tmp, s.path)
+}
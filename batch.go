@@ -0,0 +1,286 @@
+/*****************************************************************************
+*
+*	File			: batch.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The example's batch, moved out of main.go. mRun does the (simulated)
+*					: work, runBatch wraps it in the job lifecycle, see fsetl/state.go, and
+*					: pushes, audits and reports the outcome.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+)
+
+func performBackup(chunkSize int) (int, error) {
+
+	// Perform the backup and return the number of backed up records and any
+	// applicable error.
+	// ...
+
+	rand.Seed(time.Now().UnixNano())
+	n := rand.Intn(1000) // if vGeneral.sleep = 1000, then n will be random value of 0 -> 1000  aka 0 and 1 second
+	debugf("API Sleeping %d Millisecond...\n", n)
+	time.Sleep(time.Duration(n) * time.Millisecond)
+
+	return chunkSize, nil
+}
+
+func mRun(job *fsetl.Job, p RunConfig) runResult {
+
+	var todo_count = p.Iterations
+	var result runResult
+	budget := newRetryBudget(job.Batch(), p.RetryBudget)
+	slow := newSlowest(p.SlowestRecords)
+	timings := make(phaseTimings)
+
+	// intermediate pushes, at most one per push_interval, runBatch does the final one
+	var lastPush time.Time
+	push := func() {
+		if finalPushOnly || pusher.Periodic() {
+			return
+		}
+		if p.PushInterval > 0 && time.Since(lastPush) < p.PushInterval {
+			return
+		}
+		lastPush = time.Now()
+
+		if err := pusher.Add(); err != nil {
+			reportFailure("Could not push to Pushgateway:", err)
+		}
+	}
+
+	// simulate a multi second sql query
+	sqlTimer := m.StartTimer("sql", job.Batch())
+	rand.Seed(time.Now().UnixNano())
+	n := rand.Intn(10000) // if vGeneral.sleep = 1000, then n will be random value of 0 -> 1000  aka 0 and 1 second (10000 = 10 seconds)
+	debugf("SQL Sleeping %d Millisecond...\n", n)
+	time.Sleep(time.Duration(n) * time.Millisecond)
+
+	timings.Observe("sql", sqlTimer.ObserveDuration())
+
+	m.Set(m.Info, 345234523, job.Batch())
+
+	for count := 0; count < todo_count; count++ {
+
+		start := time.Now()
+		sw := promwrap.StartStopwatch() // the loop, minus the throttling
+		n, err := withRetries(budget, p.Retries, func() (int, error) {
+			return performBackup(p.ChunkSize) // execute the long running batch job.
+		})
+
+		api := time.Since(start)
+		file := fmt.Sprintf("%s_chunk_%04d.csv", job.Batch(), count)
+		m.ObserveAPI(api, job.Batch(), file)
+		m.ObserveFile(job.Batch(), file, n, api, err)
+		if err == nil {
+			job.CountDistinct("files", file)
+		}
+
+		// How many files back'd up and the execution time (= my api_duration), set together.
+		// Note that time.Since only uses a monotonic clock in Go1.9+.
+		job.Update(fsetl.Snapshot{Records: n, Duration: time.Since(start)})
+
+		if err != nil {
+			reportFailure("DB backup failed:", err)
+			result.fail(err)
+
+		} else {
+			result.Succeeded++
+			result.Records += int64(n)
+
+		}
+		if errors.Is(err, errRetryBudgetExhausted) {
+			break // fail fast, the downstream is flapping
+
+		}
+
+		// Add is used here rather than Push to not delete a previously pushed
+		// success timestamp in case of a failure of this backup.
+		push()
+
+		rand.Seed(time.Now().UnixNano())
+		n = rand.Intn(2000) // if vGeneral.sleep = 1000, then n will be random value of 0 -> 1000  aka 0 and 1 second (2000 = 2 seconds)
+		debugf("Req Sleeping %d Millisecond...\n", n)
+		sw.Sleep(time.Duration(n) * time.Millisecond) // throttling, not work
+
+		// operations total and their durations move together
+		promwrap.Transaction(func() {
+			m.Inc(m.ReqProcessed, job.Batch())
+			m.Observe(m.RecDuration, sw.Elapsed(), job.Batch()) // work time of the entire loop
+			m.Observe(m.RecWait, sw.Paused(), job.Batch())
+		})
+		phases := map[string]time.Duration{"api": api, "work": sw.Elapsed(), "wait": sw.Paused()}
+		for phase, d := range phases {
+			timings.Observe(phase, d)
+		}
+		slow.Observe(slowRecord{ID: file, Duration: time.Since(start), Phases: phases})
+
+		// force a final metric push
+		push()
+
+	}
+	result.Slowest = slow.Records()
+	result.Throughput = m.Throughput(job.Batch())
+	result.P95 = timings.P95()
+
+	return result
+}
+
+// runBatch runs one instrumented batch, unless the business calendar says not to.
+func runBatch(cal *Calendar, cfg Config) JobReport {
+
+	pushes := pusher.Stats()
+	audit := runAudit{Job: cfg.Pushgateway.JobName(), Batch: cfg.Run.Batch, Started: time.Now()}
+	job := fsetl.NewJob(m, audit.Batch)
+	setRunning(job)
+	defer setRunning(nil)
+	setStartDelay(cfg.Run, audit.Batch, audit.Started)
+
+	// deferred first, so it runs after everything else the batch deferred
+	defer startLeakCheck(cfg.LeakCheck).finish(audit.Batch)
+
+	if w, active := maint.Active(time.Now()); active {
+		infof("Running inside maintenance window: %s...\n", w.Reason)
+		m.SetGauge(m.Maintenance, 1)
+
+	} else {
+		m.SetGauge(m.Maintenance, 0)
+
+	}
+
+	// Our batches legitimately don't run on weekends/public holidays, record the skip
+	// so that alerting can tell it apart from a run that never happened.
+	if reason, skip := cal.Skip(time.Now()); skip {
+		infof("Skipping run, %s...\n", reason)
+		m.Inc(m.RunsSkipped, reason)
+		job.Transition(fsetl.StateSkipped)
+
+		finalPush(context.Background())
+		writeTextfile(cfg.Exposition)
+
+		audit.Finished = time.Now()
+		audit.Status = string(job.State())
+		return JobReport{Audit: audit, State: job.State(), Pushes: pusher.Stats().Since(pushes), Policy: cfg.Pushgateway.FailurePolicy}
+	}
+
+	job.Transition(fsetl.StateRunning)
+	resources := fsetl.SampleResources()
+
+	if db != nil && cfg.Database.LockSampleInterval > 0 {
+		stop := m.StartLockSampler(db, cfg.Database.appName(), cfg.Database.LockSampleInterval)
+		defer stop()
+	}
+
+	var result runResult
+	if err := job.RunStartHooks(context.Background()); err != nil {
+		reportFailure("OnStart hook failed:", err)
+		result.fail(err)
+
+	} else {
+		result = mRun(job, cfg.Run)
+
+	}
+
+	if err := job.RunFinishHooks(context.Background()); err != nil {
+		reportFailure("OnFinish hook failed:", err)
+		result.fail(err)
+	}
+	used := m.AttributeResources(audit.Batch, resources)
+	if cfg.Cost.enabled() {
+		cost := cfg.Cost.estimate(used.CPUSeconds, result.Records, time.Since(audit.Started), len(dbs.names()))
+		m.Set(m.EstimatedCost, cost.Total(), audit.Batch)
+		result.Cost = &cost
+	}
+
+	job.Complete(result.Outcome)
+
+	// before the final push, so it carries fs_etl_perf_regression
+	if baseline != nil {
+		run := audit
+		run.Finished, run.Status = time.Now(), string(job.State())
+		regressions, err := compareBaseline(context.Background(), cfg.Baseline, baseline, run, result.P95)
+		if err != nil {
+			reportFailure("Could not compare against the performance baseline:", err)
+		}
+		result.Regressions = regressions
+	}
+
+	// final push, carrying the batch's terminal state and timestamps, and after a
+	// success the unregistered legacy SuccessTime, see finished() in fsetl/state.go
+	if job.State() == fsetl.StateSucceeded {
+		if err := pusher.AttachFinal(m.SuccessTime); err != nil {
+			reportFailure("Could not attach success time to the final push:", err)
+		}
+	}
+	finalPush(context.Background())
+	job.Seal()
+
+	audit.Finished = time.Now()
+	audit.Status = string(job.State())
+	audit.Records = result.Records
+	if result.Err != nil {
+		audit.Err = result.Err.Error()
+	}
+
+	if db != nil {
+		if err := writeRunAudit(context.Background(), db, audit); err != nil {
+			reportFailure("Could not write run audit:", err)
+		}
+		if err := writeSlowRecords(context.Background(), db, audit, result.Slowest); err != nil {
+			reportFailure("Could not write slow records:", err)
+		}
+	}
+
+	writeTextfile(cfg.Exposition)
+	if snapshots != nil {
+		if err := snapshots.Write(audit.Batch, promwrap.Consistent(reg)); err != nil {
+			reportFailure("Could not archive snapshot:", err)
+		}
+	}
+
+	report := JobReport{Audit: audit, State: job.State(), Result: result, Pushes: pusher.Stats().Since(pushes), Policy: cfg.Pushgateway.FailurePolicy}
+	report.Print()
+
+	return report
+}
+
+// finalPush pushes and waits for any queued pushes, so the job report covers all of
+// them. In periodic mode Flush does both.
+func finalPush(ctx context.Context) {
+
+	if !pusher.Periodic() {
+		if err := pusher.AddContext(ctx); err != nil {
+			reportFailure("Could not push to Pushgateway:", err)
+		}
+	}
+	pusher.Flush()
+}
+
+func writeTextfile(c promwrap.ExpositionConfig) {
+
+	if c.Textfile == "" {
+		return
+	}
+
+	if err := promwrap.WriteTextfile(c.Textfile, promwrap.Consistent(reg), c); err != nil {
+		reportFailure("Could not write textfile:", err)
+	}
+}
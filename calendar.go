@@ -0,0 +1,87 @@
+/*****************************************************************************
+*
+*	File			: calendar.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Business calendar, used to skip runs on weekends and public holidays
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons used as the "reason" label on fs_etl_runs_skipped_total
+const (
+	skipWeekend = "weekend"
+	skipHoliday = "holiday"
+)
+
+type CalendarConfig struct {
+	SkipWeekends bool     `yaml:"skip_weekends"`
+	Holidays     []string `yaml:"holidays"` // YYYY-MM-DD
+	Timezone     string   `yaml:"timezone"` // IANA name, defaults to local time
+}
+
+type Calendar struct {
+	skipWeekends bool
+	holidays     map[string]bool
+	loc          *time.Location
+}
+
+func NewCalendar(c CalendarConfig) (*Calendar, error) {
+
+	cal := &Calendar{
+		skipWeekends: c.SkipWeekends,
+		holidays:     make(map[string]bool, len(c.Holidays)),
+		loc:          time.Local,
+	}
+
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("calendar timezone %q: %w", c.Timezone, err)
+
+		}
+		cal.loc = loc
+	}
+
+	for _, h := range c.Holidays {
+		d, err := time.Parse("2006-01-02", h)
+		if err != nil {
+			return nil, fmt.Errorf("calendar holiday %q: %w", h, err)
+
+		}
+		cal.holidays[d.Format("2006-01-02")] = true
+	}
+
+	return cal, nil
+}
+
+// Skip reports whether a run at t should be skipped, and why.
+func (c *Calendar) Skip(t time.Time) (string, bool) {
+
+	t = t.In(c.loc)
+
+	if c.holidays[t.Format("2006-01-02")] {
+		return skipHoliday, true
+
+	}
+
+	if c.skipWeekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return skipWeekend, true
+
+	}
+
+	return "", false
+}
@@ -0,0 +1,68 @@
+/*****************************************************************************
+*
+*	File			: calibration.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: calibrate subcommand, turns the raw durations a calibration run
+*					: (calibration.enabled) wrote into the buckets section of the config,
+*
+*					:   myapp calibrate [-file promwrap_calibration.txt] [-buckets 10] >> promwrap.yaml
+*
+*					: see promwrap/calibrate.go. Unlike lint-buckets it fits the actual
+*					: observations rather than what the old buckets made of them.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"myapp/promwrap"
+)
+
+func runCalibrate(args []string, c promwrap.CalibrationConfig) error {
+
+	c = c.WithDefaults()
+
+	fs := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	file := fs.String("file", c.File, "raw durations written by the calibration run")
+	n := fs.Int("buckets", c.Buckets, "buckets per histogram")
+	if err := fs.Parse(args); err != nil {
+		return err
+
+	}
+
+	buckets, err := promwrap.RecommendBuckets(*file, *n)
+	if err != nil {
+		return err
+
+	}
+	if len(buckets) == 0 {
+		return fmt.Errorf("no observations in %s", *file)
+
+	}
+
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("# Recommended by myapp calibrate from %s\n", *file)
+	fmt.Println("buckets:")
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, formatBounds(buckets[name]))
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+/*****************************************************************************
+*
+*	File			: cgroup.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Container aware collector, cgroup v2 CPU throttling and memory limit
+*					: proximity. Our batch pods get throttled and the only symptom used to be
+*					: mysteriously long durations.
+*
+*					: Read at gather time from the cgroup v2 interface files, the collector
+*					: is only registered when cpu.stat exists, ie. on cgroup v2 hosts.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultCgroupPath = "/sys/fs/cgroup"
+
+type CgroupConfig struct {
+	Disabled bool   `yaml:"disabled"`
+	Path     string `yaml:"path"` // cgroup v2 mount, defaults to /sys/fs/cgroup
+}
+
+type cgroupCollector struct {
+	path string
+
+	cpuPeriods       *prometheus.Desc
+	cpuThrottled     *prometheus.Desc
+	cpuThrottledSecs *prometheus.Desc
+	cpuLimit         *prometheus.Desc
+	memUsage         *prometheus.Desc
+	memLimit         *prometheus.Desc
+	memLimitRatio    *prometheus.Desc
+	oomKills         *prometheus.Desc
+}
+
+// NewCgroupCollector returns nil when there is no cgroup v2 hierarchy at c.Path.
+func NewCgroupCollector(c CgroupConfig) prometheus.Collector {
+
+	if c.Disabled {
+		return nil
+
+	}
+
+	path := c.Path
+	if path == "" {
+		path = defaultCgroupPath
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "cpu.stat")); err != nil {
+		return nil
+
+	}
+
+	return &cgroupCollector{
+		path:             path,
+		cpuPeriods:       prometheus.NewDesc("fs_etl_cgroup_cpu_periods_total", "Number of elapsed cgroup CPU enforcement periods.", nil, nil),
+		cpuThrottled:     prometheus.NewDesc("fs_etl_cgroup_cpu_throttled_periods_total", "Number of cgroup CPU enforcement periods in which the container was throttled.", nil, nil),
+		cpuThrottledSecs: prometheus.NewDesc("fs_etl_cgroup_cpu_throttled_seconds_total", "Total time the container was throttled, in seconds.", nil, nil),
+		cpuLimit:         prometheus.NewDesc("fs_etl_cgroup_cpu_limit_cores", "CPU limit of the container in cores, absent when unlimited.", nil, nil),
+		memUsage:         prometheus.NewDesc("fs_etl_cgroup_memory_usage_bytes", "Current memory usage of the container in bytes.", nil, nil),
+		memLimit:         prometheus.NewDesc("fs_etl_cgroup_memory_limit_bytes", "Memory limit of the container in bytes, absent when unlimited.", nil, nil),
+		memLimitRatio:    prometheus.NewDesc("fs_etl_cgroup_memory_limit_ratio", "Memory usage as a fraction of the container memory limit.", nil, nil),
+		oomKills:         prometheus.NewDesc("fs_etl_cgroup_oom_kills_total", "Number of processes in the container killed by the OOM killer.", nil, nil),
+	}
+}
+
+func (c *cgroupCollector) Describe(ch chan<- *prometheus.Desc) {
+
+	ch <- c.cpuPeriods
+	ch <- c.cpuThrottled
+	ch <- c.cpuThrottledSecs
+	ch <- c.cpuLimit
+	ch <- c.memUsage
+	ch <- c.memLimit
+	ch <- c.memLimitRatio
+	ch <- c.oomKills
+}
+
+func (c *cgroupCollector) Collect(ch chan<- prometheus.Metric) {
+
+	if stat, err := c.readKeyed("cpu.stat"); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.cpuPeriods, prometheus.CounterValue, stat["nr_periods"])
+		ch <- prometheus.MustNewConstMetric(c.cpuThrottled, prometheus.CounterValue, stat["nr_throttled"])
+		ch <- prometheus.MustNewConstMetric(c.cpuThrottledSecs, prometheus.CounterValue, stat["throttled_usec"]/1e6)
+	}
+
+	// cpu.max is "$MAX $PERIOD", $MAX being "max" when unlimited
+	if fields, err := c.readFields("cpu.max"); err == nil && len(fields) == 2 && fields[0] != "max" {
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 == nil && err2 == nil && period > 0 {
+			ch <- prometheus.MustNewConstMetric(c.cpuLimit, prometheus.GaugeValue, quota/period)
+		}
+	}
+
+	usage, usageErr := c.readValue("memory.current")
+	if usageErr == nil {
+		ch <- prometheus.MustNewConstMetric(c.memUsage, prometheus.GaugeValue, usage)
+	}
+
+	if limit, err := c.readValue("memory.max"); err == nil && limit > 0 {
+		ch <- prometheus.MustNewConstMetric(c.memLimit, prometheus.GaugeValue, limit)
+		if usageErr == nil {
+			ch <- prometheus.MustNewConstMetric(c.memLimitRatio, prometheus.GaugeValue, usage/limit)
+		}
+	}
+
+	if events, err := c.readKeyed("memory.events"); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.oomKills, prometheus.CounterValue, events["oom_kill"])
+	}
+}
+
+func (c *cgroupCollector) readFields(name string) ([]string, error) {
+
+	data, err := os.ReadFile(filepath.Join(c.path, name))
+	if err != nil {
+		return nil, err
+
+	}
+
+	return strings.Fields(string(data)), nil
+}
+
+// readValue reads a single value file, "max" (unlimited) is returned as an error.
+func (c *cgroupCollector) readValue(name string) (float64, error) {
+
+	fields, err := c.readFields(name)
+	if err != nil {
+		return 0, err
+
+	}
+	if len(fields) != 1 {
+		return 0, strconv.ErrSyntax
+
+	}
+
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readKeyed reads a flat keyed file, "key value" per line.
+func (c *cgroupCollector) readKeyed(name string) (map[string]float64, error) {
+
+	f, err := os.Open(filepath.Join(c.path, name))
+	if err != nil {
+		return nil, err
+
+	}
+	defer f.Close()
+
+	out := make(map[string]float64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+
+		}
+		if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			out[fields[0]] = v
+
+		}
+	}
+
+	return out, sc.Err()
+}
@@ -0,0 +1,123 @@
+/*****************************************************************************
+*
+*	File			: cli.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Command line flags, overriding the config file and the environment,
+*
+*					:   myapp --gateway http://pushgateway:9091 --job fs_loader_eft \
+*					:         --batch-label eft --iterations 400 --push-interval 30s
+*
+*					: followed by an optional subcommand, archive or backfill, with its own
+*					: flags. Only the flags given on the command line override anything.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+type cliFlags struct {
+	fs *flag.FlagSet
+
+	config       string
+	profile      string
+	gateway      string
+	job          string
+	batch        string
+	iterations   int
+	pushInterval time.Duration
+}
+
+func parseFlags(args []string) (*cliFlags, error) {
+
+	f := &cliFlags{fs: flag.NewFlagSet("myapp", flag.ContinueOnError)}
+	f.fs.StringVar(&f.config, "config", configFile(), "config file, default $PROMWRAP_CONFIG or "+defaultConfigFile)
+	f.fs.StringVar(&f.profile, "profile", "", "profile to run with, see profiles in the config")
+	f.fs.StringVar(&f.gateway, "gateway", "", "pushgateway url")
+	f.fs.StringVar(&f.job, "job", "", "pushgateway job name")
+	f.fs.StringVar(&f.batch, "batch-label", "", "batch label value of the batch metrics, default eft")
+	f.fs.IntVar(&f.iterations, "iterations", 0, "iterations per batch, default 40")
+	f.fs.DurationVar(&f.pushInterval, "push-interval", 0, "minimum time between pushes during a batch, 0 pushes after every iteration")
+	f.fs.Usage = func() {
+		fmt.Fprintln(f.fs.Output(), "Usage: myapp [flags] [archive cat <file> | backfill [-since 720h] | lint-buckets [-url <metrics url>] | calibrate]")
+		f.fs.PrintDefaults()
+	}
+
+	if err := f.fs.Parse(args); err != nil {
+		return nil, err
+
+	}
+
+	switch cmd, _ := f.subcommand(); cmd {
+	case "", "archive", "backfill", "lint-buckets", "calibrate":
+
+	default:
+		err := fmt.Errorf("unknown subcommand %q, expected archive, backfill, lint-buckets or calibrate", cmd)
+		fmt.Fprintln(f.fs.Output(), err)
+		f.fs.Usage()
+		return nil, err
+
+	}
+
+	return f, nil
+}
+
+// apply overrides c with the flags that were given.
+func (f *cliFlags) apply(c *Config) error {
+
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "profile":
+			c.Profile = f.profile
+
+		case "gateway":
+			c.Pushgateway.URL = f.gateway
+
+		case "job":
+			c.Pushgateway.Job = f.job
+
+		case "batch-label":
+			c.Run.Batch = f.batch
+
+		case "iterations":
+			if f.iterations <= 0 {
+				err = fmt.Errorf("--iterations %d, expected at least 1", f.iterations)
+			}
+			c.Run.Iterations = f.iterations
+
+		case "push-interval":
+			if f.pushInterval < 0 {
+				err = fmt.Errorf("--push-interval %s, expected 0 or more", f.pushInterval)
+			}
+			c.Run.PushInterval = f.pushInterval
+
+		}
+	})
+
+	return err
+}
+
+// subcommand returns the subcommand and its arguments, if any.
+func (f *cliFlags) subcommand() (string, []string) {
+
+	args := f.fs.Args()
+	if len(args) == 0 {
+		return "", nil
+
+	}
+
+	return args[0], args[1:]
+}
@@ -0,0 +1,226 @@
+/*****************************************************************************
+*
+*	File			: config.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Wrapper configuration, loaded from a yaml file at startup
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Default config file, can be overridden using the PROMWRAP_CONFIG environment variable.
+const defaultConfigFile = "promwrap.yaml"
+
+type Config struct {
+	Run    RunConfig    `yaml:"run"`
+	ABTest ABTestConfig `yaml:"abtest"`
+
+	// Selected profile, overridden by PROMWRAP_PROFILE or --profile, see profile.go
+	Profile  string                   `yaml:"profile"`
+	Profiles map[string]ProfileConfig `yaml:"profiles"`
+
+	// strict, raw_label_values, caller_labels, redact, suppress, metric_definitions,
+	// derived, run_stats, native_histograms, consistent_gather, mutation_log, pushgateway, mode and pull,
+	// see promwrap/promwrap.go
+	promwrap.Config `yaml:",inline"`
+
+	UTF8Names bool `yaml:"utf8_names"` // Prometheus 3.x UTF-8 metric/label names
+
+	// quantile -> allowed error, adds summaries next to the SQL and API duration
+	// histograms, see fsetl.Metrics.TrackQuantiles
+	SummaryObjectives map[float64]float64 `yaml:"summary_objectives"`
+
+	// Stop exporting the unlabeled fs_etl_complete_timestamp_seconds, once
+	// dashboards/alerts moved to fs_etl_batch_complete_timestamp_seconds{batch}
+	DropLegacyTimestamps bool `yaml:"drop_legacy_timestamps"`
+
+	Calendar    CalendarConfig                 `yaml:"calendar"`
+	Maintenance MaintenanceConfig              `yaml:"maintenance"`
+	Exposition  promwrap.ExpositionConfig      `yaml:"exposition"`
+	Daemon      DaemonConfig                   `yaml:"daemon"`
+	Shutdown    ShutdownConfig                 `yaml:"shutdown"`
+	Database    DatabaseConfig                 `yaml:"database"`
+	Databases   map[string]NamedDatabaseConfig `yaml:"databases"` // source, target, ...
+	RemoteWrite RemoteWriteConfig              `yaml:"remote_write"`
+	Cgroup      CgroupConfig                   `yaml:"cgroup"`
+	LeakCheck   LeakCheckConfig                `yaml:"leak_check"`
+	FileMetrics fsetl.FileMetricsConfig        `yaml:"file_metrics"`
+	Archive     ArchiveConfig                  `yaml:"archive"`
+	Baseline    BaselineConfig                 `yaml:"baseline"`
+	Cost        CostConfig                     `yaml:"cost"`
+}
+
+// RunConfig are the batch parameters
+type RunConfig struct {
+	Batch      string `yaml:"batch"` // batch label value, default eft
+	Iterations int    `yaml:"iterations"`
+	ChunkSize  int    `yaml:"chunk_size"` // records per backup call
+
+	// Minimum time between the pushes during a batch, 0 pushes after every
+	// iteration. The final push of the batch always happens.
+	PushInterval time.Duration `yaml:"push_interval"`
+
+	// Slowest records kept for the job report and fs_etl_slow_record, 0 disables, see slowest.go
+	SlowestRecords int `yaml:"slowest_records"`
+
+	// Minutes of fs_etl_throughput_records per run, later minutes add to the last one, default 60
+	ThroughputMinutes int `yaml:"throughput_minutes"`
+
+	// RFC3339 timestamp or daily clock time the batch is scheduled to start at, for
+	// fs_etl_start_delay_seconds, see startdelay.go
+	ScheduledStart string `yaml:"scheduled_start"`
+
+	scheduled time.Time // the daemon's schedule tick, overrides ScheduledStart
+
+	// Retries of a failed backup call, 0 doesn't retry, and of the whole batch, 0 is
+	// unlimited, the batch fails once they're used up, see retrybudget.go
+	Retries     int `yaml:"retries"`
+	RetryBudget int `yaml:"retry_budget"`
+}
+
+func (r RunConfig) withDefaults() RunConfig {
+
+	if r.Batch == "" {
+		r.Batch = "eft"
+	}
+	if r.Iterations <= 0 {
+		r.Iterations = 40
+	}
+	if r.ChunkSize <= 0 {
+		r.ChunkSize = 42
+	}
+	if r.ThroughputMinutes <= 0 {
+		r.ThroughputMinutes = fsetl.DefaultThroughputMinutes
+	}
+
+	return r
+}
+
+func configFile() string {
+
+	if path := os.Getenv("PROMWRAP_CONFIG"); path != "" {
+		return path
+	}
+	return defaultConfigFile
+}
+
+// Environment variables overriding the config file, for deployments that can't
+// bake endpoints and credentials into the file, eg. Kubernetes
+const (
+	envGatewayURL   = "PROMWRAP_GATEWAY_URL"
+	envJob          = "PROMWRAP_JOB"
+	envPushInterval = "PROMWRAP_PUSH_INTERVAL"
+	envUsername     = "PROMWRAP_USERNAME"
+	envPassword     = "PROMWRAP_PASSWORD"
+	envProfile      = "PROMWRAP_PROFILE"
+	envScheduled    = "PROMWRAP_SCHEDULED_START"
+	envConstLabels  = "PROMWRAP_CONST_LABELS"
+)
+
+// applyEnv overrides the config with the environment variables that are set.
+func (c *Config) applyEnv() error {
+
+	if v := os.Getenv(envGatewayURL); v != "" {
+		c.Pushgateway.URL = v
+	}
+	if v := os.Getenv(envJob); v != "" {
+		c.Pushgateway.Job = v
+	}
+	if v := os.Getenv(envUsername); v != "" {
+		c.Pushgateway.Username = v
+	}
+	if v := os.Getenv(envPassword); v != "" {
+		c.Pushgateway.Password = v
+	}
+
+	if v := os.Getenv(envProfile); v != "" {
+		c.Profile = v
+	}
+	if v := os.Getenv(envScheduled); v != "" {
+		c.Run.ScheduledStart = v
+	}
+
+	if v := os.Getenv(envConstLabels); v != "" {
+		if c.ConstLabels == nil {
+			c.ConstLabels = make(map[string]string)
+		}
+		for _, pair := range strings.Split(v, ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%s=%q, expected name=value pairs like env=prod,region=za", envConstLabels, v)
+
+			}
+			c.ConstLabels[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	if v := os.Getenv(envPushInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%s=%q, expected a duration like 30s", envPushInterval, v)
+
+		}
+		c.Run.PushInterval = d
+	}
+
+	return nil
+}
+
+// loadConfig reads the yaml config file at path. A missing file is not an error,
+// we simply run with the defaults.
+func loadConfig(path string) (Config, error) {
+
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+
+	} else if err != nil {
+		return cfg, fmt.Errorf("reading config %s: %w", path, err)
+
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing config %s: %w", path, err)
+
+	}
+
+	return cfg, nil
+}
+
+// applyNameValidation selects the client_golang metric/label name validation scheme.
+// UTF-8 names need Prometheus 3.x, so we default to the legacy scheme for older servers.
+// This is a process wide setting and has to happen before any goroutines start.
+func applyNameValidation(utf8Names bool) {
+
+	if utf8Names {
+		model.NameValidationScheme = model.UTF8Validation
+		return
+
+	}
+
+	model.NameValidationScheme = model.LegacyValidation
+}
@@ -0,0 +1,66 @@
+/*****************************************************************************
+*
+*	File			: cost.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Estimated cost of a batch run, so finance can see what each feed costs to
+*					: load. The cost model, cost.*, prices
+*
+*					:   CPU seconds		the batch's CPU time, see fsetl/resources.go
+*					:   GB transferred	records x bytes_per_record, the loader doesn't see the
+*					:   				bytes on the wire
+*					:   DB hours		the batch's duration x the databases it has open
+*
+*					: and the total is exported as fs_etl_estimated_cost_dollars{batch}, the
+*					: breakdown is in the job report. All rates 0 disables it.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"time"
+)
+
+type CostConfig struct {
+	CPUSecondCents float64 `yaml:"cpu_second_cents"`
+	GBCents        float64 `yaml:"gb_transferred_cents"`
+	DBHourCents    float64 `yaml:"db_hour_cents"`
+	BytesPerRecord int64   `yaml:"bytes_per_record"` // average record size on the wire
+}
+
+func (c CostConfig) enabled() bool {
+
+	return c.CPUSecondCents > 0 || c.GBCents > 0 || c.DBHourCents > 0
+}
+
+// runCost is a run's estimated cost in dollars.
+type runCost struct {
+	CPU      float64
+	Transfer float64
+	DB       float64
+}
+
+func (r runCost) Total() float64 {
+
+	return r.CPU + r.Transfer + r.DB
+}
+
+// estimate prices a run that used cpuSeconds, loaded records and kept databases open for d.
+func (c CostConfig) estimate(cpuSeconds float64, records int64, d time.Duration, databases int) runCost {
+
+	gb := float64(records*c.BytesPerRecord) / 1e9
+
+	return runCost{
+		CPU:      cpuSeconds * c.CPUSecondCents / 100,
+		Transfer: gb * c.GBCents / 100,
+		DB:       d.Hours() * float64(databases) * c.DBHourCents / 100,
+	}
+}
@@ -0,0 +1,284 @@
+/*****************************************************************************
+*
+*	File			: daemon.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Long running daemon mode, the way fs_loader actually runs. The process
+*					: stays up, exposes /metrics and /healthz and runs the instrumented batch
+*					: whenever triggered: schedule, Postgres NOTIFY, file watch or admin API.
+*					: The admin API (/admin/run, /admin/mutations) is served on admin_listen,
+*					: localhost by default, not with /metrics, anyone who can scrape shouldn't
+*					: be able to start a load. With admin_token set it also wants the header
+*					: "Authorization: Bearer <token>".
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Triggers, used as the "trigger" label on fs_etl_runs_triggered_total
+const (
+	triggerSchedule = "schedule"
+	triggerNotify   = "notify"
+	triggerFile     = "file"
+	triggerAdmin    = "admin"
+)
+
+type DaemonConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Listen        string        `yaml:"listen"`         // /metrics and /healthz
+	AdminListen   string        `yaml:"admin_listen"`   // /admin/*
+	AdminToken    string        `yaml:"admin_token"`    // bearer token /admin/* wants, empty doesn't check
+	Interval      time.Duration `yaml:"interval"`       // schedule trigger, 0 disables
+	NotifyChannel string        `yaml:"notify_channel"` // LISTEN channel on database.dsn
+	WatchFile     string        `yaml:"watch_file"`     // run when this file's mtime changes
+	WatchInterval time.Duration `yaml:"watch_interval"`
+}
+
+type daemon struct {
+	cfg      DaemonConfig
+	dsn      string
+	triggers chan runTrigger
+	run      func(trigger string, scheduled time.Time)
+}
+
+// runTrigger is a queued run, scheduled is the tick of a schedule trigger, zero otherwise.
+type runTrigger struct {
+	name      string
+	scheduled time.Time
+}
+
+// runDaemon blocks until ctx is done, running run() once per trigger. Runs never overlap,
+// triggers arriving while a batch runs are coalesced into one follow up run. A schedule
+// trigger passes its tick as the run's scheduled start, see startdelay.go.
+func runDaemon(ctx context.Context, cfg DaemonConfig, db DatabaseConfig, handler http.Handler, run func(trigger string, scheduled time.Time)) error {
+
+	if cfg.Listen == "" {
+		cfg.Listen = ":9100"
+	}
+	if cfg.AdminListen == "" {
+		cfg.AdminListen = "127.0.0.1:9101"
+	}
+	if cfg.WatchInterval <= 0 {
+		cfg.WatchInterval = 10 * time.Second
+	}
+
+	d := &daemon{
+		cfg:      cfg,
+		dsn:      db.DSN,
+		triggers: make(chan runTrigger, 1),
+		run:      run,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("/admin/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		d.trigger(triggerAdmin, time.Time{})
+		w.WriteHeader(http.StatusAccepted)
+	})
+	admin.Handle("/admin/mutations", m.Mutations)
+
+	// a port we can't bind is fatal, so is either server failing later on
+	errc := make(chan error, 2)
+	srv, err := serveDaemon(cfg.Listen, mux, errc)
+	if err != nil {
+		return err
+
+	}
+	defer srv.Close()
+
+	adminSrv, err := serveDaemon(cfg.AdminListen, d.authorize(admin), errc)
+	if err != nil {
+		return err
+
+	}
+	defer adminSrv.Close()
+
+	if cfg.Interval > 0 {
+		go d.schedule(ctx)
+	}
+
+	if cfg.WatchFile != "" {
+		go d.watch(ctx)
+	}
+
+	if cfg.NotifyChannel != "" {
+		if d.dsn == "" {
+			return fmt.Errorf("daemon notify_channel %s configured without database.dsn", cfg.NotifyChannel)
+
+		}
+		go d.listen(ctx)
+	}
+
+	infof("Daemon listening on %s, admin on %s...\n", cfg.Listen, cfg.AdminListen)
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			adminSrv.Shutdown(shutdown)
+			return srv.Shutdown(shutdown)
+
+		case err := <-errc:
+			return err
+
+		case t := <-d.triggers:
+			d.run(t.name, t.scheduled)
+
+		}
+	}
+}
+
+// serveDaemon binds addr and serves h in the background, a serve error after that goes to errc.
+func serveDaemon(addr string, h http.Handler, errc chan<- error) (*http.Server, error) {
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("daemon listen %s: %w", addr, err)
+
+	}
+
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errc <- fmt.Errorf("daemon http server %s: %w", addr, err)
+		}
+	}()
+
+	return srv, nil
+}
+
+// authorize wraps the admin API, with admin_token set a request needs it as its bearer token.
+func (d *daemon) authorize(h http.Handler) http.Handler {
+
+	if d.cfg.AdminToken == "" {
+		return h
+	}
+
+	want := []byte("Bearer " + d.cfg.AdminToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// trigger queues a run, if one is already queued this one is folded into it.
+func (d *daemon) trigger(name string, scheduled time.Time) {
+
+	select {
+	case d.triggers <- runTrigger{name, scheduled}:
+	default:
+	}
+}
+
+func (d *daemon) schedule(ctx context.Context) {
+
+	t := time.NewTicker(d.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case tick := <-t.C:
+			d.trigger(triggerSchedule, tick)
+
+		}
+	}
+}
+
+func (d *daemon) watch(ctx context.Context) {
+
+	var last time.Time
+	if fi, err := os.Stat(d.cfg.WatchFile); err == nil {
+		last = fi.ModTime()
+	}
+
+	t := time.NewTicker(d.cfg.WatchInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-t.C:
+			fi, err := os.Stat(d.cfg.WatchFile)
+			if err != nil {
+				continue
+
+			}
+			if fi.ModTime().After(last) {
+				last = fi.ModTime()
+				d.trigger(triggerFile, time.Time{})
+
+			}
+		}
+	}
+}
+
+func (d *daemon) listen(ctx context.Context) {
+
+	l := pq.NewListener(d.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			reportFailure("Postgres listener:", err)
+		}
+	})
+	defer l.Close()
+
+	if err := l.Listen(d.cfg.NotifyChannel); err != nil {
+		reportFailure("Could not LISTEN on "+d.cfg.NotifyChannel+":", err)
+		return
+
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-l.Notify:
+			if n != nil { // nil after a reconnect
+				d.trigger(triggerNotify, time.Time{})
+
+			}
+
+		case <-time.After(90 * time.Second):
+			go l.Ping()
+
+		}
+	}
+}
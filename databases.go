@@ -0,0 +1,210 @@
+/*****************************************************************************
+*
+*	File			: databases.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Multiple named Postgres targets, eg. source, target and control, each
+*					: with its own pool. Instrumented handles (fsetl/sqlwrap.go) are obtained by
+*					: name using dbs.Get(), pool stats are exported per db as
+*					: fs_etl_db_pool_*{db}.
+*
+*					: database.dsn, when set, is available as "control", it's the database
+*					: holding our own audit/checkpoint tables. Read queries can be routed to
+*					: a replica using dbs.Reader(), see replica.go.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"myapp/fsetl"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const controlDB = "control"
+
+type NamedDatabaseConfig struct {
+	DSN              string        `yaml:"dsn"`
+	MaxOpenConns     int           `yaml:"max_open_conns"` // 0 is unlimited
+	MaxIdleConns     int           `yaml:"max_idle_conns"` // 0 keeps database/sql's default of 2
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+
+	// Named database serving as this one's read replica, see replica.go
+	Replica       string        `yaml:"replica"`
+	MaxReplicaLag time.Duration `yaml:"max_replica_lag"`
+}
+
+type Databases struct {
+	mu     sync.Mutex
+	dbs    map[string]*fsetl.DB
+	routes map[string]*replicaRoute // primary name -> its replica
+}
+
+func NewDatabases() *Databases {
+
+	return &Databases{dbs: make(map[string]*fsetl.DB), routes: make(map[string]*replicaRoute)}
+}
+
+// Open opens and pings the named pool, appName identifies it in pg_stat_activity,
+// its statements are counted against batch.
+func (d *Databases) Open(ctx context.Context, name string, c NamedDatabaseConfig, appName, batch string) error {
+
+	if c.DSN == "" {
+		return fmt.Errorf("database %s: no dsn", name)
+
+	}
+
+	sqlDB, err := openDB(ctx, withApplicationName(c.DSN, appName))
+	if err != nil {
+		return fmt.Errorf("database %s: %w", name, err)
+
+	}
+
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	if c.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+
+	if err := d.Add(name, fsetl.NewDB(m, sqlDB, batch, c.StatementTimeout)); err != nil {
+		sqlDB.Close()
+		return err
+
+	}
+
+	if c.Replica != "" {
+		d.mu.Lock()
+		d.routes[name] = &replicaRoute{replica: c.Replica, maxLag: c.MaxReplicaLag}
+		d.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Add makes an already opened handle available by name.
+func (d *Databases) Add(name string, db *fsetl.DB) error {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, dup := d.dbs[name]; dup {
+		return fmt.Errorf("database %s configured twice", name)
+
+	}
+	d.dbs[name] = db
+
+	return nil
+}
+
+// Get returns the instrumented handle for the named database.
+func (d *Databases) Get(name string) (*fsetl.DB, error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	db, ok := d.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %s", name)
+
+	}
+
+	return db, nil
+}
+
+func (d *Databases) names() []string {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make([]string, 0, len(d.dbs))
+	for name := range d.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Close closes all pools, except control, which main closes itself.
+func (d *Databases) Close() {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, db := range d.dbs {
+		if name != controlDB {
+			db.Close()
+		}
+	}
+}
+
+// poolCollector exports sql.DBStats per named database.
+type poolCollector struct {
+	dbs *Databases
+
+	maxOpen  *prometheus.Desc
+	open     *prometheus.Desc
+	inUse    *prometheus.Desc
+	idle     *prometheus.Desc
+	waits    *prometheus.Desc
+	waitSecs *prometheus.Desc
+}
+
+func newPoolCollector(dbs *Databases) *poolCollector {
+
+	label := []string{"db"}
+
+	return &poolCollector{
+		dbs:      dbs,
+		maxOpen:  prometheus.NewDesc("fs_etl_db_pool_max_open_connections", "Maximum number of open connections of the pool, 0 is unlimited.", label, nil),
+		open:     prometheus.NewDesc("fs_etl_db_pool_open_connections", "Number of established connections of the pool, in use and idle.", label, nil),
+		inUse:    prometheus.NewDesc("fs_etl_db_pool_in_use_connections", "Number of connections of the pool currently in use.", label, nil),
+		idle:     prometheus.NewDesc("fs_etl_db_pool_idle_connections", "Number of idle connections of the pool.", label, nil),
+		waits:    prometheus.NewDesc("fs_etl_db_pool_wait_count_total", "The number of times a connection had to be waited for.", label, nil),
+		waitSecs: prometheus.NewDesc("fs_etl_db_pool_wait_seconds_total", "Total time spent waiting for a connection, in seconds.", label, nil),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waits
+	ch <- c.waitSecs
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+
+	for _, name := range c.dbs.names() {
+		db, err := c.dbs.Get(name)
+		if err != nil {
+			continue
+
+		}
+
+		s := db.Stats()
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse), name)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle), name)
+		ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(c.waitSecs, prometheus.CounterValue, s.WaitDuration.Seconds(), name)
+	}
+}
@@ -0,0 +1,81 @@
+/*****************************************************************************
+*
+*	File			: exitcode.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Exit code protocol, so orchestrators can branch on the job outcome.
+*
+*					: 0 success
+*					: 1 startup error, bad config, unreachable database, etc.
+*					: 3 partial success, some records failed with data errors
+*					: 4 data errors, no record succeeded
+*					: 5 infrastructure errors during the batch
+*					: 6 push failure, only with pushgateway.failure_policy: fail
+*
+*					: 2 is left out, it's what Go exits with on an unrecovered panic, and
+*					: strict mode panics on purpose, a crash mustn't read as partial success.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"errors"
+
+	"myapp/fsetl"
+)
+
+const (
+	exitSuccess     = 0
+	exitStartup     = 1
+	exitPartial     = 3 // not 2, that's an unrecovered panic
+	exitDataError   = 4
+	exitInfraError  = 5
+	exitPushFailure = 6
+)
+
+// DataError marks a failure caused by the data itself (bad record, constraint
+// violation), anything not wrapped in a DataError is treated as infrastructure.
+type DataError struct {
+	Err error
+}
+
+func (e *DataError) Error() string { return "data error: " + e.Err.Error() }
+func (e *DataError) Unwrap() error { return e.Err }
+
+func isDataError(err error) bool {
+
+	var de *DataError
+
+	return errors.As(err, &de)
+}
+
+// runResult is what mRun hands back, per iteration outcomes by class.
+type runResult struct {
+	fsetl.Outcome                    // succeeded iterations, errors by class, the last error
+	Records       int64              // records backed up by the successful iterations
+	Slowest       []slowRecord       // slowest first, see slowest.go
+	P95           map[string]float64 // seconds per phase, see baseline.go
+	Regressions   []regression       // phases slower than their baseline
+	Cost          *runCost           // nil without a cost model, see cost.go
+	Throughput    []float64          // records per minute of the run, see fsetl/throughput.go
+}
+
+func (r *runResult) fail(err error) {
+
+	if isDataError(err) {
+		r.DataErrors++
+
+	} else {
+		r.InfraErrors++
+
+	}
+	r.Err = err
+}
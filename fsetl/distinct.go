@@ -0,0 +1,110 @@
+/*****************************************************************************
+*
+*	File			: distinct.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Distinct business entities loaded by the batch, job.CountDistinct("accounts",
+*					: id), exported as the approximate gauge
+*
+*					:   fs_etl_distinct_entities{batch="eft",entity="accounts"} 48211
+*
+*					: answering "how many unique accounts were touched" after every load. Each
+*					: entity is a HyperLogLog (promwrap/hll.go), 16KB however many ids, about
+*					: 0.8% off for large counts. The counts are reset when the batch starts again.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"math"
+	"sync"
+
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// distinctCollector estimates the distinct counts at gather time, an estimate walks
+// all the registers, too much to do on every id.
+type distinctCollector struct {
+	desc *prometheus.Desc
+
+	mu       sync.Mutex
+	counters map[[2]string]*promwrap.HyperLogLog // batch, entity
+}
+
+func newDistinctCollector() *distinctCollector {
+
+	return &distinctCollector{
+		desc:     prometheus.NewDesc("fs_etl_distinct_entities", "Approximate number of distinct entities loaded by the FS ETL batch, see job.CountDistinct.", []string{promwrap.BatchLabel, "entity"}, nil),
+		counters: make(map[[2]string]*promwrap.HyperLogLog),
+	}
+}
+
+func (c *distinctCollector) Describe(ch chan<- *prometheus.Desc) {
+
+	ch <- c.desc
+}
+
+func (c *distinctCollector) Collect(ch chan<- prometheus.Metric) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, hll := range c.counters {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, math.Round(hll.Estimate()), key[0], key[1])
+	}
+}
+
+func (c *distinctCollector) add(batch, entity, id string) {
+
+	key := [2]string{batch, entity}
+
+	c.mu.Lock()
+	hll := c.counters[key]
+	if hll == nil {
+		hll = promwrap.NewHyperLogLog(promwrap.DefaultHLLPrecision)
+		c.counters[key] = hll
+	}
+	c.mu.Unlock()
+
+	hll.Add(id)
+}
+
+func (c *distinctCollector) reset(batch string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.counters {
+		if key[0] == batch {
+			delete(c.counters, key)
+		}
+	}
+}
+
+// CountDistinct counts id as one of the batch's entity, eg. CountDistinct("accounts", acc.ID).
+func (j *Job) CountDistinct(entity, id string) error {
+
+	if err := j.m.Check(j.m.distinct); err != nil {
+		return j.m.Misuse(err)
+
+	}
+
+	if j.m.IsSealed(j.batch) {
+		return j.m.Misuse(j.m.Late(j.batch, "job.CountDistinct"))
+
+	}
+
+	j.m.distinct.add(j.batch, j.m.Sanitize([]string{entity})[0], id)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+/*****************************************************************************
+*
+*	File			: files.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Per-file metrics without a label per file name. fs_loader processes an
+*					: unbounded stream of files, so the file label is a hash bucket of the
+*					: file's base name, file_metrics.buckets of them (default 64). Enough to
+*					: spot the one slow or failing file, the cardinality stays fixed.
+*
+*					: The bucket is the FNV-1a hash of the base name modulo buckets, stable
+*					: across runs and hosts, so a file name can be mapped to its bucket
+*					: offline. Changing the bucket count reshuffles every file.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const defaultFileBuckets = 64
+
+type FileMetricsConfig struct {
+	Buckets int `yaml:"buckets"` // distinct file label values, default 64
+}
+
+// NumBuckets returns the number of file label values, for Metrics.FileBuckets.
+func (c FileMetricsConfig) NumBuckets() int {
+
+	if c.Buckets <= 0 {
+		return defaultFileBuckets
+	}
+
+	return c.Buckets
+}
+
+// fileBucket returns the file label value for file, the zero padded bucket number.
+func (m *Metrics) fileBucket(file string) string {
+
+	buckets := m.FileBuckets
+	if buckets <= 0 {
+		buckets = defaultFileBuckets
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(filepath.Base(file)))
+
+	return fmt.Sprintf("%0*d", len(strconv.Itoa(buckets-1)), h.Sum32()%uint32(buckets))
+}
+
+// ObserveFile records one processed file of batch, its records, duration and whether it failed.
+func (m *Metrics) ObserveFile(batch, file string, records int, d time.Duration, err error) {
+
+	bucket := m.fileBucket(file)
+
+	m.Add(m.FileRecords, float64(records), batch, bucket)
+	m.Observe(m.FileDuration, d, batch, bucket)
+	if err != nil {
+		m.Inc(m.FileErrors, batch, bucket)
+	}
+}
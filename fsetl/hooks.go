@@ -0,0 +1,91 @@
+/*****************************************************************************
+*
+*	File			: hooks.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Warm-up and cool-down hooks, run around every batch, eg. truncate the
+*					: staging tables before, refresh materialized views after. Each hook is
+*					: timed into fs_etl_hook_duration_seconds{hook} so setup/teardown costs
+*					: don't disappear into the record processing numbers.
+*
+*					: OnStart hooks run in registration order, the first failure fails the
+*					: batch without processing any records. OnFinish hooks all run, even
+*					: when the batch or one of the other hooks failed.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type HookFunc func(ctx context.Context) error
+
+type hook struct {
+	name string
+	fn   HookFunc
+}
+
+var (
+	startHooks  []hook
+	finishHooks []hook
+)
+
+// OnStart registers fn to run before each batch, name is used as the hook label.
+func OnStart(name string, fn HookFunc) {
+
+	startHooks = append(startHooks, hook{name, fn})
+}
+
+// OnFinish registers fn to run after each batch, name is used as the hook label.
+func OnFinish(name string, fn HookFunc) {
+
+	finishHooks = append(finishHooks, hook{name, fn})
+}
+
+// RunStartHooks runs the OnStart hooks in order, up to the first failure, and returns its error.
+func (j *Job) RunStartHooks(ctx context.Context) error {
+
+	return j.runHooks(ctx, startHooks, true)
+}
+
+// RunFinishHooks runs all the OnFinish hooks in order and returns the first error.
+func (j *Job) RunFinishHooks(ctx context.Context) error {
+
+	return j.runHooks(ctx, finishHooks, false)
+}
+
+// runHooks runs hooks in order and returns the first error, with stopOnError
+// the remaining hooks are skipped after a failure.
+func (j *Job) runHooks(ctx context.Context, hooks []hook, stopOnError bool) error {
+
+	var first error
+	for _, h := range hooks {
+		start := time.Now()
+		err := h.fn(ctx)
+		j.m.Set(j.m.HookDuration, time.Since(start).Seconds(), h.name)
+
+		if err != nil {
+			j.m.Inc(j.m.HookFailures, h.name)
+			if first == nil {
+				first = fmt.Errorf("hook %s: %w", h.name, err)
+			}
+			if stopOnError {
+				break
+
+			}
+		}
+	}
+
+	return first
+}
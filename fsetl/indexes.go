@@ -0,0 +1,152 @@
+/*****************************************************************************
+*
+*	File			: indexes.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Helpers for the drop indexes / bulk load / recreate indexes pattern, each
+*					: index operation is timed into fs_etl_index_op_seconds{index,op} and
+*					: failures counted in fs_etl_index_op_failures_total{index,op}, so the real
+*					: cost of index maintenance during a load shows up next to the load itself.
+*
+*					: Indexes backing a constraint (primary keys, unique constraints) are left
+*					: alone, they can't be dropped with DROP INDEX.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"myapp/promwrap"
+)
+
+// Index operations, the "op" label
+const (
+	indexOpDrop   = "drop"
+	indexOpCreate = "create"
+)
+
+// IndexDef is a dropped index, Def being the CREATE INDEX statement that recreates it.
+type IndexDef struct {
+	Schema string
+	Name   string
+	Def    string
+}
+
+func (ix IndexDef) qualified() string {
+
+	return ix.Schema + "." + ix.Name
+}
+
+// DropIndexes drops the droppable indexes on table, optionally schema qualified,
+// and returns their definitions for RecreateIndexes.
+func (m *Metrics) DropIndexes(ctx context.Context, db *sql.DB, table string) ([]IndexDef, error) {
+
+	schema, name := "", table
+	if s, t, ok := strings.Cut(table, "."); ok {
+		schema, name = s, t
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT i.schemaname, i.indexname, i.indexdef
+		FROM pg_indexes i
+		WHERE i.schemaname = COALESCE(NULLIF($1, ''), current_schema()) AND i.tablename = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM pg_constraint c
+			WHERE c.conindid = (quote_ident(i.schemaname) || '.' || quote_ident(i.indexname))::regclass
+		)
+		ORDER BY i.indexname`, schema, name)
+	if err != nil {
+		return nil, err
+
+	}
+
+	var defs []IndexDef
+	for rows.Next() {
+		var ix IndexDef
+		if err := rows.Scan(&ix.Schema, &ix.Name, &ix.Def); err != nil {
+			rows.Close()
+			return nil, err
+
+		}
+		defs = append(defs, ix)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+
+	}
+
+	for i, ix := range defs {
+		start := time.Now()
+		if _, err := db.ExecContext(ctx, "DROP INDEX "+quoteQualified(ix.qualified())); err != nil {
+			m.Inc(m.IndexFailures, ix.qualified(), indexOpDrop)
+			// hand back what we did drop, so the caller can still put it back
+			return defs[:i], fmt.Errorf("dropping index %s: %w", ix.qualified(), err)
+
+		}
+		m.Set(m.IndexOp, time.Since(start).Seconds(), ix.qualified(), indexOpDrop)
+	}
+
+	return defs, nil
+}
+
+// RecreateIndexes recreates the indexes dropped by DropIndexes. All of them are
+// attempted, the first error is returned.
+func (m *Metrics) RecreateIndexes(ctx context.Context, db *sql.DB, defs []IndexDef) error {
+
+	var first error
+	for _, ix := range defs {
+		start := time.Now()
+		if _, err := db.ExecContext(ctx, ix.Def); err != nil {
+			m.Inc(m.IndexFailures, ix.qualified(), indexOpCreate)
+			if first == nil {
+				first = fmt.Errorf("recreating index %s: %w", ix.qualified(), err)
+			}
+			continue
+
+		}
+		m.Set(m.IndexOp, time.Since(start).Seconds(), ix.qualified(), indexOpCreate)
+	}
+
+	return first
+}
+
+// WithIndexesDropped runs load with the indexes on table dropped, they are recreated
+// afterwards even when load fails.
+func (m *Metrics) WithIndexesDropped(ctx context.Context, db *sql.DB, table string, load func(ctx context.Context) error) error {
+
+	defs, err := m.DropIndexes(ctx, db, table)
+	if err != nil {
+		if rerr := m.RecreateIndexes(ctx, db, defs); rerr != nil {
+			promwrap.ReportFailure("Could not restore indexes:", promwrap.RedactErr(rerr))
+		}
+		return err
+
+	}
+
+	loadErr := load(ctx)
+
+	if err := m.RecreateIndexes(ctx, db, defs); err != nil {
+		if loadErr != nil {
+			promwrap.ReportFailure("Could not restore indexes:", promwrap.RedactErr(err))
+			return loadErr
+
+		}
+		return err
+
+	}
+
+	return loadErr
+}
@@ -0,0 +1,117 @@
+/*****************************************************************************
+*
+*	File			: locks.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Lock wait and deadlock metrics for the loader's own backends, contention
+*					: with the OLTP traffic is our #1 cause of slow loads.
+*
+*					: While a batch runs pg_locks/pg_stat_activity are sampled at
+*					: database.lock_sample_interval, our backends being the ones connected
+*					: with our application_name (database.application_name, fs_etl by default).
+*					: Every sample adds interval x waiting backends to
+*					: fs_etl_pg_lock_wait_seconds_total{locktype}, so it's an estimate, good to
+*					: the sample interval. Deadlocks are counted off the errors our queries get,
+*					: see m.countDeadlock().
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"myapp/promwrap"
+
+	"github.com/lib/pq"
+)
+
+const deadlockDetected = "40P01"
+
+// StartLockSampler samples lock waits of appName's backends every interval until
+// the returned stop func is called.
+func (m *Metrics) StartLockSampler(db *sql.DB, appName string, interval time.Duration) (stop func()) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-t.C:
+				if err := m.sampleLocks(ctx, db, appName, interval); err != nil && ctx.Err() == nil {
+					promwrap.ReportFailure("Could not sample pg_locks:", promwrap.RedactErr(err))
+				}
+
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func (m *Metrics) sampleLocks(ctx context.Context, db *sql.DB, appName string, interval time.Duration) error {
+
+	rows, err := db.QueryContext(ctx, `SELECT l.locktype, count(DISTINCT l.pid)
+		FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE NOT l.granted AND a.application_name = $1
+		GROUP BY l.locktype`, appName)
+	if err != nil {
+		return err
+
+	}
+	defer rows.Close()
+
+	// locktypes without waiters this time round go back to 0
+	m.LockWaiters.Reset()
+
+	for rows.Next() {
+		var locktype string
+		var waiters int
+		if err := rows.Scan(&locktype, &waiters); err != nil {
+			return err
+
+		}
+		m.Set(m.LockWaiters, float64(waiters), locktype)
+		m.Add(m.LockWait, float64(waiters)*interval.Seconds(), locktype)
+	}
+
+	return rows.Err()
+}
+
+// countDeadlock counts err in fs_etl_pg_deadlocks_total if Postgres aborted our
+// statement to break a deadlock, and reports whether it did.
+func (m *Metrics) countDeadlock(err error) bool {
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == deadlockDetected {
+		m.Inc(m.Deadlocks)
+		return true
+
+	}
+
+	return false
+}
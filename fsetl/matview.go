@@ -0,0 +1,79 @@
+/*****************************************************************************
+*
+*	File			: matview.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Instrumented REFRESH MATERIALIZED VIEW [CONCURRENTLY]. The post load
+*					: refreshes dominate several of our pipelines, so we record per view
+*					: fs_etl_matview_refresh_seconds, fs_etl_matview_lock_wait_seconds and
+*					: fs_etl_matview_rows{stage="before|after"}.
+*
+*					: The refresh runs in its own transaction, the lock the refresh needs
+*					: (EXCLUSIVE when concurrent, ACCESS EXCLUSIVE otherwise) is taken
+*					: explicitly first so that the time spent waiting for it can be measured.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RefreshMaterializedView refreshes view, optionally schema qualified, eg. as an OnFinish hook.
+// A view created WITH NO DATA can't be counted, its "before" row count is simply not recorded.
+func (m *Metrics) RefreshMaterializedView(ctx context.Context, db *sql.DB, view string, concurrently bool) error {
+
+	quoted := quoteQualified(view)
+
+	if n, err := countRows(ctx, db, quoted); err == nil {
+		m.Set(m.MatviewRows, float64(n), view, "before")
+	}
+
+	start := time.Now()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+
+	}
+	defer tx.Rollback()
+
+	lockMode, refresh := "ACCESS EXCLUSIVE", "REFRESH MATERIALIZED VIEW "+quoted
+	if concurrently {
+		lockMode, refresh = "EXCLUSIVE", "REFRESH MATERIALIZED VIEW CONCURRENTLY "+quoted
+	}
+
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE "+quoted+" IN "+lockMode+" MODE"); err != nil {
+		return fmt.Errorf("locking %s: %w", view, err)
+
+	}
+	m.Set(m.MatviewLockWait, time.Since(start).Seconds(), view)
+
+	if _, err := tx.ExecContext(ctx, refresh); err != nil {
+		return fmt.Errorf("refreshing %s: %w", view, err)
+
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+
+	}
+	m.Set(m.MatviewRefresh, time.Since(start).Seconds(), view)
+
+	if n, err := countRows(ctx, db, quoted); err == nil {
+		m.Set(m.MatviewRows, float64(n), view, "after")
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+/*****************************************************************************
+*
+*	File			: meta.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Free-form batch metadata, job.SetMeta("source_file", "eft_20261015.csv"),
+*					: exported as the info metric
+*
+*					:   fs_etl_job_info{batch="eft",operator="george",source_file="..."} 1
+*
+*					: so a run is identifiable beyond its batch label, eg. the upstream batch
+*					: id or who kicked it off. The keys become label names, so they have to be
+*					: valid label names. The metadata is reset when the batch starts again.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const jobInfoFamily = "fs_etl_job_info"
+
+// jobInfoCollector exports the metadata of every batch, the label names differ per
+// batch so it's an unchecked collector, see prometheus.Collector.
+// The batch and values are kept as given and go through sanitize (Metrics.Sanitize) on
+// the way out, as every other batch labelled series does.
+type jobInfoCollector struct {
+	mu       sync.Mutex
+	meta     map[string]map[string]string // batch -> key -> value
+	sanitize func([]string) []string
+}
+
+func newJobInfoCollector(sanitize func([]string) []string) *jobInfoCollector {
+
+	return &jobInfoCollector{meta: make(map[string]map[string]string), sanitize: sanitize}
+}
+
+func (c *jobInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *jobInfoCollector) Collect(ch chan<- prometheus.Metric) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for batch, meta := range c.meta {
+		keys := make([]string, 0, len(meta))
+		for k := range meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		values := []string{batch}
+		for _, k := range keys {
+			values = append(values, meta[k])
+		}
+
+		desc := prometheus.NewDesc(jobInfoFamily, "Free-form metadata of the FS ETL batch, see job.SetMeta.", append([]string{promwrap.BatchLabel}, keys...), nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, c.sanitize(values)...)
+	}
+}
+
+func (c *jobInfoCollector) set(batch, key, value string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.meta[batch] == nil {
+		c.meta[batch] = make(map[string]string)
+	}
+	c.meta[batch][key] = value
+}
+
+func (c *jobInfoCollector) reset(batch string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.meta, batch)
+}
+
+// SetMeta adds key=value to the batch's fs_etl_job_info, setting a key again replaces its value.
+func (j *Job) SetMeta(key, value string) error {
+
+	if err := j.m.Check(j.m.jobInfo); err != nil {
+		return j.m.Misuse(err)
+
+	}
+
+	if j.m.IsSealed(j.batch) {
+		return j.m.Misuse(j.m.Late(j.batch, "job.SetMeta"))
+
+	}
+
+	if !model.LabelName(key).IsValid() || strings.HasPrefix(key, "__") || key == promwrap.BatchLabel {
+		return j.m.Misuse(fmt.Errorf("%s: invalid metadata key %q", jobInfoFamily, key))
+
+	}
+
+	j.m.jobInfo.set(j.batch, key, value)
+
+	return nil
+}
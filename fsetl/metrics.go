@@ -0,0 +1,421 @@
+/*****************************************************************************
+*
+*	File			: metrics.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The fs_etl metrics, moved out of main so fs_loader and the example share
+*					: them. Updates go through the embedded promwrap.Metrics, see
+*					: promwrap/strict.go, the job API (state.go) and the Postgres helpers
+*					: (sqlwrap.go, matview.go etc.) update them through *Metrics.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"time"
+
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the fs_etl metrics, created by NewMetrics.
+type Metrics struct {
+	*promwrap.Metrics // checked updates, m.Observe(m.SQLDuration, ...)
+
+	CompletionTime prometheus.Gauge // legacy, see BatchCompleted
+	SuccessTime    prometheus.Gauge // legacy, see BatchSucceeded
+	Duration       prometheus.Gauge
+	Records        prometheus.Gauge
+	Maintenance    prometheus.Gauge
+	Leaked         prometheus.Gauge
+
+	Info           *prometheus.GaugeVec
+	SQLDuration    *prometheus.HistogramVec
+	RecDuration    *prometheus.HistogramVec
+	RecWait        *prometheus.HistogramVec
+	APIDuration    *prometheus.HistogramVec
+	ReqProcessed   *prometheus.CounterVec
+	RunsSkipped    *prometheus.CounterVec
+	RunsTriggered  *prometheus.CounterVec
+	StartupPhase   *prometheus.GaugeVec
+	CPUSeconds     *prometheus.CounterVec
+	AllocBytes     *prometheus.CounterVec
+	JobState       *prometheus.GaugeVec
+	BatchCompleted *prometheus.GaugeVec
+	BatchSucceeded *prometheus.GaugeVec
+	HookDuration   *prometheus.GaugeVec
+	HookFailures   *prometheus.CounterVec
+	FileRecords    *prometheus.CounterVec
+	FileErrors     *prometheus.CounterVec
+	FileDuration   *prometheus.HistogramVec
+
+	MatviewRefresh   *prometheus.GaugeVec
+	MatviewLockWait  *prometheus.GaugeVec
+	MatviewRows      *prometheus.GaugeVec
+	IndexOp          *prometheus.GaugeVec
+	IndexFailures    *prometheus.CounterVec
+	PartitionOp      *prometheus.GaugeVec
+	PartitionOps     *prometheus.CounterVec
+	LockWaiters      *prometheus.GaugeVec
+	LockWait         *prometheus.CounterVec
+	Deadlocks        *prometheus.CounterVec
+	SQLTimeouts      *prometheus.CounterVec
+	SQLCancellations *prometheus.CounterVec
+	ReplicaLag       *prometheus.GaugeVec
+	ReadRoutes       *prometheus.CounterVec
+	PerfRegression   *prometheus.GaugeVec
+	EstimatedCost    *prometheus.GaugeVec
+	StartDelay       *prometheus.GaugeVec
+	RetryBudget      *prometheus.GaugeVec
+	RetryRemaining   *prometheus.GaugeVec
+	jobInfo          *jobInfoCollector    // job.SetMeta, see meta.go
+	distinct         *distinctCollector   // job.CountDistinct, see distinct.go
+	throughput       *throughputCollector // records per minute, see throughput.go
+
+	// nil unless summary_objectives is set, see TrackQuantiles
+	sqlQuantiles *prometheus.SummaryVec
+	apiQuantiles *prometheus.SummaryVec
+
+	FileBuckets int // file label values, see files.go
+}
+
+// NewMetrics creates the fs_etl metrics and registers them through pm.
+func NewMetrics(pm *promwrap.Metrics) *Metrics {
+
+	m := &Metrics{
+		Metrics: pm,
+
+		///////////////////////////////////////////////////////////////////
+		// Example metrics
+		CompletionTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_complete_timestamp_seconds",
+			Help: "The timestamp of the last completion of a FS ETL job, successful or not.",
+		}),
+
+		SuccessTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_success_timestamp_seconds",
+			Help: "The timestamp of the last successful completion of a FS ETL job.",
+		}),
+
+		Duration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_duration_seconds",
+			Help: "The duration of the last FS ETL job in seconds.",
+		}),
+
+		Records: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_records_processed",
+			Help: "The number of records processed in the last FS ETL job.",
+		}),
+
+		Maintenance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_maintenance_mode",
+			Help: "1 while the FS ETL job runs inside a planned maintenance window, 0 otherwise.",
+		}),
+
+		Leaked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_leaked_goroutines",
+			Help: "Number of goroutines started during the last FS ETL batch run and still running after it.",
+		}),
+
+		///////////////////////////////////////////////////////////////////
+		// My wrapper, for my metrics from my app
+		Info: prometheus.NewGaugeVec(prometheus.GaugeOpts{ // Shows value, can go up and down
+			Name: "txn_count",
+			Help: "The number of records discovered to be processed for FS ETL job",
+		}, []string{"batch"}),
+
+		//
+		SQLDuration: prometheus.NewHistogramVec(pm.HistogramOpts(prometheus.HistogramOpts{ // used to store timed values
+			Name: "fs_sql_duration_seconds",
+			Help: "Duration of the FS ETL sql requests in seconds",
+			// 4 times larger apdex status
+			// Buckets: prometheus.ExponentialBuckets(0.1, 1.5, 5),
+			// Buckets: prometheus.LinearBuckets(0.1, 5, 15),
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 100},
+		}), []string{"batch"}),
+
+		APIDuration: prometheus.NewHistogramVec(pm.HistogramOpts(prometheus.HistogramOpts{
+			Name:    "fs_api_duration_seconds",
+			Help:    "Duration of the FS ETL api requests in seconds",
+			Buckets: []float64{0.00001, 0.000015, 0.00002, 0.000025, 0.00003},
+		}), []string{"batch"}),
+
+		RecDuration: prometheus.NewHistogramVec(pm.HistogramOpts(prometheus.HistogramOpts{
+			Name:    "fs_etl_operations_seconds",
+			Help:    "Duration of the entire FS ETL requests in seconds, throttling excluded",
+			Buckets: []float64{0.001, 0.0015, 0.002, 0.0025, 0.01},
+		}), []string{"batch"}),
+
+		RecWait: prometheus.NewHistogramVec(pm.HistogramOpts(prometheus.HistogramOpts{
+			Name:    "fs_etl_operations_wait_seconds",
+			Help:    "Time the FS ETL requests spent throttled in seconds, see fs_etl_operations_seconds for the work",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5},
+		}), []string{"batch"}),
+
+		ReqProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{ // can only go up/increment, but usefull combined with rate, resets to zero at restart.
+			Name: "fs_etl_operations_total",
+			Help: "The number of records processed for the FS ETL job.",
+		}, []string{"batch"}),
+
+		RunsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_runs_skipped_total",
+			Help: "The number of FS ETL runs skipped as per the business calendar.",
+		}, []string{"reason"}),
+
+		RunsTriggered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_runs_triggered_total",
+			Help: "The number of FS ETL runs started by the daemon, by trigger.",
+		}, []string{"trigger"}),
+
+		StartupPhase: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_startup_phase_seconds",
+			Help: "Duration of each startup phase of the FS ETL job in seconds.",
+		}, []string{"phase"}),
+
+		CPUSeconds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_cpu_seconds_total",
+			Help: "CPU time used by the process while the FS ETL batch ran, in seconds.",
+		}, []string{"batch"}),
+
+		AllocBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_alloc_bytes_total",
+			Help: "Heap bytes allocated by the process while the FS ETL batch ran.",
+		}, []string{"batch"}),
+
+		JobState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_job_state",
+			Help: "Lifecycle state of the FS ETL batch, 1 for the current state, 0 otherwise.",
+		}, []string{"batch", "state"}),
+
+		BatchCompleted: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_batch_complete_timestamp_seconds",
+			Help: "The timestamp of the last completion of the FS ETL batch, successful or not.",
+		}, []string{"batch"}),
+
+		BatchSucceeded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_batch_success_timestamp_seconds",
+			Help: "The timestamp of the last successful completion of the FS ETL batch.",
+		}, []string{"batch"}),
+
+		HookDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_hook_duration_seconds",
+			Help: "Duration of the last run of each FS ETL OnStart/OnFinish hook in seconds.",
+		}, []string{"hook"}),
+
+		HookFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_hook_failures_total",
+			Help: "The number of failed FS ETL OnStart/OnFinish hook runs.",
+		}, []string{"hook"}),
+
+		FileRecords: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_file_records_total",
+			Help: "The number of records processed per file, by file hash bucket, see files.go.",
+		}, []string{"batch", "file"}),
+
+		FileErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_file_errors_total",
+			Help: "The number of files that failed processing, by file hash bucket.",
+		}, []string{"batch", "file"}),
+
+		FileDuration: prometheus.NewHistogramVec(pm.HistogramOpts(prometheus.HistogramOpts{
+			Name:    "fs_etl_file_duration_seconds",
+			Help:    "Duration of processing a file in seconds, by file hash bucket.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}), []string{"batch", "file"}),
+
+		MatviewRefresh: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_matview_refresh_seconds",
+			Help: "Duration of the last refresh of each materialized view in seconds, lock wait included.",
+		}, []string{"view"}),
+
+		MatviewLockWait: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_matview_lock_wait_seconds",
+			Help: "Time the last refresh of each materialized view waited for its lock in seconds.",
+		}, []string{"view"}),
+
+		MatviewRows: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_matview_rows",
+			Help: "Number of rows in each materialized view, before and after its last refresh.",
+		}, []string{"view", "stage"}),
+
+		IndexOp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_index_op_seconds",
+			Help: "Duration of the last drop/create of each index around a bulk load in seconds.",
+		}, []string{"index", "op"}),
+
+		IndexFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_index_op_failures_total",
+			Help: "The number of failed index drops/creates around bulk loads.",
+		}, []string{"index", "op"}),
+
+		PartitionOp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_partition_op_seconds",
+			Help: "Duration of the last batch's partition operations per table in seconds.",
+		}, []string{"table", "op"}),
+
+		PartitionOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_partition_ops_total",
+			Help: "The number of partitions created, detached and archived per table.",
+		}, []string{"table", "op"}),
+
+		LockWaiters: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_pg_lock_waiters",
+			Help: "Number of the loader's Postgres backends waiting for a lock at the last sample.",
+		}, []string{"locktype"}),
+
+		LockWait: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_pg_lock_wait_seconds_total",
+			Help: "Estimated time the loader's Postgres backends spent waiting for locks, in seconds.",
+		}, []string{"locktype"}),
+
+		Deadlocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_pg_deadlocks_total",
+			Help: "The number of the loader's statements aborted by Postgres to break a deadlock.",
+		}, nil),
+
+		SQLTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_sql_timeouts_total",
+			Help: "The number of FS ETL sql requests that hit their statement timeout.",
+		}, []string{"batch"}),
+
+		SQLCancellations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_sql_cancellations_total",
+			Help: "The number of FS ETL sql requests canceled before completing, timeouts excluded.",
+		}, []string{"batch"}),
+
+		ReplicaLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_db_replica_lag_seconds",
+			Help: "Replication lag of each read replica at its last measurement, in seconds.",
+		}, []string{"db"}),
+
+		ReadRoutes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_db_read_routes_total",
+			Help: "The number of read handles handed out per database, by route taken.",
+		}, []string{"db", "route"}),
+
+		PerfRegression: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_perf_regression",
+			Help: "1 when the phase's p95 duration in the last FS ETL batch regressed against its rolling baseline, 0 otherwise.",
+		}, []string{"phase"}),
+
+		EstimatedCost: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_estimated_cost_dollars",
+			Help: "Estimated cost of the last FS ETL batch run in dollars, as per the cost model.",
+		}, []string{"batch"}),
+
+		StartDelay: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_start_delay_seconds",
+			Help: "How long after its scheduled start the last FS ETL batch run started, in seconds.",
+		}, []string{"batch"}),
+
+		RetryBudget: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_retry_budget",
+			Help: "Retries the FS ETL batch may make in total, run.retry_budget.",
+		}, []string{"batch"}),
+
+		RetryRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_retry_budget_remaining",
+			Help: "Retries the FS ETL batch has left, the batch fails once it reaches 0 and another call fails.",
+		}, []string{"batch"}),
+
+		jobInfo:    newJobInfoCollector(pm.Sanitize),
+		distinct:   newDistinctCollector(),
+		throughput: newThroughputCollector(DefaultThroughputMinutes),
+	}
+
+	// Note that SuccessTime is not registered, see finished() in state.go. CompletionTime,
+	// duration and records weren't either in the original example, they are since checked
+	// updates report updates of unregistered metrics, see the README's Metrics section.
+	m.Register(m.CompletionTime, m.Duration, m.Records, m.Maintenance, m.Leaked)
+	m.Register(m.Info, m.SQLDuration, m.APIDuration, m.RecDuration, m.RecWait, m.ReqProcessed, m.RunsSkipped, m.RunsTriggered, m.StartupPhase, m.CPUSeconds, m.AllocBytes, m.JobState, m.BatchCompleted, m.BatchSucceeded, m.HookDuration, m.HookFailures)
+	m.Register(m.MatviewRefresh, m.MatviewLockWait, m.MatviewRows, m.IndexOp, m.IndexFailures, m.PartitionOp, m.PartitionOps, m.LockWaiters, m.LockWait, m.Deadlocks, m.SQLTimeouts, m.SQLCancellations, m.ReplicaLag, m.ReadRoutes, m.PerfRegression, m.EstimatedCost, m.StartDelay, m.RetryBudget, m.RetryRemaining, m.jobInfo)
+	m.Register(m.FileRecords, m.FileErrors, m.FileDuration, m.distinct, m.throughput)
+
+	return m
+}
+
+// TrackQuantiles adds summaries with objectives next to the SQL and API duration histograms.
+func (m *Metrics) TrackQuantiles(objectives map[float64]float64) error {
+
+	if err := promwrap.ValidateObjectives(objectives); err != nil {
+		return err
+
+	}
+
+	m.sqlQuantiles = promwrap.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "fs_sql_duration_quantile_seconds",
+		Help:       "Quantiles of the duration of the FS ETL sql requests in seconds, see fs_sql_duration_seconds",
+		Objectives: objectives,
+	}, []string{"batch"})
+
+	m.apiQuantiles = promwrap.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "fs_api_duration_quantile_seconds",
+		Help:       "Quantiles of the duration of the FS ETL api requests in seconds, see fs_api_duration_seconds",
+		Objectives: objectives,
+	}, []string{"batch"})
+
+	m.Register(m.sqlQuantiles, m.apiQuantiles)
+
+	return nil
+}
+
+// ObserveSQL records a sql request's duration, in the summary as well if tracked.
+func (m *Metrics) ObserveSQL(d time.Duration, batch string) {
+
+	m.Observe(m.SQLDuration, d, batch)
+	if m.sqlQuantiles != nil {
+		m.ObserveQuantiles(m.sqlQuantiles, d, batch)
+	}
+}
+
+// ObserveAPI records an api request's duration, see ObserveSQL. The record's id goes
+// along as the exemplar, so a slow bucket leads to the record.
+func (m *Metrics) ObserveAPI(d time.Duration, batch, record string) {
+
+	if record != "" {
+		m.ObserveWithExemplar(m.APIDuration, d, prometheus.Labels{"record_id": record}, batch)
+
+	} else {
+		m.Observe(m.APIDuration, d, batch)
+
+	}
+	if m.apiQuantiles != nil {
+		m.ObserveQuantiles(m.apiQuantiles, d, batch)
+	}
+}
+
+// StartTimer starts a timer for the batch's phase, sql, api, work or wait, observed into
+// the phase's duration metrics,
+//
+//	t := m.StartTimer("sql", job.batch)
+//	defer t.ObserveDuration()
+//
+// Any other phase is taken as the name of a defined histogram, see promwrap/timer.go.
+func (m *Metrics) StartTimer(phase, batch string) *promwrap.Timer {
+
+	switch phase {
+	case "sql":
+		return promwrap.NewTimer(func(d time.Duration) { m.ObserveSQL(d, batch) })
+
+	case "api":
+		return promwrap.NewTimer(func(d time.Duration) { m.ObserveAPI(d, batch, "") })
+
+	case "work":
+		return promwrap.NewTimer(func(d time.Duration) { m.Observe(m.RecDuration, d, batch) })
+
+	case "wait":
+		return promwrap.NewTimer(func(d time.Duration) { m.Observe(m.RecWait, d, batch) })
+
+	}
+
+	return m.Metrics.StartTimer(phase, batch)
+}
@@ -0,0 +1,238 @@
+/*****************************************************************************
+*
+*	File			: partitions.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Partition management for range partitioned (by time) target tables.
+*					: Before the load the next periods' partitions are created, after it
+*					: partitions past retention are detached and optionally moved to an
+*					: archive schema. Partitions are named <table>_pYYYYMMDD (daily) or
+*					: <table>_pYYYYMM (monthly), only partitions following that naming are
+*					: ever detached.
+*
+*					: fs_etl_partition_ops_total{table,op} counts the operations,
+*					: fs_etl_partition_op_seconds{table,op} times them per batch.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Partition operations, the "op" label
+const (
+	partitionOpCreate  = "create"
+	partitionOpDetach  = "detach"
+	partitionOpArchive = "archive"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+type PartitionSpec struct {
+	Table         string // partitioned parent, optionally schema qualified
+	Period        string // day or month
+	Ahead         int    // periods to create ahead of now, the current one included
+	Retain        int    // periods to keep attached, 0 keeps everything
+	ArchiveSchema string // detached partitions are moved here, empty leaves them in place
+}
+
+func (p PartitionSpec) layout() string {
+
+	if p.Period == PeriodMonth {
+		return "200601"
+
+	}
+
+	return "20060102"
+}
+
+// periodStart truncates t to the start of its period, adding n periods.
+func (p PartitionSpec) periodStart(t time.Time, n int) time.Time {
+
+	if p.Period == PeriodMonth {
+		return time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day()+n, 0, 0, 0, 0, t.Location())
+}
+
+func (p PartitionSpec) split() (schema, table string) {
+
+	if s, t, ok := strings.Cut(p.Table, "."); ok {
+		return s, t
+
+	}
+
+	return "", p.Table
+}
+
+// partitionName returns the (unquoted, unqualified) name of the partition starting at start.
+func (p PartitionSpec) partitionName(start time.Time) string {
+
+	_, table := p.split()
+
+	return table + "_p" + start.Format(p.layout())
+}
+
+func (p PartitionSpec) qualify(name string) string {
+
+	if schema, _ := p.split(); schema != "" {
+		return quoteQualified(schema + "." + name)
+
+	}
+
+	return pq.QuoteIdentifier(name)
+}
+
+func (p PartitionSpec) validate() error {
+
+	switch p.Period {
+	case PeriodDay, PeriodMonth:
+
+	default:
+		return fmt.Errorf("partitions %s: period %q, expected day or month", p.Table, p.Period)
+
+	}
+
+	if p.Ahead < 1 {
+		return fmt.Errorf("partitions %s: ahead must be at least 1", p.Table)
+
+	}
+
+	return nil
+}
+
+// CreatePartitions creates the partitions for the current and following periods,
+// Ahead in total, existing ones are left alone. Bounds are in now's location.
+func (m *Metrics) CreatePartitions(ctx context.Context, db *sql.DB, p PartitionSpec, now time.Time) error {
+
+	if err := p.validate(); err != nil {
+		return err
+
+	}
+
+	start := time.Now()
+	defer func() { m.Set(m.PartitionOp, time.Since(start).Seconds(), p.Table, partitionOpCreate) }()
+
+	for i := 0; i < p.Ahead; i++ {
+		from, to := p.periodStart(now, i), p.periodStart(now, i+1)
+
+		_, err := db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)`,
+			p.qualify(p.partitionName(from)), quoteQualified(p.Table),
+			pq.QuoteLiteral(from.Format("2006-01-02")), pq.QuoteLiteral(to.Format("2006-01-02"))))
+		if err != nil {
+			return fmt.Errorf("creating partition %s: %w", p.partitionName(from), err)
+
+		}
+		m.Inc(m.PartitionOps, p.Table, partitionOpCreate)
+	}
+
+	return nil
+}
+
+// DetachPartitions detaches the partitions older than Retain periods before now,
+// and moves them to ArchiveSchema if set.
+func (m *Metrics) DetachPartitions(ctx context.Context, db *sql.DB, p PartitionSpec, now time.Time) error {
+
+	if err := p.validate(); err != nil {
+		return err
+
+	}
+	if p.Retain <= 0 {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() { m.Set(m.PartitionOp, time.Since(start).Seconds(), p.Table, partitionOpDetach) }()
+
+	rows, err := db.QueryContext(ctx, `SELECT c.relname
+		FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass
+		ORDER BY c.relname`, quoteQualified(p.Table))
+	if err != nil {
+		return err
+
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+
+	}
+
+	_, table := p.split()
+	cutoff := p.periodStart(now, -p.Retain)
+	for _, name := range names {
+		if !strings.HasPrefix(name, table+"_p") {
+			continue
+
+		}
+		from, err := time.ParseInLocation(p.layout(), strings.TrimPrefix(name, table+"_p"), now.Location())
+		if err != nil || !from.Before(cutoff) {
+			continue
+
+		}
+
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, quoteQualified(p.Table), p.qualify(name))); err != nil {
+			return fmt.Errorf("detaching partition %s: %w", name, err)
+
+		}
+		m.Inc(m.PartitionOps, p.Table, partitionOpDetach)
+
+		if p.ArchiveSchema == "" {
+			continue
+
+		}
+
+		archived := time.Now()
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s SET SCHEMA %s`, p.qualify(name), pq.QuoteIdentifier(p.ArchiveSchema))); err != nil {
+			return fmt.Errorf("archiving partition %s: %w", name, err)
+
+		}
+		m.Inc(m.PartitionOps, p.Table, partitionOpArchive)
+		m.Set(m.PartitionOp, time.Since(archived).Seconds(), p.Table, partitionOpArchive)
+	}
+
+	return nil
+}
+
+// PartitionHooks registers partition creation as an OnStart and detaching as an
+// OnFinish hook, see hooks.go.
+func (m *Metrics) PartitionHooks(db *sql.DB, p PartitionSpec) {
+
+	OnStart("partitions_create:"+p.Table, func(ctx context.Context) error {
+		return m.CreatePartitions(ctx, db, p, time.Now())
+	})
+	OnFinish("partitions_detach:"+p.Table, func(ctx context.Context) error {
+		return m.DetachPartitions(ctx, db, p, time.Now())
+	})
+}
@@ -0,0 +1,53 @@
+/*****************************************************************************
+*
+*	File			: pg.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Small Postgres helpers shared by the instrumentation helpers,
+*					: matview.go etc.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// queryer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// quoteQualified quotes a possibly schema qualified name, "staging.orders" becomes
+// "staging"."orders". Names are quoted as given, so they are case sensitive.
+func quoteQualified(name string) string {
+
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = pq.QuoteIdentifier(p)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// countRows returns the number of rows in relation, which must already be quoted.
+func countRows(ctx context.Context, q queryer, relation string) (int64, error) {
+
+	var n int64
+	err := q.QueryRowContext(ctx, `SELECT count(*) FROM `+relation).Scan(&n)
+
+	return n, err
+}
@@ -0,0 +1,67 @@
+/*****************************************************************************
+*
+*	File			: resources.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Per batch resource attribution, CPU time and heap allocations are
+*					: sampled around each batch and the deltas added
+*					: to fs_etl_cpu_seconds_total{batch} and fs_etl_alloc_bytes_total{batch}.
+*
+*					: CPU time comes from getrusage(2) where available (resources_unix.go),
+*					: the runtime/metrics CPU estimate is only updated at GC, useless for short
+*					: batches. Both are process totals, so batches running at the same time
+*					: in one process each get charged for the other's usage as well.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	rtmetrics "runtime/metrics"
+)
+
+const rmAllocBytes = "/gc/heap/allocs:bytes"
+
+// ResourceSample is the process' CPU time and heap allocations so far, see SampleResources.
+type ResourceSample struct {
+	CPUSeconds float64
+	AllocBytes uint64
+}
+
+func SampleResources() ResourceSample {
+
+	samples := []rtmetrics.Sample{{Name: rmAllocBytes}}
+	rtmetrics.Read(samples)
+
+	r := ResourceSample{CPUSeconds: processCPUSeconds()}
+	if samples[0].Value.Kind() == rtmetrics.KindUint64 {
+		r.AllocBytes = samples[0].Value.Uint64()
+	}
+
+	return r
+}
+
+// AttributeResources charges the usage since before to batch, and returns it.
+func (m *Metrics) AttributeResources(batch string, before ResourceSample) ResourceSample {
+
+	after := SampleResources()
+
+	var used ResourceSample
+	if d := after.CPUSeconds - before.CPUSeconds; d > 0 {
+		used.CPUSeconds = d
+		m.Add(m.CPUSeconds, d, batch)
+	}
+	if after.AllocBytes > before.AllocBytes {
+		used.AllocBytes = after.AllocBytes - before.AllocBytes
+		m.Add(m.AllocBytes, float64(used.AllocBytes), batch)
+	}
+
+	return used
+}
@@ -0,0 +1,35 @@
+//go:build !unix
+
+/*****************************************************************************
+*
+*	File			: resources_other.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: CPU time for resources.go, runtime estimate where getrusage(2) is missing
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import rtmetrics "runtime/metrics"
+
+// processCPUSeconds falls back to the runtime's estimate, which is only updated at GC.
+func processCPUSeconds() float64 {
+
+	samples := []rtmetrics.Sample{{Name: "/cpu/classes/total:cpu-seconds"}}
+	rtmetrics.Read(samples)
+
+	if samples[0].Value.Kind() != rtmetrics.KindFloat64 {
+		return 0
+
+	}
+
+	return samples[0].Value.Float64()
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+/*****************************************************************************
+*
+*	File			: resources_unix.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: CPU time for resources.go, getrusage(2)
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import "syscall"
+
+// processCPUSeconds returns the user+system CPU time of the process.
+func processCPUSeconds() float64 {
+
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+
+	}
+
+	return float64(ru.Utime.Nano()+ru.Stime.Nano()) / 1e9
+}
@@ -0,0 +1,199 @@
+/*****************************************************************************
+*
+*	File			: sqlwrap.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Instrumented *sql.DB for the batch's own queries. Every statement is
+*					: timed into fs_sql_duration_seconds{batch} and bounded by a statement
+*					: timeout (database.statement_timeout, overridable per query using
+*					: WithTimeout), so a runaway query fails instead of hanging the batch.
+*
+*					: The timeout is applied as a context deadline, lib/pq then sends
+*					: Postgres a cancel request for the running statement. Timeouts, ours
+*					: or the server's own statement_timeout, are counted in
+*					: fs_sql_timeouts_total{batch}, other cancellations in
+*					: fs_sql_cancellations_total{batch}.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const queryCanceled = "57014"
+
+// DB is a *sql.DB timing its statements into m's sql metrics, created by NewDB.
+type DB struct {
+	*sql.DB
+	m       *Metrics
+	batch   string
+	timeout time.Duration // 0 means no timeout
+}
+
+func NewDB(m *Metrics, db *sql.DB, batch string, timeout time.Duration) *DB {
+
+	return &DB{DB: db, m: m, batch: batch, timeout: timeout}
+}
+
+// WithTimeout returns a copy of d using timeout for its statements, 0 disables it.
+func (d *DB) WithTimeout(timeout time.Duration) *DB {
+
+	c := *d
+	c.timeout = timeout
+
+	return &c
+}
+
+func (d *DB) context(ctx context.Context) (context.Context, context.CancelFunc) {
+
+	if d.timeout <= 0 {
+		return context.WithCancel(ctx)
+
+	}
+
+	return context.WithTimeout(ctx, d.timeout)
+}
+
+// done records a finished statement, err is passed through.
+func (d *DB) done(ctx context.Context, start time.Time, err error) error {
+
+	d.m.ObserveSQL(time.Since(start), d.batch)
+
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || canceledBecause(err, "statement timeout"):
+		d.m.Inc(d.m.SQLTimeouts, d.batch)
+
+	case errors.Is(ctx.Err(), context.Canceled) || canceledBecause(err, ""):
+		d.m.Inc(d.m.SQLCancellations, d.batch)
+
+	default:
+		d.m.countDeadlock(err)
+
+	}
+
+	return err
+}
+
+// canceledBecause reports whether Postgres canceled the statement, for reason if set.
+func canceledBecause(err error, reason string) bool {
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != queryCanceled {
+		return false
+
+	}
+
+	return strings.Contains(pqErr.Message, reason)
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+
+	ctx, cancel := d.context(ctx)
+	defer cancel()
+
+	start := time.Now()
+	res, err := d.DB.ExecContext(ctx, query, args...)
+
+	return res, d.done(ctx, start, err)
+}
+
+// QueryContext runs query, the statement timeout covers reading the rows as well.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+
+	ctx, cancel := d.context(ctx)
+
+	start := time.Now()
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, d.done(ctx, start, err)
+
+	}
+
+	return &Rows{Rows: rows, db: d, ctx: ctx, cancel: cancel, start: start}, nil
+}
+
+// QueryRowContext runs query, errors are deferred until Row.Scan as with *sql.Row.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+
+	rows, err := d.QueryContext(ctx, query, args...)
+
+	return &Row{rows: rows, err: err}
+}
+
+type Rows struct {
+	*sql.Rows
+	db     *DB
+	ctx    context.Context
+	cancel context.CancelFunc
+	start  time.Time
+	closed bool
+}
+
+// Close closes the rows and records the statement, including the time spent reading.
+func (r *Rows) Close() error {
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	err := r.Rows.Close()
+	if rerr := r.Rows.Err(); rerr != nil {
+		err = rerr
+	}
+	err = r.db.done(r.ctx, r.start, err)
+	r.cancel()
+
+	return err
+}
+
+type Row struct {
+	rows *Rows
+	err  error
+}
+
+func (r *Row) Scan(dest ...any) error {
+
+	if r.err != nil {
+		return r.err
+
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Close(); err != nil {
+			return err
+
+		}
+		return sql.ErrNoRows
+
+	}
+
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+
+	}
+
+	return r.rows.Close()
+}
@@ -0,0 +1,227 @@
+/*****************************************************************************
+*
+*	File			: state.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Batch lifecycle as an explicit state machine,
+*
+*					:   pending -> running -> succeeded | partial | failed | cancelled | timed_out
+*					:   pending -> skipped (business calendar)
+*
+*					: exported as fs_etl_job_state{batch,state}, 1 for the current state and 0
+*					: for all the others. Terminal states are final, so a batch can't flip from
+*					: failed to succeeded, illegal transitions are reported as metric misuse.
+*
+*					: The completion/success timestamps are owned by the state machine, set
+*					: per batch when it finishes, so concurrent batches don't overwrite each other.
+*					: Update() sets the related gauges in one go, see promwrap/consistency.go.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"myapp/promwrap"
+)
+
+type State string
+
+// The state names double as the fs_etl_run_audit.status values, see audit.go in the example.
+const (
+	StatePending   State = "pending"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StatePartial   State = "partial" // some records failed with data errors
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+	StateTimedOut  State = "timed_out"
+	StateSkipped   State = "skipped" // business calendar
+)
+
+var states = []State{StatePending, StateRunning, StateSucceeded, StatePartial, StateFailed, StateCancelled, StateTimedOut, StateSkipped}
+
+var transitions = map[State][]State{
+	StatePending: {StateRunning, StateSkipped, StateCancelled},
+	StateRunning: {StateSucceeded, StatePartial, StateFailed, StateCancelled, StateTimedOut},
+}
+
+func (s State) Terminal() bool {
+
+	return len(transitions[s]) == 0
+}
+
+// Job is one run of a batch, created by NewJob.
+type Job struct {
+	m     *Metrics
+	batch string
+
+	mu    sync.Mutex
+	state State
+}
+
+// NewJob starts batch in pending, resetting whatever state, run stats, metadata
+// distinct counts and throughput a previous run left behind.
+func NewJob(m *Metrics, batch string) *Job {
+
+	m.SetSealed(batch, false)
+	m.ResetRunStats(batch)
+	m.jobInfo.reset(batch)
+	m.distinct.reset(batch)
+	m.throughput.reset(batch)
+
+	j := &Job{m: m, batch: batch, state: StatePending}
+	j.export()
+
+	return j
+}
+
+func (j *Job) Batch() string {
+
+	return j.batch
+}
+
+func (j *Job) State() State {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.state
+}
+
+// Transition moves the batch to state to, an illegal transition leaves the state unchanged.
+func (j *Job) Transition(to State) error {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, s := range transitions[j.state] {
+		if s == to {
+			j.state = to
+			j.export()
+			j.finished()
+			return nil
+
+		}
+	}
+
+	return j.m.Misuse(fmt.Errorf("illegal job state transition %s -> %s for batch %s", j.state, to, j.batch))
+}
+
+// Seal rejects further updates for the batch, see promwrap/seal.go.
+func (j *Job) Seal() {
+
+	j.m.SetSealed(j.batch, true)
+}
+
+func (j *Job) export() {
+
+	for _, s := range states {
+		v := 0.0
+		if s == j.state {
+			v = 1
+		}
+		j.m.Set(j.m.JobState, v, j.batch, string(s))
+	}
+}
+
+// Outcome is what a batch run came to, per iteration outcomes by class, for Complete.
+type Outcome struct {
+	Succeeded   int
+	DataErrors  int
+	InfraErrors int
+	Err         error // the last error
+}
+
+// Complete moves a running batch to its terminal state as per the outcome of its run.
+func (j *Job) Complete(r Outcome) error {
+
+	switch {
+	case r.Err == nil:
+		return j.Transition(StateSucceeded)
+
+	case r.Succeeded > 0 && r.InfraErrors == 0:
+		return j.Transition(StatePartial)
+
+	}
+
+	return j.Transition(StateFailed)
+}
+
+// Snapshot is a consistent set of the batch gauges, for Update.
+type Snapshot struct {
+	Records   int           // records processed by the last iteration
+	Duration  time.Duration // duration of the last iteration
+	Completed time.Time     // completion timestamp, zero leaves it alone
+	Success   time.Time     // success timestamp, zero leaves it alone
+}
+
+// Update sets all the gauges in s at once, a push or scrape sees either all of
+// them or none of them.
+func (j *Job) Update(s Snapshot) error {
+
+	if j.m.IsSealed(j.batch) {
+		return j.m.Misuse(j.m.Late(j.batch, "job.Update"))
+
+	}
+
+	promwrap.Atomically(func() {
+		j.m.SetGauge(j.m.Records, float64(s.Records))
+		j.m.SetDuration(j.m.Duration, s.Duration)
+		j.setTimestamps(s.Completed, s.Success)
+	})
+	j.m.throughput.add(j.batch, s.Records)
+
+	return nil
+}
+
+// setTimestamps sets the completion/success timestamps that aren't zero, called
+// from promwrap.Atomically.
+func (j *Job) setTimestamps(completed, success time.Time) {
+
+	if !completed.IsZero() {
+		now := float64(completed.UnixNano()) / 1e9
+		j.m.Set(j.m.BatchCompleted, now, j.batch)
+
+		// Legacy unlabeled timestamp, last batch to finish wins, dropped by drop_legacy_timestamps.
+		if j.m.IsRegistered(j.m.CompletionTime) {
+			j.m.SetGauge(j.m.CompletionTime, now)
+		}
+	}
+
+	if !success.IsZero() {
+		now := float64(success.UnixNano()) / 1e9
+		j.m.Set(j.m.BatchSucceeded, now, j.batch)
+
+		// SuccessTime is deliberately not registered, the caller attaches it to the final
+		// push after a success only, see promwrap/final.go.
+		j.m.SuccessTime.Set(now)
+	}
+}
+
+// finished sets the batch's completion timestamp once it reaches a terminal state,
+// and the success timestamp if it succeeded. A skipped batch didn't complete anything.
+func (j *Job) finished() {
+
+	if !j.state.Terminal() || j.state == StateSkipped {
+		return
+	}
+
+	now := time.Now()
+	success := time.Time{}
+	if j.state == StateSucceeded {
+		success = now
+	}
+
+	promwrap.Atomically(func() { j.setTimestamps(now, success) })
+}
@@ -0,0 +1,137 @@
+/*****************************************************************************
+*
+*	File			: throughput.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The shape of a batch run, records processed per minute of the run,
+*					: exported as the positional gauge
+*
+*					:   fs_etl_throughput_records{batch="eft",minute="0"} 1260
+*					:   fs_etl_throughput_records{batch="eft",minute="1"} 1302
+*					:   fs_etl_throughput_records{batch="eft",minute="2"} 0
+*
+*					: so a slow start or a stall mid-run shows, not just the total duration.
+*					: Minutes without records are 0, minutes past run.throughput_minutes are
+*					: added to the last one, which keeps the series bounded. Fed by
+*					: job.Update, reset when the batch starts again, and listed in the job
+*					: report.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package fsetl
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultThroughputMinutes is the default of run.throughput_minutes.
+const DefaultThroughputMinutes = 60
+
+type throughputRun struct {
+	started time.Time
+	minutes []float64 // records per minute of the run
+}
+
+type throughputCollector struct {
+	desc *prometheus.Desc
+
+	mu   sync.Mutex
+	max  int // minutes kept per run, run.throughput_minutes
+	runs map[string]*throughputRun
+}
+
+func newThroughputCollector(max int) *throughputCollector {
+
+	return &throughputCollector{
+		desc: prometheus.NewDesc("fs_etl_throughput_records", "Records processed by the FS ETL batch per minute of the run, by minute since the start.", []string{promwrap.BatchLabel, "minute"}, nil),
+		max:  max,
+		runs: make(map[string]*throughputRun),
+	}
+}
+
+func (c *throughputCollector) Describe(ch chan<- *prometheus.Desc) {
+
+	ch <- c.desc
+}
+
+func (c *throughputCollector) Collect(ch chan<- prometheus.Metric) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for batch, run := range c.runs {
+		for i, n := range run.minutes {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, n, batch, strconv.Itoa(i))
+		}
+	}
+}
+
+// reset starts the batch's run now.
+func (c *throughputCollector) reset(batch string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.runs[batch] = &throughputRun{started: time.Now()}
+}
+
+func (c *throughputCollector) add(batch string, records int) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	run := c.runs[batch]
+	if run == nil {
+		return
+	}
+
+	minute := int(time.Since(run.started) / time.Minute)
+	if minute >= c.max {
+		minute = c.max - 1
+	}
+	for len(run.minutes) <= minute {
+		run.minutes = append(run.minutes, 0)
+	}
+	run.minutes[minute] += float64(records)
+}
+
+// Minutes returns the batch's records per minute so far.
+func (c *throughputCollector) Minutes(batch string) []float64 {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if run := c.runs[batch]; run != nil {
+		return append([]float64(nil), run.minutes...)
+	}
+
+	return nil
+}
+
+// SetThroughputMinutes sets the minutes kept per run, run.throughput_minutes.
+func (m *Metrics) SetThroughputMinutes(max int) {
+
+	m.throughput.mu.Lock()
+	defer m.throughput.mu.Unlock()
+
+	m.throughput.max = max
+}
+
+// Throughput returns the batch's records per minute so far, see Minutes.
+func (m *Metrics) Throughput(batch string) []float64 {
+
+	return m.throughput.Minutes(batch)
+}
@@ -0,0 +1,199 @@
+/*****************************************************************************
+*
+*	File			: fuzz_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Fuzz targets for what comes from outside, the config file, the metric
+*					: definitions and label values (file names, batch names). Malformed yaml
+*					: or a hostile file name may fail a run, it may never crash the loader.
+*					: The seed corpus runs with the normal tests, fuzz one target at a time,
+*
+*					:   go test -run '^$' -fuzz FuzzLoadConfig -fuzztime 5m
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+func FuzzLoadConfig(f *testing.F) {
+
+	if data, err := os.ReadFile(defaultConfigFile); err == nil {
+		f.Add(data)
+	}
+	f.Add([]byte("profile: dev\nprofiles:\n  dev:\n    sinks: []\n    log_level: info\n"))
+	f.Add([]byte("pushgateway:\n  url: 42\n  jobs:\n    a: [x, x]\n"))
+	f.Add([]byte("run: {iterations: -1, push_interval: -5s}\ncalendar: {timezone: Nowhere/Nope}\n"))
+	f.Add([]byte("- just\n- a\n- list\n"))
+	f.Add([]byte("\t:\x00\xff"))
+
+	mainLevel := logLevel
+	defer func() { logLevel = mainLevel }()
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "promwrap.yaml")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+
+		}
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return
+
+		}
+
+		// everything main does with the config before it touches the network
+		if cfg.applyProfile() != nil {
+			return
+
+		}
+		cfg.Run = cfg.Run.withDefaults()
+		cfg.ApplyMode()
+		cfg.checkGateway()
+		promwrap.ExpositionFormat(cfg.Exposition.Format)
+		NewCalendar(cfg.Calendar)
+		NewMaintenance(cfg.Maintenance)
+		cfg.FileMetrics.NumBuckets()
+	})
+}
+
+func FuzzMetricDefinitions(f *testing.F) {
+
+	if data, err := os.ReadFile("metrics.yaml"); err == nil {
+		f.Add(data)
+	}
+	f.Add([]byte("metrics:\n  - {name: x, type: histogram, help: h, labels: [le], buckets: [1, 2]}\n"))
+	f.Add([]byte("metrics:\n  - {name: 'bad name', type: gauge, help: h, labels: [a, a]}\n"))
+	f.Add([]byte("metrics:\n  - {name: txn_count, type: counter, help: h}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "metrics.yaml")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+
+		}
+
+		defs, err := promwrap.LoadDefinitions(path)
+		if err != nil {
+			return
+
+		}
+
+		reg := prometheus.NewRegistry()
+		pm := promwrap.NewMetrics(reg)
+		if pm.Define(defs...) != nil {
+			return
+
+		}
+
+		// every defined metric can be updated
+		for _, d := range defs {
+			lvs := make([]string, len(d.Labels))
+			switch d.Type {
+			case promwrap.TypeGauge:
+				pm.Set(pm.Gauge(d.Name), 1, lvs...)
+
+			case promwrap.TypeCounter:
+				pm.Inc(pm.Counter(d.Name), lvs...)
+
+			case promwrap.TypeHistogram:
+				pm.Observe(pm.Histogram(d.Name), time.Second, lvs...)
+
+			}
+		}
+
+		if _, err := reg.Gather(); err != nil {
+			t.Fatalf("gather after defining %+v: %v", defs, err)
+
+		}
+	})
+}
+
+func FuzzSanitizeLabelValue(f *testing.F) {
+
+	for _, seed := range []string{"", "eft", "eft chunk 0001.csv", "/data/in/../../etc/passwd", "naïve_日本語", "a\x00b\xff\xfe", strings.Repeat("é", 200)} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, v string) {
+		s := promwrap.SanitizeLabelValue(v)
+
+		if !utf8.ValidString(s) {
+			t.Fatalf("SanitizeLabelValue(%q) = %q, invalid UTF-8", v, s)
+
+		}
+		if n := utf8.RuneCountInString(s); n > 128 {
+			t.Fatalf("SanitizeLabelValue(%q) is %d runes long", v, n)
+
+		}
+		for _, r := range s {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:", r) {
+				t.Fatalf("SanitizeLabelValue(%q) = %q, contains %q", v, s, r)
+
+			}
+		}
+		if again := promwrap.SanitizeLabelValue(s); again != s {
+			t.Fatalf("SanitizeLabelValue not idempotent, %q -> %q -> %q", v, s, again)
+
+		}
+	})
+}
+
+// FuzzLabelValues checks a hostile label value, sanitized or not, can't break the exposition.
+func FuzzLabelValues(f *testing.F) {
+
+	f.Add("eft_chunk_0001.csv", false)
+	f.Add("a\x00b\xff\"}\n# TYPE", false)
+	f.Add("a\x00b\xff\"}\n# TYPE", true)
+
+	f.Fuzz(func(t *testing.T, v string, raw bool) {
+		r := stressSetup(t)
+		m.RawLabels = raw
+
+		m.Set(m.Info, 1, v)
+		m.ObserveFile(v, v, 1, 0, nil)
+
+		mfs, err := r.Gather()
+		if err != nil {
+			if raw && !utf8.ValidString(v) {
+				return // client_golang rejects invalid UTF-8, the reason we sanitize
+
+			}
+			t.Fatalf("gather with label value %q: %v", v, err)
+
+		}
+
+		var buf bytes.Buffer
+		if err := promwrap.WriteExposition(&buf, mfs, expfmt.NewFormat(expfmt.TypeTextPlain)); err != nil {
+			t.Fatalf("exposition with label value %q: %v", v, err)
+
+		}
+		var p expfmt.TextParser
+		if _, err := p.TextToMetricFamilies(&buf); err != nil {
+			t.Fatalf("exposition with label value %q doesn't parse back: %v", v, err)
+
+		}
+	})
+}
@@ -0,0 +1,161 @@
+/*****************************************************************************
+*
+*	File			: golden_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Golden file tests of the exposition text after representative batch
+*					: runs, against testdata/golden/<run>.prom. A renamed metric, or an added
+*					: or dropped label, shows up here before it breaks a dashboard. After an
+*					: intended change regenerate the files and review the diff,
+*
+*					:   go test -run Golden -update
+*
+*					: Timestamps differ from run to run, their values are replaced by
+*					: <timestamp> before comparing.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// Sample lines of the timestamp gauges, name{labels} value
+var goldenTimestamp = regexp.MustCompile(`(?m)^(\w+_timestamp_seconds(\{[^}]*\})?) .*$`)
+
+// goldenRuns are the representative runs, each a batch as mRun would do it, minus the sleeps.
+var goldenRuns = map[string]func(){
+	"success": func() {
+		job := fsetl.NewJob(m, "eft")
+		job.Transition(fsetl.StateRunning)
+		goldenIterations(job, nil, nil, nil)
+		job.Complete(fsetl.Outcome{Succeeded: 3})
+	},
+
+	"partial": func() {
+		job := fsetl.NewJob(m, "eft")
+		job.Transition(fsetl.StateRunning)
+		err := &DataError{errors.New("bad record")}
+		goldenIterations(job, nil, err, nil)
+		job.Complete(fsetl.Outcome{Succeeded: 2, DataErrors: 1, Err: err})
+	},
+
+	"skipped": func() {
+		job := fsetl.NewJob(m, "eft")
+		m.Inc(m.RunsSkipped, "weekend")
+		job.Transition(fsetl.StateSkipped)
+	},
+}
+
+// goldenIterations runs one iteration per error, nil for a successful one.
+func goldenIterations(job *fsetl.Job, errs ...error) {
+
+	m.Observe(m.SQLDuration, 2*time.Second, job.Batch())
+	m.Set(m.Info, 345234523, job.Batch())
+
+	for i, err := range errs {
+		d := time.Duration(i+1) * 10 * time.Microsecond
+		m.Observe(m.APIDuration, d, job.Batch())
+		m.ObserveFile(job.Batch(), fmt.Sprintf("%s_chunk_%04d.csv", job.Batch(), i), 42, d, err)
+		job.Update(fsetl.Snapshot{Records: 42, Duration: d})
+
+		promwrap.Transaction(func() {
+			m.Inc(m.ReqProcessed, job.Batch())
+			m.Observe(m.RecDuration, 2*d, job.Batch())
+			m.Observe(m.RecWait, time.Duration(i)*300*time.Millisecond, job.Batch())
+		})
+	}
+}
+
+func TestGoldenExposition(t *testing.T) {
+
+	for name, run := range goldenRuns {
+		t.Run(name, func(t *testing.T) {
+			r := stressSetup(t)
+			run()
+
+			mfs, err := r.Gather()
+			if err != nil {
+				t.Fatalf("gather: %v", err)
+
+			}
+
+			var buf bytes.Buffer
+			if err := promwrap.WriteExposition(&buf, mfs, expfmt.NewFormat(expfmt.TypeTextPlain)); err != nil {
+				t.Fatalf("exposition: %v", err)
+
+			}
+			got := goldenTimestamp.ReplaceAll(buf.Bytes(), []byte("$1 <timestamp>"))
+
+			path := filepath.Join("testdata", "golden", name+".prom")
+			if *updateGolden {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+
+				}
+				return
+
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("exposition differs from %s, run with -update and review the diff if intended\n%s", path, goldenDiff(want, got))
+			}
+		})
+	}
+}
+
+// goldenDiff returns the lines only in want (-) and only in got (+).
+func goldenDiff(want, got []byte) string {
+
+	in := func(lines [][]byte) map[string]bool {
+		set := make(map[string]bool, len(lines))
+		for _, l := range lines {
+			set[string(l)] = true
+		}
+		return set
+	}
+	wantLines, gotLines := bytes.Split(want, []byte("\n")), bytes.Split(got, []byte("\n"))
+	inWant, inGot := in(wantLines), in(gotLines)
+
+	var diff bytes.Buffer
+	for _, l := range wantLines {
+		if !inGot[string(l)] {
+			fmt.Fprintf(&diff, "- %s\n", l)
+		}
+	}
+	for _, l := range gotLines {
+		if !inWant[string(l)] {
+			fmt.Fprintf(&diff, "+ %s\n", l)
+		}
+	}
+
+	return diff.String()
+}
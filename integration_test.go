@@ -0,0 +1,304 @@
+//go:build integration
+
+/*****************************************************************************
+*
+*	File			: integration_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: End to end tests against a real Pushgateway and Postgres, started in
+*					: docker for the duration of the run,
+*
+*					:   go test -tags integration -run Integration ./...
+*
+*					: The containers are driven through the docker CLI rather than dockertest
+*					: or testcontainers, so the integration tests don't add to the module's
+*					: dependencies. Without a docker daemon the tests are skipped.
+*
+*					: PROMWRAP_IT_PUSHGATEWAY_IMAGE and PROMWRAP_IT_POSTGRES_IMAGE override
+*					: the images, eg. to test against the versions running in production.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	itPostgresPassword = "promwrap"
+	itStartTimeout     = time.Minute
+)
+
+var (
+	itGatewayURL  string // http://127.0.0.1:<port>
+	itPostgresDSN string
+	itSkip        string // why the integration tests can't run
+)
+
+func TestMain(tm *testing.M) {
+
+	var containers []string
+	code := func() int {
+		defer func() {
+			for _, id := range containers {
+				exec.Command("docker", "rm", "-f", id).Run()
+			}
+		}()
+
+		if err := exec.Command("docker", "info").Run(); err != nil {
+			itSkip = "docker not available: " + err.Error()
+			return tm.Run()
+
+		}
+
+		gw, err := dockerRun("9091/tcp", itImage("PROMWRAP_IT_PUSHGATEWAY_IMAGE", "prom/pushgateway:v1.9.0"))
+		if err != nil {
+			fmt.Println("Could not start pushgateway:", err)
+			return 1
+
+		}
+		containers = append(containers, gw.id)
+		itGatewayURL = "http://" + gw.addr
+
+		pg, err := dockerRun("5432/tcp", "-e", "POSTGRES_PASSWORD="+itPostgresPassword, itImage("PROMWRAP_IT_POSTGRES_IMAGE", "postgres:16-alpine"))
+		if err != nil {
+			fmt.Println("Could not start postgres:", err)
+			return 1
+
+		}
+		containers = append(containers, pg.id)
+		itPostgresDSN = fmt.Sprintf("postgres://postgres:%s@%s/postgres?sslmode=disable", itPostgresPassword, pg.addr)
+
+		if err := itWaitReady(); err != nil {
+			fmt.Println("Containers not ready:", err)
+			return 1
+
+		}
+
+		return tm.Run()
+	}()
+
+	os.Exit(code)
+}
+
+func itImage(env, image string) string {
+
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return image
+}
+
+type itContainer struct {
+	id   string
+	addr string // host:port the container port is published on
+}
+
+// dockerRun starts a container from args, publishing port on a random local port.
+func dockerRun(port string, args ...string) (itContainer, error) {
+
+	out, err := exec.Command("docker", append([]string{"run", "-d", "--rm", "-p", "127.0.0.1::" + port}, args...)...).Output()
+	if err != nil {
+		return itContainer{}, err
+
+	}
+	c := itContainer{id: strings.TrimSpace(string(out))}
+
+	out, err = exec.Command("docker", "port", c.id, port).Output()
+	if err != nil {
+		exec.Command("docker", "rm", "-f", c.id).Run()
+		return itContainer{}, err
+
+	}
+	c.addr = strings.TrimSpace(strings.Split(string(out), "\n")[0])
+
+	return c, nil
+}
+
+// itWaitReady waits for the gateway to be ready and Postgres to accept connections.
+func itWaitReady() error {
+
+	deadline := time.Now().Add(itStartTimeout)
+	for {
+		_, gwErr := promwrap.Preflight(context.Background(), itGatewayURL, time.Second)
+
+		var pgErr error
+		if db, err := openDB(context.Background(), itPostgresDSN); err != nil {
+			pgErr = err
+
+		} else {
+			db.Close()
+
+		}
+
+		if gwErr == nil && pgErr == nil {
+			return nil
+
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pushgateway: %v, postgres: %v", gwErr, pgErr)
+
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// itSetup skips the test without docker, otherwise points m at a fresh registry.
+func itSetup(t *testing.T) *prometheus.Registry {
+
+	t.Helper()
+
+	if itSkip != "" {
+		t.Skip(itSkip)
+	}
+
+	return stressSetup(t)
+}
+
+// itDB returns a migrated database, cleaned up after the test.
+func itDB(t *testing.T) *sql.DB {
+
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), itStartTimeout)
+	defer cancel()
+
+	db, err := openDB(ctx, itPostgresDSN)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := Migrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+
+	}
+
+	return db
+}
+
+func TestIntegrationPush(t *testing.T) {
+
+	itSetup(t)
+
+	w, err := promwrap.New(promwrap.WithPushgatewayURL(itGatewayURL), promwrap.WithJobName("fs_etl_integration"))
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	m = fsetl.NewMetrics(w.Metrics)
+
+	m.Add(m.ReqProcessed, 42, "eft")
+	m.Observe(m.SQLDuration, 2*time.Second, "eft")
+	if err := w.Pusher.Push(); err != nil {
+		t.Fatalf("push: %v", err)
+
+	}
+
+	resp, err := http.Get(itGatewayURL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		`fs_etl_operations_total{batch="eft",instance="",job="fs_etl_integration"} 42`,
+		`fs_sql_duration_seconds_count{batch="eft",instance="",job="fs_etl_integration"} 1`,
+		`push_failure_time_seconds{instance="",job="fs_etl_integration"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("gateway is missing %s", want)
+		}
+	}
+}
+
+func TestIntegrationSQL(t *testing.T) {
+
+	r := itSetup(t)
+	db := itDB(t)
+
+	d := fsetl.NewDB(m, db, "eft", time.Second)
+
+	var one int
+	if err := d.QueryRowContext(context.Background(), "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Fatalf("SELECT 1 = %d, %v", one, err)
+
+	}
+
+	if _, err := d.ExecContext(context.Background(), "SELECT pg_sleep(5)"); err == nil {
+		t.Fatal("pg_sleep(5) didn't hit the 1s statement timeout")
+
+	}
+
+	if v := value(t, r, "fs_sql_duration_seconds", "eft"); v != 2 {
+		t.Errorf("fs_sql_duration_seconds count = %g, want 2", v)
+	}
+	if v := value(t, r, "fs_sql_timeouts_total", "eft"); v != 1 {
+		t.Errorf("fs_sql_timeouts_total = %g, want 1", v)
+	}
+}
+
+func TestIntegrationAudit(t *testing.T) {
+
+	itSetup(t)
+	db := itDB(t)
+
+	started := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	a := runAudit{
+		Job:      "fs_etl_integration",
+		Batch:    fmt.Sprintf("it_%d", time.Now().UnixNano()),
+		Started:  started,
+		Finished: started.Add(30 * time.Second),
+		Status:   statusPartial,
+		Records:  1234,
+		Err:      "3 records failed",
+	}
+	if err := writeRunAudit(context.Background(), db, a); err != nil {
+		t.Fatalf("write: %v", err)
+
+	}
+
+	runs, err := readRunAudit(context.Background(), db, started)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+
+	}
+	for _, got := range runs {
+		if got.Batch != a.Batch {
+			continue
+
+		}
+		if got.Status != a.Status || got.Records != a.Records || got.Err != a.Err || got.Duration() != 30*time.Second {
+			t.Errorf("read back %+v, want %+v", got, a)
+		}
+		return
+
+	}
+
+	t.Errorf("audit row for batch %s not found", a.Batch)
+}
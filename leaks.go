@@ -0,0 +1,145 @@
+/*****************************************************************************
+*
+*	File			: leaks.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Goroutine leak detection around batch runs, leaked workers have slowly
+*					: degraded our long running loaders. The goroutines alive before the
+*					: batch are remembered, those started during the batch and still around
+*					: a grace period after it are counted in fs_etl_leaked_goroutines and
+*					: their stacks logged. The check runs after the batch's final push, the
+*					: gauge goes out with the next push or scrape.
+*
+*					: Goroutines whose stack contains one of the ignore patterns aren't
+*					: counted, eg. keep-alive HTTP connections to the Pushgateway.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"time"
+
+	"myapp/promwrap"
+)
+
+const defaultLeakGrace = time.Second
+
+// Long lived by design, connection pools, keep-alives etc.
+var defaultLeakIgnore = []string{
+	"net/http.(*persistConn)",
+	"net/http.(*Server)",
+	"database/sql.(*DB).connectionOpener",
+	"database/sql.(*DB).connectionCleaner",
+	"github.com/lib/pq.(*ListenerConn)",
+	"github.com/lib/pq.(*Listener)",
+	"os/signal.",
+}
+
+type LeakCheckConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Grace   time.Duration `yaml:"grace"`  // time given to goroutines to wind down after the batch
+	Ignore  []string      `yaml:"ignore"` // stack substrings, added to the defaults
+}
+
+type leakCheck struct {
+	cfg    LeakCheckConfig
+	before map[string]bool // goroutine ids
+}
+
+// goroutineStacks returns the stacks of all goroutines by goroutine id.
+func goroutineStacks() map[string]string {
+
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	stacks := make(map[string]string)
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		// "goroutine 42 [chan receive]:"
+		fields := strings.Fields(string(stack))
+		if len(fields) < 2 || fields[0] != "goroutine" {
+			continue
+
+		}
+		stacks[fields[1]] = string(stack)
+	}
+
+	return stacks
+}
+
+// startLeakCheck remembers the goroutines alive now, nil when disabled.
+func startLeakCheck(c LeakCheckConfig) *leakCheck {
+
+	if !c.Enabled {
+		return nil
+	}
+
+	before := make(map[string]bool)
+	for id := range goroutineStacks() {
+		before[id] = true
+	}
+
+	return &leakCheck{cfg: c, before: before}
+}
+
+// finish waits out the grace period and reports the goroutines started since
+// startLeakCheck that are still running.
+func (l *leakCheck) finish(batch string) {
+
+	if l == nil {
+		return
+	}
+
+	grace := l.cfg.Grace
+	if grace <= 0 {
+		grace = defaultLeakGrace
+	}
+	time.Sleep(grace)
+
+	ignore := append(append([]string(nil), defaultLeakIgnore...), l.cfg.Ignore...)
+
+	var leaked []string
+	for id, stack := range goroutineStacks() {
+		if l.before[id] || ignored(stack, ignore) {
+			continue
+
+		}
+		leaked = append(leaked, stack)
+	}
+
+	m.SetGauge(m.Leaked, float64(len(leaked)))
+
+	if len(leaked) > 0 {
+		promwrap.Log.Error("Batch leaked goroutines", "batch", batch, "goroutines", len(leaked), "stacks", "\n\n"+strings.Join(leaked, "\n\n"))
+	}
+}
+
+func ignored(stack string, ignore []string) bool {
+
+	for _, pattern := range ignore {
+		if strings.Contains(stack, pattern) {
+			return true
+
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,334 @@
+/*****************************************************************************
+*
+*	File			: lintbuckets.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: lint-buckets subcommand, checks the bucket boundaries of every histogram
+*					: against the values it actually observed, from the last archived snapshots
+*					: of the batch (archive.dir) or a live /metrics endpoint,
+*
+*					:   myapp lint-buckets [-snapshots 10] [-batch eft]
+*					:   myapp lint-buckets -url http://fs-loader:9100/metrics
+*
+*					: A histogram is flagged when over 5% of its observations land in +Inf
+*					: (saturated, the percentiles above the last bucket are unknowable), over
+*					: half of them in the first bucket (too coarse at the low end) or most of
+*					: its buckets are never hit, and gets a suggestion: as many exponential
+*					: buckets, spanning the estimated 1st percentile up to twice the
+*					: estimated mean of what overflowed (or 1.5 times the 99th percentile).
+*
+*					: Exits 1 when a histogram is flagged, so it can gate a CI pipeline.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myapp/promwrap"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	lintOverflowShare = 0.05 // of the observations in +Inf
+	lintFirstShare    = 0.5  // in the first bucket
+	lintMinBuckets    = 6    // in a suggestion
+)
+
+// bucketUsage is a histogram's buckets summed over its series and the sources read.
+type bucketUsage struct {
+	bounds []float64 // upper bounds, without +Inf
+	counts []float64 // cumulative, per bound
+	count  float64
+	sum    float64
+}
+
+func runLintBuckets(args []string, c ArchiveConfig, batch string) error {
+
+	fs := flag.NewFlagSet("lint-buckets", flag.ContinueOnError)
+	url := fs.String("url", "", "/metrics endpoint of a live run, rather than the archive")
+	snapshots := fs.Int("snapshots", 10, "archived snapshots of the batch to read, newest first")
+	fs.StringVar(&batch, "batch", batch, "batch whose snapshots are read")
+	if err := fs.Parse(args); err != nil {
+		return err
+
+	}
+
+	var sources [][]byte
+	if *url != "" {
+		data, err := scrapeMetrics(*url)
+		if err != nil {
+			return err
+
+		}
+		sources = append(sources, data)
+
+	} else {
+		if c.Dir == "" {
+			return fmt.Errorf("lint-buckets needs -url or archive.dir")
+
+		}
+		files, err := filepath.Glob(filepath.Join(c.Dir, batch+"-*.prom*"))
+		if err != nil {
+			return err
+
+		}
+		sort.Strings(files)
+		if len(files) > *snapshots {
+			files = files[len(files)-*snapshots:]
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no snapshots of batch %s in %s", batch, c.Dir)
+
+		}
+
+		for _, path := range files {
+			data, err := readArchive(path, c.Key)
+			if err != nil {
+				return err
+
+			}
+			sources = append(sources, data)
+		}
+	}
+
+	usage := make(map[string]*bucketUsage)
+	for _, data := range sources {
+		var p expfmt.TextParser
+		mfs, err := p.TextToMetricFamilies(bytes.NewReader(data))
+		if err != nil {
+			return err
+
+		}
+		for name, mf := range mfs {
+			if mf.GetType() == dto.MetricType_HISTOGRAM {
+				addBucketUsage(usage, name, mf)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(usage))
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flagged := 0
+	for _, name := range names {
+		u := usage[name]
+		findings := u.lint()
+		if len(findings) == 0 {
+			fmt.Printf("%s: ok, %.0f observations\n", name, u.count)
+			continue
+
+		}
+
+		flagged++
+		fmt.Printf("%s: %.0f observations, %s\n", name, u.count, strings.Join(findings, ", "))
+		fmt.Printf("  buckets   : %s\n", formatBounds(u.bounds))
+		fmt.Printf("  suggested : %s\n", formatBounds(u.suggest()))
+	}
+
+	if flagged > 0 {
+		return fmt.Errorf("%d of %d histograms need their buckets looked at", flagged, len(names))
+
+	}
+
+	return nil
+}
+
+func scrapeMetrics(url string) ([]byte, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %s: %s", url, resp.Status)
+
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// addBucketUsage adds the series of histogram mf to its usage, series with other
+// bounds than the first one seen are skipped.
+func addBucketUsage(usage map[string]*bucketUsage, name string, mf *dto.MetricFamily) {
+
+	for _, metric := range mf.GetMetric() {
+		h := metric.GetHistogram()
+		var bounds, counts []float64
+		for _, b := range h.GetBucket() {
+			if !math.IsInf(b.GetUpperBound(), +1) {
+				bounds = append(bounds, b.GetUpperBound())
+				counts = append(counts, float64(b.GetCumulativeCount()))
+			}
+		}
+		if len(bounds) == 0 {
+			continue // native only
+
+		}
+
+		u := usage[name]
+		if u == nil {
+			u = &bucketUsage{bounds: bounds, counts: make([]float64, len(bounds))}
+			usage[name] = u
+		}
+		if !equalBounds(u.bounds, bounds) {
+			continue
+
+		}
+
+		for i := range counts {
+			u.counts[i] += counts[i]
+		}
+		u.count += float64(h.GetSampleCount())
+		u.sum += h.GetSampleSum()
+	}
+}
+
+func equalBounds(a, b []float64) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (u *bucketUsage) overflow() float64 {
+
+	return u.count - u.counts[len(u.counts)-1]
+}
+
+// lint returns what's wrong with the buckets, nothing when they fit.
+func (u *bucketUsage) lint() []string {
+
+	if u.count == 0 {
+		return nil
+	}
+
+	var findings []string
+	if share := u.overflow() / u.count; share > lintOverflowShare {
+		findings = append(findings, fmt.Sprintf("%.0f%% above the last bucket le=%g (+Inf saturated)", share*100, u.bounds[len(u.bounds)-1]))
+	}
+	if share := u.counts[0] / u.count; share > lintFirstShare {
+		findings = append(findings, fmt.Sprintf("%.0f%% in the first bucket le=%g (too coarse at the low end)", share*100, u.bounds[0]))
+	}
+
+	empty, prev := 0, 0.0
+	for _, c := range u.counts {
+		if c == prev {
+			empty++
+		}
+		prev = c
+	}
+	if empty > len(u.counts)/2 {
+		findings = append(findings, fmt.Sprintf("%d of %d buckets never hit", empty, len(u.counts)))
+	}
+
+	return findings
+}
+
+// quantile estimates quantile q from the buckets, interpolating linearly inside the
+// bucket like histogram_quantile, the last bound when q falls in +Inf.
+func (u *bucketUsage) quantile(q float64) float64 {
+
+	rank := q * u.count
+	lower, prev := 0.0, 0.0
+	for i, c := range u.counts {
+		if c >= rank && c > prev {
+			return lower + (u.bounds[i]-lower)*(rank-prev)/(c-prev)
+
+		}
+		lower, prev = u.bounds[i], c
+	}
+
+	return u.bounds[len(u.bounds)-1]
+}
+
+// suggest returns exponential buckets covering what was observed.
+func (u *bucketUsage) suggest() []float64 {
+
+	low := u.quantile(0.01)
+	high := u.quantile(0.99) * 1.5
+
+	if overflow := u.overflow(); overflow > 0 {
+		// the sum of the overflowed observations, the bucketed ones at their bucket's midpoint
+		inBuckets, lower, prev := 0.0, 0.0, 0.0
+		for i, c := range u.counts {
+			inBuckets += (c - prev) * (lower + u.bounds[i]) / 2
+			lower, prev = u.bounds[i], c
+		}
+		mean := (u.sum - inBuckets) / overflow
+		high = 2 * math.Max(mean, u.bounds[len(u.bounds)-1])
+
+		// the 1st percentile overflowed as well, all we know is the mean
+		if overflow > 0.99*u.count {
+			low = math.Max(low, mean/20)
+		}
+	}
+
+	if low <= 0 {
+		low = u.bounds[0] / 2
+	}
+	if high <= low {
+		high = low * 10
+	}
+
+	n := len(u.bounds)
+	if n < lintMinBuckets {
+		n = lintMinBuckets
+	}
+
+	return promwrap.ExponentialFit(low, high, n)
+}
+
+func formatBounds(bounds []float64) string {
+
+	s := make([]string, len(bounds))
+	for i, b := range bounds {
+		s[i] = strconv.FormatFloat(b, 'g', -1, 64)
+	}
+
+	return "[" + strings.Join(s, ", ") + "]"
+}
@@ -0,0 +1,105 @@
+/*****************************************************************************
+*
+*	File			: maintenance.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Planned maintenance windows (gateway/DB), during which failures
+*					: can be suppressed so that planned work does not page anyone.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"myapp/promwrap"
+)
+
+type MaintenanceWindow struct {
+	Start  time.Time `yaml:"start"`
+	End    time.Time `yaml:"end"`
+	Reason string    `yaml:"reason"`
+}
+
+type MaintenanceConfig struct {
+	SuppressFailures bool                `yaml:"suppress_failures"`
+	Windows          []MaintenanceWindow `yaml:"windows"`
+}
+
+type Maintenance struct {
+	suppressFailures bool
+	windows          []MaintenanceWindow
+}
+
+func NewMaintenance(c MaintenanceConfig) (*Maintenance, error) {
+
+	for _, w := range c.Windows {
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("maintenance window %q: end %s is not after start %s", w.Reason, w.End, w.Start)
+
+		}
+	}
+
+	return &Maintenance{
+		suppressFailures: c.SuppressFailures,
+		windows:          c.Windows,
+	}, nil
+}
+
+// Active returns the maintenance window covering t, if any.
+func (mw *Maintenance) Active(t time.Time) (MaintenanceWindow, bool) {
+
+	if mw == nil {
+		return MaintenanceWindow{}, false
+
+	}
+
+	for _, w := range mw.windows {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w, true
+
+		}
+	}
+
+	return MaintenanceWindow{}, false
+}
+
+// Suppressed reports whether failures at t should be kept quiet.
+func (mw *Maintenance) Suppressed(t time.Time) bool {
+
+	if mw == nil || !mw.suppressFailures {
+		return false
+
+	}
+
+	_, active := mw.Active(t)
+
+	return active
+}
+
+// reportFailure is used for all failure reporting, so that planned maintenance
+// can quietly swallow the noise. The error goes through the redaction rules, the
+// line through promwrap.Log.
+func reportFailure(msg string, err error) {
+
+	err = promwrap.RedactErr(err)
+	msg = strings.TrimSuffix(msg, ":")
+
+	if maint.Suppressed(time.Now()) {
+		promwrap.Log.Info("Maintenance window, suppressed: "+msg, "err", err)
+		return
+
+	}
+
+	promwrap.Log.Error(msg, "err", err)
+}
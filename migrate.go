@@ -0,0 +1,257 @@
+/*****************************************************************************
+*
+*	File			: migrate.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Embedded schema migrations for the wrapper owned tables (run audit,
+*					: checkpoints, batch definitions), applied at startup so consumers
+*					: don't have to hand create them.
+*
+*					: Migrations live in migrations/NNNN_name.up.sql, are applied in version
+*					: order, each in its own transaction, and recorded in
+*					: fs_etl_schema_migrations. Never edit an applied migration, add a new one.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Arbitrary, but fixed, key so that only one loader migrates at a time.
+const migrationLockKey = 0x66735f65746c // "fs_etl"
+
+const defaultApplicationName = "fs_etl"
+
+type DatabaseConfig struct {
+	DSN            string `yaml:"dsn"`
+	SkipMigrations bool   `yaml:"skip_migrations"` // don't apply migrations/*.sql at startup
+
+	// Identifies our backends in pg_stat_activity, unless the DSN sets its own
+	ApplicationName string `yaml:"application_name"`
+
+	// Bound on each statement run through the instrumented DB, 0 disables, see fsetl/sqlwrap.go
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+
+	// Sample lock waits of our backends while a batch runs, 0 disables, see fsetl/locks.go
+	LockSampleInterval time.Duration `yaml:"lock_sample_interval"`
+}
+
+func (c DatabaseConfig) appName() string {
+
+	if c.ApplicationName == "" {
+		return defaultApplicationName
+
+	}
+
+	return c.ApplicationName
+}
+
+// dsn returns the DSN with our application_name added.
+func (c DatabaseConfig) dsn() string {
+
+	return withApplicationName(c.DSN, c.appName())
+}
+
+// withApplicationName adds application_name to dsn, URL or key=value form, unless it sets its own.
+func withApplicationName(dsn, appName string) string {
+
+	if dsn == "" || strings.Contains(dsn, "application_name") {
+		return dsn
+
+	}
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn // let openDB complain
+
+		}
+		q := u.Query()
+		q.Set("application_name", appName)
+		u.RawQuery = q.Encode()
+		return u.String()
+
+	}
+
+	return dsn + " application_name=" + pq.QuoteLiteral(appName)
+}
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+
+	files, err := fs.Glob(migrationFiles, "migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+
+	}
+
+	var ms []migration
+	seen := make(map[int]string)
+	for _, f := range files {
+		base := strings.TrimSuffix(path.Base(f), ".up.sql")
+
+		v, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.up.sql", f)
+
+		}
+
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migration %s: version %d already used by %s", f, version, other)
+
+		}
+		seen[version] = f
+
+		body, err := migrationFiles.ReadFile(f)
+		if err != nil {
+			return nil, err
+
+		}
+
+		ms = append(ms, migration{version: version, name: name, sql: string(body)})
+	}
+
+	sort.Slice(ms, func(i, j int) bool { return ms[i].version < ms[j].version })
+
+	return ms, nil
+}
+
+// openDB opens the Postgres pool and checks we can actually reach it.
+func openDB(ctx context.Context, dsn string) (*sql.DB, error) {
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+
+	}
+
+	return db, nil
+}
+
+// Migrate applies all outstanding migrations and returns how many were applied.
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+
+	ms, err := loadMigrations()
+	if err != nil {
+		return 0, err
+
+	}
+
+	// Advisory locks are per session, so hold on to one connection throughout.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return 0, fmt.Errorf("migration lock: %w", err)
+
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS fs_etl_schema_migrations (
+		version		INTEGER PRIMARY KEY,
+		name		TEXT NOT NULL,
+		applied_at	TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return 0, fmt.Errorf("creating fs_etl_schema_migrations: %w", err)
+
+	}
+
+	applied := make(map[int]bool)
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM fs_etl_schema_migrations`)
+	if err != nil {
+		return 0, err
+
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return 0, err
+
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+
+	}
+
+	n := 0
+	for _, mg := range ms {
+		if applied[mg.version] {
+			continue
+
+		}
+
+		if err := applyMigration(ctx, conn, mg); err != nil {
+			return n, fmt.Errorf("migration %04d_%s: %w", mg.version, mg.name, err)
+
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, mg migration) error {
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, mg.sql); err != nil {
+		return err
+
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO fs_etl_schema_migrations (version, name) VALUES ($1, $2)`, mg.version, mg.name); err != nil {
+		return err
+
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,33 @@
+-- Wrapper owned tables: run audit, checkpoints and batch definitions
+
+CREATE TABLE IF NOT EXISTS fs_etl_run_audit (
+    id                  BIGSERIAL PRIMARY KEY,
+    job                 TEXT NOT NULL,
+    batch               TEXT NOT NULL,
+    started_at          TIMESTAMPTZ NOT NULL,
+    finished_at         TIMESTAMPTZ,
+    status              TEXT NOT NULL,
+    records             BIGINT NOT NULL DEFAULT 0,
+    duration_seconds    DOUBLE PRECISION,
+    error               TEXT
+);
+
+CREATE INDEX IF NOT EXISTS fs_etl_run_audit_batch_started_idx ON fs_etl_run_audit (batch, started_at);
+
+CREATE TABLE IF NOT EXISTS fs_etl_checkpoint (
+    job                 TEXT NOT NULL,
+    batch               TEXT NOT NULL,
+    position            TEXT NOT NULL,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job, batch)
+);
+
+CREATE TABLE IF NOT EXISTS fs_etl_batch_definition (
+    batch               TEXT PRIMARY KEY,
+    description         TEXT,
+    schedule            TEXT,
+    enabled             BOOLEAN NOT NULL DEFAULT TRUE,
+    params              JSONB NOT NULL DEFAULT '{}',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,13 @@
+-- The slowest records of each run, see slowest.go
+
+CREATE TABLE IF NOT EXISTS fs_etl_slow_record (
+    id                  BIGSERIAL PRIMARY KEY,
+    job                 TEXT NOT NULL,
+    batch               TEXT NOT NULL,
+    started_at          TIMESTAMPTZ NOT NULL,
+    record_id           TEXT NOT NULL,
+    duration_seconds    DOUBLE PRECISION NOT NULL,
+    phases              JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS fs_etl_slow_record_batch_started_idx ON fs_etl_slow_record (batch, started_at);
@@ -0,0 +1,12 @@
+-- The p95 per phase of succeeded runs, the rolling performance baseline, see baseline.go
+
+CREATE TABLE IF NOT EXISTS fs_etl_perf_baseline (
+    id                  BIGSERIAL PRIMARY KEY,
+    job                 TEXT NOT NULL,
+    batch               TEXT NOT NULL,
+    phase               TEXT NOT NULL,
+    finished_at         TIMESTAMPTZ NOT NULL,
+    p95_seconds         DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fs_etl_perf_baseline_job_batch_phase_idx ON fs_etl_perf_baseline (job, batch, phase, finished_at);
@@ -0,0 +1,208 @@
+/*****************************************************************************
+*
+*	File			: profile.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Per environment profiles (dev, staging, prod, ...), each selecting the
+*					: metric families, log level and sinks that are active. The profile is
+*					: picked by profile: in the config, the PROMWRAP_PROFILE environment
+*					: variable or --profile, so switching a laptop run from prod to dev is one setting
+*					: rather than editing the gateway URL and hoping nobody forgets.
+*
+*					: The profile's settings replace the top level ones, anything it doesn't
+*					: set is left as per the rest of the config.
+*
+*					: A profile can also limit the gateways it pushes to (gateways: allow/deny),
+*					: the process refuses to start rather than push to a gateway of another
+*					: environment, eg. prod dashboards fed by a test run on a laptop.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"myapp/promwrap"
+)
+
+// Sinks a profile can enable
+const (
+	sinkPushgateway = "pushgateway"
+	sinkTextfile    = "textfile"
+	sinkArchive     = "archive"
+)
+
+// Log levels, each includes the ones after it, failures are always logged.
+const (
+	logDebug = "debug"
+	logInfo  = "info"
+	logError = "error"
+)
+
+type ProfileConfig struct {
+	// Replaces the top level suppress when set, see promwrap/suppress.go
+	Suppress *promwrap.SuppressConfig `yaml:"suppress"`
+
+	// debug, info or error, default debug
+	LogLevel string `yaml:"log_level"`
+
+	// pushgateway, textfile and/or archive. Leaving it out keeps all of them as
+	// configured, an empty list disables all of them.
+	Sinks []string `yaml:"sinks"`
+
+	// Gateways this environment may push to, see checkGateway
+	Gateways GatewayGuardConfig `yaml:"gateways"`
+}
+
+// GatewayGuardConfig are gateway URL prefixes, eg. "https://pushgateway.prod.", an empty
+// allow list allows every gateway not denied.
+type GatewayGuardConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// Current log level, see applyLogLevel.
+var logLevel = logDebug
+
+// applyProfile applies the selected profile to the config, no profile leaves it alone.
+func (c *Config) applyProfile() error {
+
+	name := c.Profile
+	if name == "" {
+		return nil
+
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q, expected one of %v", name, names)
+
+	}
+
+	switch p.LogLevel {
+	case "":
+
+	case logDebug, logInfo, logError:
+		logLevel = p.LogLevel
+
+	default:
+		return fmt.Errorf("profile %s: log_level %q, expected debug, info or error", name, p.LogLevel)
+
+	}
+
+	if p.Suppress != nil {
+		c.Suppress = *p.Suppress
+	}
+
+	if p.Sinks != nil {
+		active := make(map[string]bool)
+		for _, s := range p.Sinks {
+			switch s {
+			case sinkPushgateway, sinkTextfile, sinkArchive:
+				active[s] = true
+
+			default:
+				return fmt.Errorf("profile %s: unknown sink %q, expected pushgateway, textfile or archive", name, s)
+
+			}
+		}
+
+		c.Pushgateway.Disabled = c.Pushgateway.Disabled || !active[sinkPushgateway]
+		if !active[sinkTextfile] {
+			c.Exposition.Textfile = ""
+		}
+		if !active[sinkArchive] {
+			c.Archive.Dir = ""
+		}
+	}
+
+	infof("Running with profile %s...\n", name)
+
+	return nil
+}
+
+// checkGateway refuses pushgateway URLs the selected profile doesn't allow, eg. the
+// production gateway from a dev run. Called once the URLs are final, after all overrides.
+func (c Config) checkGateway() error {
+
+	name := c.Profile
+	if name == "" || c.Pushgateway.Disabled {
+		return nil
+
+	}
+
+	g := c.Profiles[name].Gateways
+	for _, url := range c.Pushgateway.GatewayURLs() {
+		if err := g.check(name, url); err != nil {
+			return err
+
+		}
+	}
+
+	return nil
+}
+
+// check refuses url unless allowed and not denied, every gateway has to pass.
+func (g GatewayGuardConfig) check(profile, url string) error {
+
+	for _, prefix := range g.Deny {
+		if strings.HasPrefix(url, prefix) {
+			return fmt.Errorf("profile %s may not push to %s, denied by %q", profile, url, prefix)
+
+		}
+	}
+
+	if len(g.Allow) == 0 {
+		return nil
+
+	}
+	for _, prefix := range g.Allow {
+		if strings.HasPrefix(url, prefix) {
+			return nil
+
+		}
+	}
+
+	return fmt.Errorf("profile %s may not push to %s, it only allows %v", profile, url, g.Allow)
+}
+
+// applyLogLevel points promwrap.Log, which debugf and infof log through too, at
+// stdout at the configured level.
+func applyLogLevel() error {
+
+	l, err := promwrap.NewStdoutLogger(logLevel)
+	if err != nil {
+		return err
+
+	}
+	promwrap.Log = l
+
+	return nil
+}
+
+// debugf logs the chatty progress messages, through promwrap.Log.
+func debugf(format string, args ...interface{}) {
+
+	promwrap.Log.Debug(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// infof logs progress messages worth seeing in production, see debugf.
+func infof(format string, args ...interface{}) {
+
+	promwrap.Log.Info(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
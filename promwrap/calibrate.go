@@ -0,0 +1,200 @@
+/*****************************************************************************
+*
+*	File			: calibrate.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Bucket calibration. With calibration.enabled every histogram observation
+*					: is also written, raw, to calibration.file, one "name seconds" line each,
+*					: meant for a trial run rather than production. Afterwards
+*
+*					:   myapp calibrate
+*
+*					: fits calibration.buckets exponential buckets to each histogram's
+*					: observations, its 1st to 99th percentile, and prints them as the
+*					: buckets section, which overrides the buckets set in code,
+*
+*					:   buckets:
+*					:     fs_api_duration_seconds: [0.021, 0.04, 0.077, ...]
+*
+*					: by family name as in code, applied through HistogramOpts.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultCalibrationFile    = "promwrap_calibration.txt"
+	defaultCalibrationBuckets = 10
+)
+
+type CalibrationConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	File    string `yaml:"file"`    // default promwrap_calibration.txt
+	Buckets int    `yaml:"buckets"` // per histogram in the recommendation, default 10
+}
+
+func (c CalibrationConfig) WithDefaults() CalibrationConfig {
+
+	if c.File == "" {
+		c.File = defaultCalibrationFile
+	}
+	if c.Buckets <= 1 {
+		c.Buckets = defaultCalibrationBuckets
+	}
+
+	return c
+}
+
+// calibrator appends the raw observations to the calibration file.
+type calibrator struct {
+	mu    sync.Mutex
+	f     *os.File
+	names map[prometheus.Collector]string
+}
+
+func newCalibrator(c CalibrationConfig) (*calibrator, error) {
+
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(c.WithDefaults().File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("calibration file: %w", err)
+
+	}
+
+	return &calibrator{f: f, names: make(map[prometheus.Collector]string)}, nil
+}
+
+// calibrate writes observation v of c when calibrating, unbuffered, a run may end in
+// os.Exit anywhere.
+func (m *Metrics) calibrate(c prometheus.Collector, v float64) {
+
+	cal := m.calibration
+	if cal == nil {
+		return
+	}
+
+	cal.mu.Lock()
+	defer cal.mu.Unlock()
+
+	name, ok := cal.names[c]
+	if !ok {
+		name = describe(c)
+		cal.names[c] = name
+	}
+
+	if _, err := fmt.Fprintf(cal.f, "%s %g\n", name, v); err != nil {
+		reportFailure("Could not write calibration file:", err)
+	}
+}
+
+// validateBuckets checks the buckets section, each family's buckets increasing.
+func validateBuckets(buckets map[string][]float64) error {
+
+	for name, bs := range buckets {
+		if len(bs) == 0 {
+			return fmt.Errorf("buckets of %s empty", name)
+
+		}
+		for i := 1; i < len(bs); i++ {
+			if bs[i] <= bs[i-1] {
+				return fmt.Errorf("buckets of %s not increasing at %g", name, bs[i])
+
+			}
+		}
+	}
+
+	return nil
+}
+
+// RecommendBuckets fits n exponential buckets to the observations of every histogram in
+// the calibration file at path.
+func RecommendBuckets(path string, n int) (map[string][]float64, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+
+	}
+	defer f.Close()
+
+	observed := make(map[string][]float64)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		v, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("%s line %d, expected name and seconds", path, line)
+
+		}
+		observed[name] = append(observed[name], v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+
+	}
+
+	buckets := make(map[string][]float64, len(observed))
+	for name, vs := range observed {
+		sort.Float64s(vs)
+		buckets[name] = ExponentialFit(percentile(vs, 0.01), percentile(vs, 0.99), n)
+	}
+
+	return buckets, nil
+}
+
+// percentile of sorted vs, nearest rank.
+func percentile(sorted []float64, q float64) float64 {
+
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+// ExponentialFit returns n exponential buckets from low to high, rounded to two
+// significant digits so they read well in a config file. A low of 0 or less starts
+// at high/1000.
+func ExponentialFit(low, high float64, n int) []float64 {
+
+	if high <= 0 {
+		high = 1
+	}
+	if low <= 0 || low >= high {
+		low = high / 1000
+	}
+
+	var buckets []float64
+	for _, b := range prometheus.ExponentialBucketsRange(low, high, n) {
+		b, _ = strconv.ParseFloat(strconv.FormatFloat(b, 'g', 2, 64), 64)
+		if len(buckets) == 0 || b > buckets[len(buckets)-1] {
+			buckets = append(buckets, b)
+		}
+	}
+
+	return buckets
+}
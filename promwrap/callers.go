@@ -0,0 +1,70 @@
+/*****************************************************************************
+*
+*	File			: callers.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Source location labels, dev builds only (go build -tags dev). The metric
+*					: families listed in caller_labels get a caller="file:line" const label,
+*					: the place they were registered, to trace which fs_loader module is
+*					: producing a given series. Production builds ignore caller_labels, the
+*					: labels never make it into a production push.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const callerLabel = "caller"
+
+// callerLabelFamilies are the families that get the caller label, set from caller_labels
+// before NewMetrics.
+var callerLabelFamilies map[string]bool
+
+func ApplyCallerLabels(families []string) {
+
+	if len(families) == 0 {
+		return
+	}
+
+	if !devBuild {
+		Log.Warn("caller_labels ignored, only supported by dev builds (-tags dev)")
+		return
+
+	}
+
+	callerLabelFamilies = make(map[string]bool)
+	for _, name := range families {
+		callerLabelFamilies[name] = true
+	}
+}
+
+// callerRegisterer returns the registerer for c, reg wrapped with the caller label
+// if c is one of the selected families. skip is the number of frames up to the
+// registration site.
+func callerRegisterer(reg prometheus.Registerer, c prometheus.Collector, skip int) prometheus.Registerer {
+
+	if !devBuild || !callerLabelFamilies[describe(c)] {
+		return reg
+	}
+
+	site := "unknown"
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		site = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	return prometheus.WrapRegistererWith(prometheus.Labels{callerLabel: site}, reg)
+}
@@ -0,0 +1,21 @@
+//go:build dev
+
+/*****************************************************************************
+*
+*	File			: callers_dev.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Dev build, enables the caller labels, see callers.go
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+const devBuild = true
@@ -0,0 +1,21 @@
+//go:build !dev
+
+/*****************************************************************************
+*
+*	File			: callers_prod.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Production build, caller labels are stripped, see callers.go
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+const devBuild = false
@@ -0,0 +1,112 @@
+/*****************************************************************************
+*
+*	File			: compat.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Compatibility with pre 1.0 gateways, pushgateway.compat, for the 0.9.x
+*					: gateway still running in one DC. In legacy mode we
+*
+*					:   push the text format rather than delimited protobuf
+*					:   strip sample timestamps, an old gateway keeps them and Prometheus
+*					:   honors them, so the series go stale as if the job stopped pushing
+*					:   refuse job names and grouping values (phases) that need the
+*					:   @base64 encoding old gateways don't understand, eg. with a '/'
+*
+*					: auto asks the gateway for its version at startup, 1.x gateways get
+*					: the current protocol. One without the status API, or one we can't
+*					: reach, gets legacy, which 1.x gateways accept just as well.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Compatibility modes, pushgateway.compat
+const (
+	CompatCurrent = ""
+	CompatLegacy  = "legacy"
+	CompatAuto    = "auto"
+)
+
+// legacyGateway decides whether we talk to the gateway as a pre 1.0 one.
+func (c PushgatewayConfig) legacyGateway(client push.HTTPDoer) (bool, error) {
+
+	switch c.Compat {
+	case CompatCurrent:
+		return false, nil
+
+	case CompatLegacy:
+		return true, nil
+
+	case CompatAuto:
+		if c.Disabled {
+			return false, nil
+
+		}
+		version, err := preflight(context.Background(), client, c.URL, c.PreflightTimeout, c.Username, c.Password)
+		legacy := err != nil || version == "unknown" || strings.HasPrefix(version, "0.")
+		Log.Info("Pushgateway version", "version", version, "legacy", legacy)
+		return legacy, nil
+
+	}
+
+	return false, fmt.Errorf("pushgateway compat %q, expected legacy or auto", c.Compat)
+}
+
+// legacyName checks a job name or grouping value can be sent to a pre 1.0 gateway.
+func legacyName(what, name string) error {
+
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("pushgateway %s %q needs base64 encoding, not supported by pre 1.0 gateways", what, name)
+
+	}
+
+	return nil
+}
+
+// withoutTimestamps returns mfs without sample timestamps, copying only the families that had any.
+func withoutTimestamps(mfs []*dto.MetricFamily) []*dto.MetricFamily {
+
+	out, copied := mfs, false
+	for i, mf := range mfs {
+		stamped := false
+		for _, mt := range mf.GetMetric() {
+			if mt.TimestampMs != nil {
+				stamped = true
+				break
+
+			}
+		}
+		if !stamped {
+			continue
+
+		}
+
+		if !copied {
+			out, copied = append([]*dto.MetricFamily(nil), mfs...), true
+		}
+		mf = proto.Clone(mf).(*dto.MetricFamily)
+		for _, mt := range mf.GetMetric() {
+			mt.TimestampMs = nil
+		}
+		out[i] = mf
+	}
+
+	return out
+}
@@ -0,0 +1,230 @@
+/*****************************************************************************
+*
+*	File			: concurrency.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Adaptive concurrency, a worker pool limit driven by the latency of the
+*					: SQL/API calls the workers make, AIMD style. Every window calls the limit
+*					: goes up by one while their average latency stays under target_latency,
+*					: and is multiplied by backoff once it doesn't, so the pool finds the most
+*					: throughput the database or API takes without tuning it per environment,
+*
+*					:   cc, _ := w.Metrics.AdaptiveConcurrency("api", promwrap.ConcurrencyConfig{
+*					:       Max: 32, TargetLatency: 200 * time.Millisecond})
+*					:   for _, rec := range records {
+*					:       done, err := cc.Acquire(ctx)
+*					:       ...
+*					:       go func() { start := time.Now(); call(rec); done(time.Since(start)) }()
+*					:   }
+*
+*					: exporting fs_etl_concurrency_limit{controller}, the current pool size,
+*					: fs_etl_concurrency_in_flight{controller} and
+*					: fs_etl_concurrency_adjustments_total{controller,direction}, up or down.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultConcurrencyMax     = 16
+	defaultConcurrencyWindow  = 20
+	defaultConcurrencyBackoff = 0.7
+)
+
+type ConcurrencyConfig struct {
+	Min           int           `yaml:"min"`            // default 1
+	Max           int           `yaml:"max"`            // default 16
+	Initial       int           `yaml:"initial"`        // default min
+	TargetLatency time.Duration `yaml:"target_latency"` // required
+	Window        int           `yaml:"window"`         // calls per adjustment, default 20
+	Backoff       float64       `yaml:"backoff"`        // multiplicative decrease, default 0.7
+}
+
+func (c ConcurrencyConfig) withDefaults() (ConcurrencyConfig, error) {
+
+	if c.TargetLatency <= 0 {
+		return c, fmt.Errorf("concurrency target_latency %s, expected a positive duration", c.TargetLatency)
+
+	}
+	if c.Min <= 0 {
+		c.Min = 1
+	}
+	if c.Max <= 0 {
+		c.Max = defaultConcurrencyMax
+	}
+	if c.Max < c.Min {
+		return c, fmt.Errorf("concurrency max %d below min %d", c.Max, c.Min)
+
+	}
+	if c.Initial < c.Min || c.Initial > c.Max {
+		c.Initial = c.Min
+	}
+	if c.Window <= 0 {
+		c.Window = defaultConcurrencyWindow
+	}
+	if c.Backoff <= 0 || c.Backoff >= 1 {
+		c.Backoff = defaultConcurrencyBackoff
+	}
+
+	return c, nil
+}
+
+// Controller limits the calls in flight, adapting the limit to their latency.
+type Controller struct {
+	c ConcurrencyConfig
+
+	limitGauge    prometheus.Gauge
+	inFlightGauge prometheus.Gauge
+	up, down      prometheus.Counter
+
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	wake     chan struct{} // closed when a slot may have come free
+	sum      time.Duration // latency of the window so far
+	count    int
+}
+
+// AdaptiveConcurrency returns controller name, see ConcurrencyConfig.
+func (m *Metrics) AdaptiveConcurrency(name string, c ConcurrencyConfig) (*Controller, error) {
+
+	c, err := c.withDefaults()
+	if err != nil {
+		return nil, err
+
+	}
+
+	cc := &Controller{c: c, limit: c.Initial, wake: make(chan struct{})}
+
+	col, err := RegisterOrExisting(m.reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fs_etl_concurrency_limit",
+		Help: "Current limit of the adaptive concurrency controller, the worker pool size.",
+	}, []string{"controller"}))
+	if err != nil {
+		return nil, err
+
+	}
+	cc.limitGauge = col.(*prometheus.GaugeVec).WithLabelValues(name)
+
+	if col, err = RegisterOrExisting(m.reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fs_etl_concurrency_in_flight",
+		Help: "Calls in flight under the adaptive concurrency controller.",
+	}, []string{"controller"})); err != nil {
+		return nil, err
+
+	}
+	cc.inFlightGauge = col.(*prometheus.GaugeVec).WithLabelValues(name)
+
+	if col, err = RegisterOrExisting(m.reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_concurrency_adjustments_total",
+		Help: "The number of times the adaptive concurrency controller changed its limit, by direction.",
+	}, []string{"controller", "direction"})); err != nil {
+		return nil, err
+
+	}
+	cc.up = col.(*prometheus.CounterVec).WithLabelValues(name, "up")
+	cc.down = col.(*prometheus.CounterVec).WithLabelValues(name, "down")
+
+	cc.limitGauge.Set(float64(cc.limit))
+
+	return cc, nil
+}
+
+// Limit returns the current limit.
+func (cc *Controller) Limit() int {
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	return cc.limit
+}
+
+// Acquire waits for a slot, or until ctx is done. done hands the slot back with the
+// latency of the call made in it.
+func (cc *Controller) Acquire(ctx context.Context) (done func(latency time.Duration), err error) {
+
+	for {
+		cc.mu.Lock()
+		if cc.inFlight < cc.limit {
+			cc.inFlight++
+			cc.inFlightGauge.Set(float64(cc.inFlight))
+			cc.mu.Unlock()
+
+			var once sync.Once
+			return func(latency time.Duration) { once.Do(func() { cc.release(latency) }) }, nil
+
+		}
+		wake := cc.wake
+		cc.mu.Unlock()
+
+		select {
+		case <-wake:
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		}
+	}
+}
+
+func (cc *Controller) release(latency time.Duration) {
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.inFlight--
+	cc.inFlightGauge.Set(float64(cc.inFlight))
+
+	cc.sum += latency
+	cc.count++
+	if cc.count >= cc.c.Window {
+		cc.adjust(cc.sum / time.Duration(cc.count))
+		cc.sum, cc.count = 0, 0
+	}
+
+	close(cc.wake)
+	cc.wake = make(chan struct{})
+}
+
+// adjust moves the limit for a window with average latency avg, with cc.mu held.
+func (cc *Controller) adjust(avg time.Duration) {
+
+	limit := cc.limit
+	if avg > cc.c.TargetLatency {
+		limit = int(float64(limit) * cc.c.Backoff)
+		if limit < cc.c.Min {
+			limit = cc.c.Min
+		}
+
+	} else if limit < cc.c.Max {
+		limit++
+
+	}
+
+	switch {
+	case limit > cc.limit:
+		cc.up.Inc()
+
+	case limit < cc.limit:
+		cc.down.Inc()
+
+	}
+	cc.limit = limit
+	cc.limitGauge.Set(float64(limit))
+}
@@ -0,0 +1,87 @@
+/*****************************************************************************
+*
+*	File			: consistency.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Consistent gathering. Related gauges are updated together under the
+*					: write lock, see Atomically() and job.Update(), and every Gather for a push,
+*					: scrape or textfile takes the read lock, so a half updated set of
+*					: gauges never leaves the process.
+*
+*					: With consistent_gather set, Transaction() extends that to any group of
+*					: updates, eg. when a summary gauge is derived from several metrics.
+*					: Gathering waits for the transaction to finish, so keep them short.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Shared by all registries, the A/B run has one per variant.
+var consistency sync.RWMutex
+
+// ConsistentGather makes Transaction() hold off gathering, set from config before
+// any updates happen.
+var ConsistentGather bool
+
+// Transaction runs fn, a logical group of metric updates, with gathering held off
+// when consistent_gather is set. fn may not push, nor call job.Update(), both
+// need the lock Transaction is holding.
+func Transaction(fn func()) {
+
+	if ConsistentGather {
+		consistency.Lock()
+		defer consistency.Unlock()
+	}
+
+	fn()
+}
+
+// Atomically runs fn, a group of metric updates that must never be seen half done,
+// with gathering held off, regardless of ConsistentGather.
+func Atomically(fn func()) {
+
+	consistency.Lock()
+	defer consistency.Unlock()
+
+	fn()
+}
+
+// Consistent returns g, gathering while no grouped update is in flight, without
+// the suppressed families (see suppress.go) and renamed as per the namespace (see
+// namespace.go).
+func Consistent(g prometheus.Gatherer) prometheus.Gatherer {
+
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := consistentGatherer{g}.Gather()
+		return renamed(mfs), err
+	})
+}
+
+// consistentGatherer gathers g while no grouped update is in flight.
+type consistentGatherer struct {
+	g prometheus.Gatherer
+}
+
+func (c consistentGatherer) Gather() ([]*dto.MetricFamily, error) {
+
+	consistency.RLock()
+	defer consistency.RUnlock()
+
+	mfs, err := c.g.Gather()
+
+	return unsuppressed(mfs), err
+}
@@ -0,0 +1,64 @@
+/*****************************************************************************
+*
+*	File			: constlabels.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Constant labels on every metric registered through the wrapper, its own
+*					: included, so dashboards can tell deployments apart without the call
+*					: sites passing extra label values,
+*
+*					:   const_labels:
+*					:     env: prod
+*					:     region: za
+*					:     app: fs_loader
+*
+*					: WithDefaultLabels adds to them in code, a label set both ways takes the
+*					: code's value. job and instance belong to the Pushgateway, and a label a
+*					: metric already has fails its registration.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// constLabels merges the configured labels with the ones set in code, and checks them.
+func constLabels(configured map[string]string, code prometheus.Labels) (prometheus.Labels, error) {
+
+	labels := prometheus.Labels{}
+	for k, v := range configured {
+		labels[k] = v
+	}
+	for k, v := range code {
+		labels[k] = v
+	}
+
+	for name, value := range labels {
+		switch {
+		case name == "job" || name == "instance":
+			return nil, fmt.Errorf("const label %s belongs to the Pushgateway", name)
+
+		case !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__"):
+			return nil, fmt.Errorf("const label name %q, expected letters, digits and underscores", name)
+
+		case value == "":
+			return nil, fmt.Errorf("const label %s has no value", name)
+
+		}
+	}
+
+	return labels, nil
+}
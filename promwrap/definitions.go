@@ -0,0 +1,303 @@
+/*****************************************************************************
+*
+*	File			: definitions.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metrics declared in a yaml file (metric_definitions) rather than in code,
+*					: so adding or dropping a gauge, counter, histogram or summary is a config change,
+*					: not a rebuild,
+*
+*					:   metrics:
+*					:     - name: fs_etl_rows_rejected_total
+*					:       type: counter
+*					:       help: "Rows rejected by the validation step."
+*					:       labels: [batch, reason]
+*
+*					: The code updates them by name, m.Inc(m.Counter("fs_etl_rows_rejected_total"),
+*					: "eft", "null_key"), through the same checks as the code defined metrics.
+*					: An undefined name, or one of another type, is reported as metric misuse.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// Metric definition types
+const (
+	TypeGauge     = "gauge"
+	TypeCounter   = "counter"
+	TypeHistogram = "histogram"
+	TypeSummary   = "summary"
+)
+
+type MetricDefinition struct {
+	Name    string    `yaml:"name"`
+	Type    string    `yaml:"type"` // gauge, counter, histogram or summary
+	Help    string    `yaml:"help"`
+	Labels  []string  `yaml:"labels"`
+	Buckets []float64 `yaml:"buckets"` // histograms only, default prometheus.DefBuckets
+
+	// summaries only, quantile -> allowed error, default DefaultObjectives, see summary.go
+	Objectives map[float64]float64 `yaml:"objectives"`
+	MaxAge     time.Duration       `yaml:"max_age"` // default 10m
+}
+
+func (d MetricDefinition) validate() error {
+
+	if d.Name == "" {
+		return fmt.Errorf("metric definition without a name")
+
+	}
+
+	if d.Help == "" {
+		return fmt.Errorf("metric %s: help is required", d.Name)
+
+	}
+
+	if d.Type != TypeSummary && (len(d.Objectives) > 0 || d.MaxAge != 0) {
+		return fmt.Errorf("metric %s: objectives and max_age only apply to summaries", d.Name)
+
+	}
+
+	switch d.Type {
+	case TypeGauge, TypeCounter:
+		if len(d.Buckets) > 0 {
+			return fmt.Errorf("metric %s: buckets only apply to histograms", d.Name)
+
+		}
+
+	case TypeSummary:
+		if len(d.Buckets) > 0 {
+			return fmt.Errorf("metric %s: buckets only apply to histograms", d.Name)
+
+		}
+		for _, l := range d.Labels {
+			if l == "quantile" {
+				return fmt.Errorf("metric %s: quantile is reserved for the summary quantiles", d.Name)
+
+			}
+		}
+		if err := ValidateObjectives(d.Objectives); err != nil {
+			return fmt.Errorf("metric %s: %w", d.Name, err)
+
+		}
+
+	case TypeHistogram:
+		for _, l := range d.Labels {
+			if l == "le" {
+				return fmt.Errorf("metric %s: le is reserved for the histogram buckets", d.Name)
+
+			}
+		}
+		for i := 1; i < len(d.Buckets); i++ {
+			if d.Buckets[i] <= d.Buckets[i-1] {
+				return fmt.Errorf("metric %s: buckets must be in increasing order", d.Name)
+
+			}
+		}
+
+	default:
+		return fmt.Errorf("metric %s: type %q, expected gauge, counter, histogram or summary", d.Name, d.Type)
+
+	}
+
+	return nil
+}
+
+func (d MetricDefinition) collector(m *Metrics) prometheus.Collector {
+
+	switch d.Type {
+	case TypeGauge:
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: d.Name, Help: d.Help}, d.Labels)
+
+	case TypeCounter:
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: d.Name, Help: d.Help}, d.Labels)
+
+	case TypeSummary:
+		return NewSummaryVec(prometheus.SummaryOpts{Name: d.Name, Help: d.Help, Objectives: d.Objectives, MaxAge: d.MaxAge}, d.Labels)
+
+	}
+
+	return prometheus.NewHistogramVec(m.HistogramOpts(prometheus.HistogramOpts{Name: d.Name, Help: d.Help, Buckets: d.Buckets}), d.Labels)
+}
+
+// LoadDefinitions reads the metric definitions in the yaml file at path.
+func LoadDefinitions(path string) ([]MetricDefinition, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading metric definitions %s: %w", path, err)
+
+	}
+
+	var file struct {
+		Metrics []MetricDefinition `yaml:"metrics"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing metric definitions %s: %w", path, err)
+
+	}
+
+	return file.Metrics, nil
+}
+
+// Define creates and registers the defined metrics, none of them if any is invalid
+// or clashes with a metric registered earlier.
+func (m *Metrics) Define(defs ...MetricDefinition) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, d := range defs {
+		if err := d.validate(); err != nil {
+			return err
+
+		}
+		if seen[d.Name] || m.defined[d.Name] != nil {
+			return fmt.Errorf("metric %s defined twice", d.Name)
+
+		}
+		seen[d.Name] = true
+	}
+
+	var done []prometheus.Collector
+	for _, d := range defs {
+		c := d.collector(m)
+		if err := m.reg.Register(c); err != nil {
+			for _, c := range done {
+				m.reg.Unregister(c)
+			}
+			return fmt.Errorf("metric %s: %w", d.Name, err)
+
+		}
+		done = append(done, c)
+	}
+
+	if m.defined == nil {
+		m.defined = make(map[string]prometheus.Collector)
+		m.definitions = make(map[string]MetricDefinition)
+	}
+	for i, c := range done {
+		m.defined[defs[i].Name] = c
+		m.definitions[defs[i].Name] = defs[i]
+		m.registered[c] = m.reg
+	}
+
+	return nil
+}
+
+// Defined returns the names of the defined metrics, sorted.
+func (m *Metrics) Defined() []string {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.defined))
+	for name := range m.defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Gauge returns the defined gauge name. An undefined name, or one of another type,
+// is reported as misuse and gets an unregistered stand-in, so the update that
+// follows is rejected as well rather than panicking on a nil vector.
+func (m *Metrics) Gauge(name string) *prometheus.GaugeVec {
+
+	c, err := m.lookup(name, TypeGauge)
+	if g, ok := c.(*prometheus.GaugeVec); ok {
+		return g
+
+	}
+	m.Misuse(err)
+
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: "undefined"}, nil)
+}
+
+// Counter returns the defined counter name, see Gauge.
+func (m *Metrics) Counter(name string) *prometheus.CounterVec {
+
+	c, err := m.lookup(name, TypeCounter)
+	if cv, ok := c.(*prometheus.CounterVec); ok {
+		return cv
+
+	}
+	m.Misuse(err)
+
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: "undefined"}, nil)
+}
+
+// Histogram returns the defined histogram name, see Gauge.
+func (m *Metrics) Histogram(name string) *prometheus.HistogramVec {
+
+	c, err := m.lookup(name, TypeHistogram)
+	if h, ok := c.(*prometheus.HistogramVec); ok {
+		return h
+
+	}
+	m.Misuse(err)
+
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: "undefined"}, nil)
+}
+
+// Summary returns the defined summary name, see Gauge.
+func (m *Metrics) Summary(name string) *prometheus.SummaryVec {
+
+	c, err := m.lookup(name, TypeSummary)
+	if s, ok := c.(*prometheus.SummaryVec); ok {
+		return s
+
+	}
+	m.Misuse(err)
+
+	return prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: name, Help: "undefined"}, nil)
+}
+
+// lookup returns the defined metric name if it's of type typ.
+func (m *Metrics) lookup(name, typ string) (prometheus.Collector, error) {
+
+	m.mu.Lock()
+	c := m.defined[name]
+	m.mu.Unlock()
+
+	ok := false
+	switch c.(type) {
+	case *prometheus.GaugeVec:
+		ok = typ == TypeGauge
+
+	case *prometheus.CounterVec:
+		ok = typ == TypeCounter
+
+	case *prometheus.HistogramVec:
+		ok = typ == TypeHistogram
+
+	case *prometheus.SummaryVec:
+		ok = typ == TypeSummary
+
+	}
+	if !ok {
+		return nil, fmt.Errorf("no %s %s in the metric definitions", typ, name)
+
+	}
+
+	return c, nil
+}
@@ -0,0 +1,422 @@
+/*****************************************************************************
+*
+*	File			: derived.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Derived metrics, gauges computed at gather time from other metrics,
+*
+*					:   derived:
+*					:     - name: fs_etl_file_error_ratio
+*					:       help: "Share of the files that failed processing."
+*					:       expr: fs_etl_file_errors_total / fs_etl_file_duration_seconds_count
+*					:       by: [batch]
+*
+*					: Ratios like this are awkward in PromQL against Pushgateway data, the
+*					: series of a failed run linger and rate() over a batch's pushes is
+*					: meaningless, so we push the ratio itself.
+*
+*					: expr is + - * / and parentheses over numbers and metric names, a
+*					: histogram or summary is referenced by its _count or _sum. Each operand
+*					: is summed over the labels not in by, series combine on equal by values,
+*					: a group missing from one of the operands is left out. So is a result
+*					: that isn't a number, eg. 0/0 before the first file was processed.
+*
+*					: The inputs are the metrics registered through Metrics, collected
+*					: directly rather than through a Gather of the registry the derived
+*					: metrics are registered with, that Gather would call back into us.
+*					: Derived metrics can't refer to each other.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type DerivedMetric struct {
+	Name string   `yaml:"name"`
+	Help string   `yaml:"help"`
+	Expr string   `yaml:"expr"`
+	By   []string `yaml:"by"` // labels kept, the operands are summed over all others
+}
+
+// Derive registers the derived metrics, none of them if any is invalid.
+func (m *Metrics) Derive(defs ...DerivedMetric) error {
+
+	c := &derivedCollector{m: m}
+	for _, d := range defs {
+		if d.Name == "" {
+			return fmt.Errorf("derived metric without a name")
+
+		}
+		if d.Help == "" {
+			return fmt.Errorf("derived metric %s: help is required", d.Name)
+
+		}
+		e, err := parseExpr(d.Expr)
+		if err != nil {
+			return fmt.Errorf("derived metric %s: %w", d.Name, err)
+
+		}
+		c.metrics = append(c.metrics, derived{
+			desc: prometheus.NewDesc(d.Name, d.Help, d.By, nil),
+			by:   d.By,
+			expr: e,
+		})
+	}
+
+	if err := m.reg.Register(c); err != nil {
+		return fmt.Errorf("derived metrics: %w", err)
+
+	}
+
+	return nil
+}
+
+type derived struct {
+	desc *prometheus.Desc
+	by   []string
+	expr expr
+}
+
+type derivedCollector struct {
+	m       *Metrics
+	metrics []derived
+}
+
+func (c *derivedCollector) Describe(ch chan<- *prometheus.Desc) {
+
+	for _, d := range c.metrics {
+		ch <- d.desc
+	}
+}
+
+func (c *derivedCollector) Collect(ch chan<- prometheus.Metric) {
+
+	samples := c.inputs()
+
+	for _, d := range c.metrics {
+		v := d.expr.eval(func(name string) operand { return sumBy(samples[name], d.by) })
+		if v.scalar {
+			if !math.IsNaN(v.value) && !math.IsInf(v.value, 0) {
+				ch <- prometheus.MustNewConstMetric(d.desc, prometheus.GaugeValue, v.value)
+			}
+			continue
+
+		}
+		for key, value := range v.groups {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+
+			}
+			var lvs []string
+			if len(d.by) > 0 {
+				lvs = strings.Split(key, "\xff")
+			}
+			ch <- prometheus.MustNewConstMetric(d.desc, prometheus.GaugeValue, value, lvs...)
+		}
+	}
+}
+
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// inputs collects the metrics registered through Metrics, by sample name.
+func (c *derivedCollector) inputs() map[string][]sample {
+
+	c.m.mu.Lock()
+	cs := make([]prometheus.Collector, 0, len(c.m.registered))
+	for col := range c.m.registered {
+		cs = append(cs, col)
+	}
+	c.m.mu.Unlock()
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		for _, col := range cs {
+			col.Collect(metrics)
+		}
+		close(metrics)
+	}()
+
+	samples := make(map[string][]sample)
+	for mt := range metrics {
+		var pb dto.Metric
+		if mt.Write(&pb) != nil {
+			continue
+
+		}
+		name := descName(mt.Desc())
+		labels := make(map[string]string, len(pb.GetLabel()))
+		for _, lp := range pb.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+
+		add := func(name string, v float64) {
+			samples[name] = append(samples[name], sample{labels, v})
+		}
+		switch {
+		case pb.Counter != nil:
+			add(name, pb.GetCounter().GetValue())
+
+		case pb.Gauge != nil:
+			add(name, pb.GetGauge().GetValue())
+
+		case pb.Untyped != nil:
+			add(name, pb.GetUntyped().GetValue())
+
+		case pb.Histogram != nil:
+			add(name+"_count", float64(pb.GetHistogram().GetSampleCount()))
+			add(name+"_sum", pb.GetHistogram().GetSampleSum())
+
+		case pb.Summary != nil:
+			add(name+"_count", float64(pb.GetSummary().GetSampleCount()))
+			add(name+"_sum", pb.GetSummary().GetSampleSum())
+
+		}
+	}
+
+	return samples
+}
+
+// sumBy sums the samples per combination of the by label values.
+func sumBy(samples []sample, by []string) operand {
+
+	groups := make(map[string]float64)
+	for _, s := range samples {
+		lvs := make([]string, len(by))
+		for i, l := range by {
+			lvs[i] = s.labels[l]
+		}
+		groups[strings.Join(lvs, "\xff")] += s.value
+	}
+
+	return operand{groups: groups}
+}
+
+// operand is a number, or a value per group of by label values.
+type operand struct {
+	scalar bool
+	value  float64
+	groups map[string]float64
+}
+
+type expr interface {
+	eval(lookup func(name string) operand) operand
+}
+
+type numberExpr float64
+
+func (n numberExpr) eval(func(string) operand) operand {
+
+	return operand{scalar: true, value: float64(n)}
+}
+
+type nameExpr string
+
+func (n nameExpr) eval(lookup func(string) operand) operand {
+
+	return lookup(string(n))
+}
+
+type binaryExpr struct {
+	op          byte
+	left, right expr
+}
+
+func (b binaryExpr) eval(lookup func(string) operand) operand {
+
+	l, r := b.left.eval(lookup), b.right.eval(lookup)
+
+	switch {
+	case l.scalar && r.scalar:
+		return operand{scalar: true, value: b.apply(l.value, r.value)}
+
+	case l.scalar:
+		groups := make(map[string]float64, len(r.groups))
+		for k, v := range r.groups {
+			groups[k] = b.apply(l.value, v)
+		}
+		return operand{groups: groups}
+
+	case r.scalar:
+		groups := make(map[string]float64, len(l.groups))
+		for k, v := range l.groups {
+			groups[k] = b.apply(v, r.value)
+		}
+		return operand{groups: groups}
+
+	}
+
+	groups := make(map[string]float64)
+	for k, lv := range l.groups {
+		if rv, ok := r.groups[k]; ok {
+			groups[k] = b.apply(lv, rv)
+		}
+	}
+
+	return operand{groups: groups}
+}
+
+func (b binaryExpr) apply(l, r float64) float64 {
+
+	switch b.op {
+	case '+':
+		return l + r
+
+	case '-':
+		return l - r
+
+	case '*':
+		return l * r
+
+	}
+
+	return l / r
+}
+
+// parseExpr parses s, expr := term {(+|-) term}, term := factor {(*|/) factor},
+// factor := number | name | ( expr ).
+func parseExpr(s string) (expr, error) {
+
+	p := &exprParser{s: s}
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, fmt.Errorf("expr %q: unexpected %q at %d", s, p.s[p.pos], p.pos)
+
+	}
+
+	return e, nil
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// next returns the next operator in ops, 0 if there isn't one.
+func (p *exprParser) next(ops string) byte {
+
+	p.skipSpace()
+	if p.pos < len(p.s) && strings.IndexByte(ops, p.s[p.pos]) >= 0 {
+		p.pos++
+		return p.s[p.pos-1]
+
+	}
+
+	return 0
+}
+
+func (p *exprParser) expr() (expr, error) {
+
+	e, err := p.term()
+	for err == nil {
+		op := p.next("+-")
+		if op == 0 {
+			break
+		}
+		var r expr
+		if r, err = p.term(); err == nil {
+			e = binaryExpr{op, e, r}
+		}
+	}
+
+	return e, err
+}
+
+func (p *exprParser) term() (expr, error) {
+
+	e, err := p.factor()
+	for err == nil {
+		op := p.next("*/")
+		if op == 0 {
+			break
+		}
+		var r expr
+		if r, err = p.factor(); err == nil {
+			e = binaryExpr{op, e, r}
+		}
+	}
+
+	return e, err
+}
+
+func (p *exprParser) factor() (expr, error) {
+
+	if p.next("(") != 0 {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+
+		}
+		if p.next(")") == 0 {
+			return nil, fmt.Errorf("expr %q: missing ) at %d", p.s, p.pos)
+
+		}
+		return e, nil
+
+	}
+
+	start := p.pos
+	switch {
+	case p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.'):
+		for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("expr %q: bad number %q", p.s, p.s[start:p.pos])
+
+		}
+		return numberExpr(v), nil
+
+	case p.pos < len(p.s) && isNameChar(p.s[p.pos]):
+		for p.pos < len(p.s) && (isNameChar(p.s[p.pos]) || isDigit(p.s[p.pos])) {
+			p.pos++
+		}
+		return nameExpr(p.s[start:p.pos]), nil
+
+	case p.pos < len(p.s):
+		return nil, fmt.Errorf("expr %q: unexpected %q at %d", p.s, p.s[p.pos], p.pos)
+
+	}
+
+	return nil, fmt.Errorf("expr %q: unexpected end", p.s)
+}
+
+func isDigit(c byte) bool {
+
+	return c >= '0' && c <= '9'
+}
+
+func isNameChar(c byte) bool {
+
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':'
+}
@@ -0,0 +1,175 @@
+/*****************************************************************************
+*
+*	File			: dynamic.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metrics created at runtime, eg. one set per data source discovered by
+*					: the job, rather than fixed in the application's metrics struct,
+*
+*					:   rows, err := w.RegisterCounter("fs_etl_source_rows_total",
+*					:       "Rows read per data source.", []string{"source"})
+*					:   w.Add(rows, 42, "crm")
+*
+*					:   lag, err := w.RegisterHistogram("fs_etl_source_lag_seconds",
+*					:       "Source replication lag.", []string{"source"},
+*					:       promwrap.Buckets(0.1, 1, 10, 60))
+*
+*					: They are metric definitions (see definitions.go) made in code, so the
+*					: name lookups (m.Counter(name)) and the update checks apply as usual.
+*					: Registering a name again returns the existing metric when the type and
+*					: labels match, so every data source can register what it needs without
+*					: coordinating, a mismatch is an error. Undefine drops one again.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricOption sets the type specific options of a metric registered at runtime.
+type MetricOption func(*MetricDefinition)
+
+// Buckets sets a histogram's buckets, default prometheus.DefBuckets.
+func Buckets(buckets ...float64) MetricOption {
+
+	return func(d *MetricDefinition) { d.Buckets = buckets }
+}
+
+// Objectives sets a summary's quantiles and max age, see summary.go.
+func Objectives(objectives map[float64]float64, maxAge time.Duration) MetricOption {
+
+	return func(d *MetricDefinition) { d.Objectives, d.MaxAge = objectives, maxAge }
+}
+
+// RegisterGauge creates gauge name at runtime, or returns the one registered before.
+func (m *Metrics) RegisterGauge(name, help string, labels []string, opts ...MetricOption) (*prometheus.GaugeVec, error) {
+
+	c, err := m.registerDynamic(MetricDefinition{Name: name, Type: TypeGauge, Help: help, Labels: labels}, opts)
+	if err != nil {
+		return nil, err
+
+	}
+
+	return c.(*prometheus.GaugeVec), nil
+}
+
+// RegisterCounter creates counter name at runtime, see RegisterGauge.
+func (m *Metrics) RegisterCounter(name, help string, labels []string, opts ...MetricOption) (*prometheus.CounterVec, error) {
+
+	c, err := m.registerDynamic(MetricDefinition{Name: name, Type: TypeCounter, Help: help, Labels: labels}, opts)
+	if err != nil {
+		return nil, err
+
+	}
+
+	return c.(*prometheus.CounterVec), nil
+}
+
+// RegisterHistogram creates histogram name at runtime, see RegisterGauge and Buckets.
+func (m *Metrics) RegisterHistogram(name, help string, labels []string, opts ...MetricOption) (*prometheus.HistogramVec, error) {
+
+	c, err := m.registerDynamic(MetricDefinition{Name: name, Type: TypeHistogram, Help: help, Labels: labels}, opts)
+	if err != nil {
+		return nil, err
+
+	}
+
+	return c.(*prometheus.HistogramVec), nil
+}
+
+// RegisterSummary creates summary name at runtime, see RegisterGauge and Objectives.
+func (m *Metrics) RegisterSummary(name, help string, labels []string, opts ...MetricOption) (*prometheus.SummaryVec, error) {
+
+	c, err := m.registerDynamic(MetricDefinition{Name: name, Type: TypeSummary, Help: help, Labels: labels}, opts)
+	if err != nil {
+		return nil, err
+
+	}
+
+	return c.(*prometheus.SummaryVec), nil
+}
+
+func (m *Metrics) registerDynamic(d MetricDefinition, opts []MetricOption) (prometheus.Collector, error) {
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	if c, err := m.existing(d); c != nil || err != nil {
+		return c, err
+
+	}
+
+	if err := m.Define(d); err != nil {
+		// another goroutine may have defined it in the meantime
+		if c, _ := m.existing(d); c != nil {
+			return c, nil
+
+		}
+		return nil, err
+
+	}
+
+	return m.existing(d)
+}
+
+// existing returns the metric defined as d, nil if there is none, an error if it was
+// defined differently.
+func (m *Metrics) existing(d MetricDefinition) (prometheus.Collector, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.definitions[d.Name]
+	if !ok {
+		return nil, nil
+
+	}
+	if existing.Type != d.Type || !sameLabels(existing.Labels, d.Labels) {
+		return nil, fmt.Errorf("metric %s already defined as %s with labels %v", d.Name, existing.Type, existing.Labels)
+
+	}
+
+	return m.defined[d.Name], nil
+}
+
+func sameLabels(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Undefine unregisters the defined metric name, later updates of it are reported as
+// misuse. An undefined name is ignored.
+func (m *Metrics) Undefine(name string) {
+
+	m.mu.Lock()
+	c, ok := m.defined[name]
+	delete(m.defined, name)
+	delete(m.definitions, name)
+	m.mu.Unlock()
+
+	if ok {
+		m.Unregister(c)
+	}
+}
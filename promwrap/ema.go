@@ -0,0 +1,145 @@
+/*****************************************************************************
+*
+*	File			: ema.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Exponential moving averages of noisy histograms, eg. the per record API
+*					: latency, so a threshold alert on a pushed snapshot doesn't fire on one
+*					: slow record. The pushgateway keeps only the last push, rate() and
+*					: avg_over_time() have next to nothing to work with,
+*
+*					:   ema:
+*					:     fs_api_duration_seconds: 0.1
+*
+*					:   fs_api_duration_seconds -> fs_api_duration_ema_seconds
+*
+*					: with the histogram's labels. Every observation v moves the average
+*					: by alpha, ema += alpha * (v - ema), the first one starts it. A small
+*					: alpha smooths more and follows a real change slower. Unlike run_stats
+*					: it carries over from one run of a batch to the next.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// emas is an unchecked collector like runStats, the label names differ per histogram.
+type emas struct {
+	alphas map[string]float64 // by histogram name
+
+	mu     sync.Mutex
+	names  map[prometheus.Collector]string // "" for histograms not smoothed
+	series map[string]*emaSeries           // name + label values
+}
+
+type emaSeries struct {
+	name   string
+	labels []*dto.LabelPair
+	value  float64
+}
+
+// TrackEMA exports an exponential moving average of the named histograms, name -> alpha.
+func (m *Metrics) TrackEMA(alphas map[string]float64) error {
+
+	e := &emas{
+		alphas: make(map[string]float64, len(alphas)),
+		names:  make(map[prometheus.Collector]string),
+		series: make(map[string]*emaSeries),
+	}
+	for name, alpha := range alphas {
+		if alpha <= 0 || alpha > 1 {
+			return fmt.Errorf("ema of %s, alpha %g not in (0, 1]", name, alpha)
+
+		}
+		e.alphas[name] = alpha
+	}
+
+	if err := m.reg.Register(e); err != nil {
+		return err
+
+	}
+
+	m.mu.Lock()
+	m.emas = e
+	m.mu.Unlock()
+
+	return nil
+}
+
+// observeEMA moves the average of h's series by v, observed on o, if h is smoothed.
+func (m *Metrics) observeEMA(h *prometheus.HistogramVec, o prometheus.Observer, v float64, lvs []string) {
+
+	m.mu.Lock()
+	e := m.emas
+	m.mu.Unlock()
+
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	name, ok := e.names[h]
+	if !ok {
+		name = describe(h)
+		if _, ok := e.alphas[name]; !ok {
+			name = ""
+		}
+		e.names[h] = name
+	}
+	if name == "" {
+		return
+	}
+
+	key := name + "\xff" + strings.Join(lvs, "\xff")
+	es := e.series[key]
+	if es == nil {
+		var pb dto.Metric
+		if mt, ok := o.(prometheus.Metric); !ok || mt.Write(&pb) != nil {
+			return
+
+		}
+		e.series[key] = &emaSeries{name: name, labels: pb.GetLabel(), value: v}
+		return
+
+	}
+
+	es.value += e.alphas[name] * (v - es.value)
+}
+
+func (e *emas) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (e *emas) Collect(ch chan<- prometheus.Metric) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, es := range e.series {
+		names := make([]string, len(es.labels))
+		values := make([]string, len(es.labels))
+		for i, lp := range es.labels {
+			names[i], values[i] = lp.GetName(), lp.GetValue()
+		}
+
+		desc := prometheus.NewDesc(strings.TrimSuffix(es.name, "_seconds")+"_ema_seconds",
+			fmt.Sprintf("Exponential moving average of %s, alpha %g.", es.name, e.alphas[es.name]), names, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, es.value, values...)
+	}
+}
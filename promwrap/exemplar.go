@@ -0,0 +1,117 @@
+/*****************************************************************************
+*
+*	File			: exemplar.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Exemplars, a trace or record id attached to an observation, so a Grafana
+*					: panel can jump from a slow bucket to the record behind it,
+*
+*					:   m.ObserveWithExemplar(m.api_duration, d, prometheus.Labels{"record_id": file}, "eft")
+*
+*					: Each bucket (counter) keeps its latest exemplar. Only OpenMetrics and
+*					: protobuf carry them, exposition.openmetrics for scrapes, pushes use
+*					: protobuf unless the legacy gateway protocol is on (compat.go).
+*
+*					: The values are redacted (redact.go) as label values are, a record id
+*					: is exactly what the redaction rules are there for.
+
+*					: client_golang panics on an invalid exemplar, we report it as metric
+*					: misuse and record the observation without it.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// ObserveWithExemplar is Observe, attaching exemplar to the observation.
+func (m *Metrics) ObserveWithExemplar(h *prometheus.HistogramVec, d time.Duration, exemplar prometheus.Labels, lvs ...string) error {
+
+	exemplar = redactExemplar(exemplar)
+	if err := validExemplar(exemplar); err != nil {
+		m.Misuse(fmt.Errorf("%s: %w", describe(h), err))
+		return m.observe(h, d, nil, lvs)
+
+	}
+
+	return m.observe(h, d, exemplar, lvs)
+}
+
+// AddWithExemplar is Add, attaching exemplar to the increment.
+func (m *Metrics) AddWithExemplar(c *prometheus.CounterVec, v float64, exemplar prometheus.Labels, lvs ...string) error {
+
+	exemplar = redactExemplar(exemplar)
+	if err := validExemplar(exemplar); err != nil {
+		m.Misuse(fmt.Errorf("%s: %w", describe(c), err))
+		return m.add(c, v, nil, lvs)
+
+	}
+
+	return m.add(c, v, exemplar, lvs)
+}
+
+// redactExemplar returns exemplar with its values redacted, exemplar itself if nothing changed.
+func redactExemplar(exemplar prometheus.Labels) prometheus.Labels {
+
+	var out prometheus.Labels
+	for name, value := range exemplar {
+		r := Redact(value)
+		if r == value {
+			continue
+
+		}
+
+		if out == nil {
+			out = make(prometheus.Labels, len(exemplar))
+			for k, v := range exemplar {
+				out[k] = v
+			}
+		}
+		out[name] = r
+	}
+
+	if out == nil {
+		return exemplar
+
+	}
+
+	return out
+}
+
+// validExemplar checks the label names and the 128 rune limit on names and values together.
+func validExemplar(exemplar prometheus.Labels) error {
+
+	runes := 0
+	for name, value := range exemplar {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid exemplar label name %q", name)
+
+		}
+		if !utf8.ValidString(value) {
+			return fmt.Errorf("exemplar label %s is not valid UTF-8", name)
+
+		}
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+
+	if runes > prometheus.ExemplarMaxRunes {
+		return fmt.Errorf("exemplar labels are %d runes, at most %d allowed", runes, prometheus.ExemplarMaxRunes)
+
+	}
+
+	return nil
+}
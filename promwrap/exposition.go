@@ -0,0 +1,194 @@
+/*****************************************************************************
+*
+*	File			: exposition.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Exposition format selection, text, OpenMetrics or protobuf, used when
+*					: serving /metrics (content negotiation) and when writing textfiles.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+type ExpositionConfig struct {
+	// text, openmetrics or protobuf. When serving /metrics an empty format means
+	// negotiate with the scraper, files default to text.
+	Format string `yaml:"format"`
+
+	// Offer OpenMetrics during content negotiation, the only format carrying exemplars.
+	OpenMetrics bool `yaml:"openmetrics"`
+
+	// Add _created lines to counters (and histograms/summaries) in OpenMetrics output, so
+	// a counter reset after a loader restart can be told apart from zero activity. Needs
+	// --enable-feature=created-timestamp-zero-ingestion on the Prometheus side.
+	CreatedTimestamps bool `yaml:"created_timestamps"`
+
+	// Write the registry to this file at the end of the run, eg. for the node_exporter
+	// textfile collector.
+	Textfile string `yaml:"textfile"`
+}
+
+func ExpositionFormat(name string) (expfmt.Format, error) {
+
+	switch name {
+	case "", "text":
+		return expfmt.NewFormat(expfmt.TypeTextPlain), nil
+
+	case "openmetrics":
+		return expfmt.NewFormat(expfmt.TypeOpenMetrics), nil
+
+	case "protobuf":
+		return expfmt.NewFormat(expfmt.TypeProtoDelim), nil
+
+	}
+
+	return "", fmt.Errorf("unknown exposition format %q, expected text, openmetrics or protobuf", name)
+}
+
+// negotiate picks the format for a scrape, a configured format always wins.
+func (c ExpositionConfig) negotiate(h http.Header) (expfmt.Format, error) {
+
+	if c.Format != "" {
+		return ExpositionFormat(c.Format)
+
+	}
+
+	if c.OpenMetrics {
+		return expfmt.NegotiateIncludingOpenMetrics(h), nil
+
+	}
+
+	return expfmt.Negotiate(h), nil
+}
+
+func (c ExpositionConfig) encoderOptions() []expfmt.EncoderOption {
+
+	if c.CreatedTimestamps {
+		return []expfmt.EncoderOption{expfmt.WithCreatedLines()}
+
+	}
+
+	return nil
+}
+
+// writeExposition encodes mfs, the options only apply to OpenMetrics, the other formats
+// either always carry the created timestamp (protobuf) or have no way to (text).
+func WriteExposition(w io.Writer, mfs []*dto.MetricFamily, format expfmt.Format, opts ...expfmt.EncoderOption) error {
+
+	enc := expfmt.NewEncoder(w, format, opts...)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+
+		}
+	}
+
+	// OpenMetrics needs the closing # EOF
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+
+	}
+
+	return nil
+}
+
+// metricsHandler serves g in the configured or negotiated format.
+func MetricsHandler(g prometheus.Gatherer, c ExpositionConfig) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		format, err := c.negotiate(r.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+
+		}
+
+		mfs, err := g.Gather()
+		if err != nil && len(mfs) == 0 {
+			http.Error(w, "error gathering metrics: "+err.Error(), http.StatusInternalServerError)
+			return
+
+		}
+
+		w.Header().Set("Content-Type", string(format))
+		if err := WriteExposition(w, mfs, format, c.encoderOptions()...); err != nil {
+			Log.Error("Could not write metrics", "err", err)
+
+		}
+	})
+}
+
+// WriteTextfile writes g to path in the configured format (text by default). The file
+// is written next to path and renamed into place so readers never see half a file.
+func WriteTextfile(path string, g prometheus.Gatherer, c ExpositionConfig) error {
+
+	if _, err := ExpositionFormat(c.Format); err != nil {
+		return err
+
+	}
+
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+
+	}
+
+	return writeFamiliesFile(path, mfs, c)
+}
+
+// writeFamiliesFile atomically replaces path with mfs, in the configured format.
+func writeFamiliesFile(path string, mfs []*dto.MetricFamily, c ExpositionConfig) error {
+
+	format, err := ExpositionFormat(c.Format)
+	if err != nil {
+		return err
+
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := WriteExposition(tmp, mfs, format, c.encoderOptions()...); err != nil {
+		tmp.Close()
+		return err
+
+	}
+
+	// CreateTemp gives us 0600, the textfile collector runs as another user
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+/*****************************************************************************
+*
+*	File			: final.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Collectors that only go out with a run's final push, eg. one-shot audit
+*					: gauges or the success timestamp, without registering them in the
+*					: application's registry, so intermediate pushes and scrapes never carry
+*					: them,
+*
+*					:   pusher.AttachFinal(m.successTime)
+*					:   pusher.AddContext(ctx)
+*
+*					: AttachFinal attaches them to the next push only, whichever way it goes
+*					: out (Add, the periodic push's Flush or the async queue), routed to the
+*					: jobs like any other family. Attaching a collector twice is fine, a
+*					: different one clashing with an attached one is an error, as is one
+*					: clashing with a registered metric at push time.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AttachFinal adds cs to the next push, and only to that one.
+func (r *PushRouter) AttachFinal(cs ...prometheus.Collector) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.final == nil {
+		r.final = prometheus.NewRegistry()
+	}
+
+	for _, c := range cs {
+		if err := r.final.Register(c); err != nil {
+			if are, ok := err.(prometheus.AlreadyRegisteredError); ok && are.ExistingCollector == c {
+				continue
+
+			}
+			return err
+
+		}
+	}
+
+	return nil
+}
+
+// takeFinal returns the collectors attached for this push, nil if there are none.
+func (r *PushRouter) takeFinal() prometheus.Gatherer {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	final := r.final
+	r.final = nil
+	if final == nil {
+		return nil
+	}
+
+	return final
+}
@@ -0,0 +1,147 @@
+/*****************************************************************************
+*
+*	File			: gateways.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: More than one gateway, eg. an HA pushgateway pair. url is the primary,
+*					: urls the secondaries, tried in order,
+*
+*					:   url: http://pushgw-a:9091
+*					:   urls: [http://pushgw-b:9091]
+*					:   fan_out: false
+*
+*					: By default a push goes to the primary and fails over to the next
+*					: gateway on error, every push starts at the primary again. With fan_out
+*					: every push goes to all of them. Either way a push only fails when no
+*					: gateway took it, the others' failures are reported and counted in
+*					: fs_etl_pushgateway_pushes_total{gateway,result}.
+*
+*					: Delete deletes from all of them, a failed over push may have landed
+*					: on any of them.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gateway is a job's pusher towards one of the gateways.
+type gateway struct {
+	name   string // host:port, the gateway label
+	pusher *push.Pusher
+}
+
+// GatewayURLs returns the primary followed by the secondaries.
+func (c PushgatewayConfig) GatewayURLs() []string {
+
+	return append([]string{c.GatewayURL()}, c.URLs...)
+}
+
+// gatewayName returns the host:port of u, without any credentials in it.
+func gatewayName(u string) string {
+
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return Redact(u)
+
+	}
+
+	return parsed.Host
+}
+
+// push sends mfs to the job's gateways, replacing all of the job's metrics if replace.
+func (r *PushRouter) push(ctx context.Context, jp *jobPusher, mfs []*dto.MetricFamily, replace bool) error {
+
+	jp.snapshot.mfs = mfs
+	start := time.Now()
+
+	var errs []error
+	for _, gw := range jp.gateways {
+		var err error
+		if replace {
+			err = gw.pusher.PushContext(ctx)
+
+		} else {
+			err = gw.pusher.AddContext(ctx)
+
+		}
+		r.countGateway(gw.name, err)
+
+		errs = append(errs, err)
+		if err == nil && !r.fanOut {
+			break
+
+		}
+	}
+
+	err := gatewayErr(jp, errs)
+	r.pushes.observe(jp.name, pushSize(mfs), start, err)
+
+	return err
+}
+
+// deleteGroup deletes the job's group from all of its gateways.
+func (r *PushRouter) deleteGroup(jp *jobPusher) error {
+
+	errs := make([]error, len(jp.gateways))
+	for i, gw := range jp.gateways {
+		errs[i] = gw.pusher.Delete()
+	}
+
+	return gatewayErr(jp, errs)
+}
+
+// gatewayErr is the error of a push or delete with errs per gateway tried, nil unless all
+// of them failed. The failures of the others are reported.
+func gatewayErr(jp *jobPusher, errs []error) error {
+
+	if len(jp.gateways) == 1 {
+		return errs[0]
+
+	}
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", jp.gateways[i].name, err))
+
+		}
+	}
+
+	switch {
+	case len(failed) == 0:
+		return nil
+
+	case len(failed) < len(errs):
+		reportFailure(fmt.Sprintf("Job %s failed on some gateways:", jp.name), fmt.Errorf("%s", strings.Join(failed, "; ")))
+		return nil
+
+	}
+
+	return fmt.Errorf("all gateways failed, %s", strings.Join(failed, "; "))
+}
+
+func (r *PushRouter) countGateway(name string, err error) {
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.gatewayPushes.WithLabelValues(name, result).Inc()
+}
@@ -0,0 +1,81 @@
+/*****************************************************************************
+*
+*	File			: grouping.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Grouping key labels added to every push, pushgateway.grouping. Workers
+*					: running in parallel under the same job name each push to a group of
+*					: their own, rather than overwriting each other's series,
+*
+*					:   grouping:
+*					:     region: eu
+*					:   auto_instance: true    # instance=<hostname>-<pid>
+*
+*					: Delete() then only deletes the worker's own group.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/prometheus/common/model"
+)
+
+const InstanceLabel = "instance"
+
+// Instance returns <hostname>-<pid>, the auto_instance grouping value.
+func Instance() string {
+
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// groupingKey returns the grouping labels as name, value pairs sorted by name.
+func (c PushgatewayConfig) groupingKey() ([][2]string, error) {
+
+	labels := make(map[string]string, len(c.Grouping)+1)
+	for name, value := range c.Grouping {
+		labels[name] = value
+	}
+	if c.AutoInstance {
+		if _, ok := labels[InstanceLabel]; ok {
+			return nil, fmt.Errorf("pushgateway grouping sets %s as well as auto_instance", InstanceLabel)
+
+		}
+		labels[InstanceLabel] = Instance()
+	}
+
+	var key [][2]string
+	for name, value := range labels {
+		switch {
+		case name == "job" || !model.LabelName(name).IsValid():
+			return nil, fmt.Errorf("pushgateway grouping label %q, expected a label name other than job", name)
+
+		case name == PhaseLabel && len(c.Phases) > 0:
+			return nil, fmt.Errorf("pushgateway grouping label %s clashes with the phases", name)
+
+		case !model.LabelValue(value).IsValid():
+			return nil, fmt.Errorf("pushgateway grouping label %s, invalid value %q", name, value)
+
+		}
+		key = append(key, [2]string{name, value})
+	}
+	sort.Slice(key, func(i, j int) bool { return key[i][0] < key[j][0] })
+
+	return key, nil
+}
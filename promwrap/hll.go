@@ -0,0 +1,103 @@
+/*****************************************************************************
+*
+*	File			: hll.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: HyperLogLog, approximate distinct counting in bounded memory, eg. the
+*					: unique accounts touched by a load. 2^precision one byte registers, the
+*					: default precision 14 takes 16KB and is good for about 0.8% standard
+*					: error however many ids are added. Small counts use linear counting,
+*					: which is close to exact.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+const DefaultHLLPrecision = 14
+
+type HyperLogLog struct {
+	p uint8
+
+	mu        sync.Mutex
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty counter with 2^precision registers, precision 4 to 18,
+// anything else gets DefaultHLLPrecision.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+
+	if precision < 4 || precision > 18 {
+		precision = DefaultHLLPrecision
+	}
+
+	return &HyperLogLog{p: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// Add counts id.
+func (h *HyperLogLog) Add(id string) {
+
+	f := fnv.New64a()
+	f.Write([]byte(id))
+	x := mix64(f.Sum64())
+
+	idx := x >> (64 - h.p)
+	rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+
+	h.mu.Lock()
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+	h.mu.Unlock()
+}
+
+// Estimate returns the approximate number of distinct ids added.
+func (h *HyperLogLog) Estimate() float64 {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros)) // linear counting
+
+	}
+
+	return estimate
+}
+
+// mix64 is the splitmix64 finalizer, FNV alone spreads similar ids poorly over the registers.
+func mix64(x uint64) uint64 {
+
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,253 @@
+/*****************************************************************************
+*
+*	File			: ingest.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The relay's ingestion API, for batch jobs in other languages, eg. Python
+*					: or Java, reporting through the same relay, merging and delivery as ours.
+*					: An IngestRequest, see ingest.proto, POSTed as application/x-protobuf to
+*					: /api/v1/ingest. It's a group like any push to the relay, a push through
+*					: the pushgateway API with the same job and grouping key lands in it too.
+*
+*					: Decoded with protowire rather than generated code, the schema is small
+*					: and stable, and a client's own fields beyond it are skipped. The
+*					: families are then checked as expfmt checks a push's, metric and label
+*					: names valid, values UTF-8, so a bad request is turned away rather than
+*					: failing every merged push to the gateway.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const ingestPath = "/api/v1/ingest"
+
+// ingestRequest is ingest.proto's IngestRequest.
+type ingestRequest struct {
+	job      string
+	grouping map[string]string
+	families []*dto.MetricFamily
+	replace  bool
+}
+
+func (r *Relay) ingest(w http.ResponseWriter, req *http.Request) {
+
+	status, err := r.ingestRequest(req)
+	result := "success"
+	if err != nil {
+		result = "failure"
+		http.Error(w, err.Error(), status)
+
+	}
+	r.pushes.WithLabelValues("ingest", result).Inc()
+}
+
+func (r *Relay) ingestRequest(req *http.Request) (int, error) {
+
+	if req.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, POST an IngestRequest", req.Method)
+
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, relayMaxBody))
+	if err != nil {
+		return http.StatusBadRequest, err
+
+	}
+	in, err := decodeIngest(body)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("IngestRequest: %w", err)
+
+	}
+	if in.job == "" {
+		return http.StatusBadRequest, fmt.Errorf("IngestRequest without a job")
+
+	}
+	if !model.LabelValue(in.job).IsValid() {
+		return http.StatusBadRequest, fmt.Errorf("IngestRequest job %q is not valid UTF-8", in.job)
+
+	}
+	for _, mf := range in.families {
+		if err := validFamily(mf); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("IngestRequest family %q: %w", mf.GetName(), err)
+
+		}
+	}
+
+	// the group's path as a push through the pushgateway API would have it
+	names := make([]string, 0, len(in.grouping))
+	for name := range in.grouping {
+		if !model.LabelName(name).IsValid() || name == "job" {
+			return http.StatusBadRequest, fmt.Errorf("grouping label name %q", name)
+
+		}
+		if !model.LabelValue(in.grouping[name]).IsValid() {
+			return http.StatusBadRequest, fmt.Errorf("grouping label %s value is not valid UTF-8", name)
+
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := "/metrics/job/" + url.QueryEscape(in.job)
+	for _, name := range names {
+		key += "/" + name + "/" + url.QueryEscape(in.grouping[name])
+	}
+	labels, err := relayGrouping(key)
+	if err != nil {
+		return http.StatusBadRequest, err
+
+	}
+
+	r.store(key, labels, in.families, in.replace)
+
+	return http.StatusOK, nil
+}
+
+// validFamily checks mf as expfmt's protobuf decoder checks the families of a push.
+func validFamily(mf *dto.MetricFamily) error {
+
+	if !model.IsValidMetricName(model.LabelValue(mf.GetName())) {
+		return fmt.Errorf("invalid metric name %q", mf.GetName())
+
+	}
+	for _, metric := range mf.GetMetric() {
+		for _, lp := range metric.GetLabel() {
+			if !model.LabelName(lp.GetName()).IsValid() {
+				return fmt.Errorf("invalid label name %q", lp.GetName())
+
+			}
+			if !model.LabelValue(lp.GetValue()).IsValid() {
+				return fmt.Errorf("invalid label value %q", lp.GetValue())
+
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeIngest decodes an IngestRequest, skipping unknown fields.
+func decodeIngest(b []byte) (ingestRequest, error) {
+
+	in := ingestRequest{grouping: make(map[string]string)}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return in, protowire.ParseError(n)
+
+		}
+		b = b[n:]
+
+		if typ == protowire.BytesType && num >= 1 && num <= 3 {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return in, protowire.ParseError(n)
+
+			}
+			b = b[n:]
+
+			switch num {
+			case 1:
+				in.job = string(v)
+
+			case 2:
+				key, value, err := decodeMapEntry(v)
+				if err != nil {
+					return in, fmt.Errorf("grouping: %w", err)
+
+				}
+				in.grouping[key] = value
+
+			case 3:
+				mf := &dto.MetricFamily{}
+				if err := proto.Unmarshal(v, mf); err != nil {
+					return in, fmt.Errorf("families: %w", err)
+
+				}
+				in.families = append(in.families, mf)
+
+			}
+			continue
+
+		}
+
+		if typ == protowire.VarintType && num == 4 {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return in, protowire.ParseError(n)
+
+			}
+			b = b[n:]
+			in.replace = v != 0
+			continue
+
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return in, protowire.ParseError(n)
+
+		}
+		b = b[n:]
+	}
+
+	return in, nil
+}
+
+// decodeMapEntry decodes a map<string, string> entry, key 1 and value 2.
+func decodeMapEntry(b []byte) (key, value string, err error) {
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+
+		}
+		b = b[n:]
+
+		if typ == protowire.BytesType && (num == 1 || num == 2) {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return "", "", protowire.ParseError(n)
+
+			}
+			b = b[n:]
+			if num == 1 {
+				key = string(v)
+			} else {
+				value = string(v)
+			}
+			continue
+
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+
+		}
+		b = b[n:]
+	}
+
+	return key, value, nil
+}
@@ -0,0 +1,180 @@
+/*****************************************************************************
+*
+*	File			: ingest_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Tests of the relay's ingestion API, decoding IngestRequests, well formed
+*					: and not, and turning away families a push would have been refused for.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// ingestBody encodes an IngestRequest, extra is appended as is, eg. unknown fields.
+func ingestBody(t *testing.T, job string, grouping map[string]string, families []*dto.MetricFamily, replace bool, extra []byte) []byte {
+
+	t.Helper()
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, job)
+	for k, v := range grouping {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, v)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	for _, mf := range families {
+		fb, err := proto.Marshal(mf)
+		if err != nil {
+			t.Fatal(err)
+
+		}
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, fb)
+	}
+	if replace {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+
+	return append(b, extra...)
+}
+
+func ingestCounter(name, label, value string, v float64) *dto.MetricFamily {
+
+	metric := &dto.Metric{Counter: &dto.Counter{Value: proto.Float64(v)}}
+	if label != "" {
+		metric.Label = []*dto.LabelPair{{Name: proto.String(label), Value: proto.String(value)}}
+	}
+
+	return &dto.MetricFamily{Name: proto.String(name), Type: dto.MetricType_COUNTER.Enum(), Metric: []*dto.Metric{metric}}
+}
+
+func TestDecodeIngest(t *testing.T) {
+
+	// a client's own fields, a varint 9 and a string 10, are skipped
+	var extra []byte
+	extra = protowire.AppendTag(extra, 9, protowire.VarintType)
+	extra = protowire.AppendVarint(extra, 42)
+	extra = protowire.AppendTag(extra, 10, protowire.BytesType)
+	extra = protowire.AppendString(extra, "ignored")
+
+	body := ingestBody(t, "loader", map[string]string{"instance": "host-1", "lang": "python"},
+		[]*dto.MetricFamily{ingestCounter("rows_total", "batch", "eft", 10)}, true, extra)
+
+	in, err := decodeIngest(body)
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	if in.job != "loader" || !in.replace || len(in.grouping) != 2 || in.grouping["lang"] != "python" || in.grouping["instance"] != "host-1" {
+		t.Errorf("decoded job %q, grouping %v, replace %t", in.job, in.grouping, in.replace)
+	}
+	if len(in.families) != 1 || in.families[0].GetName() != "rows_total" || in.families[0].GetMetric()[0].GetCounter().GetValue() != 10 {
+		t.Errorf("decoded families %v", in.families)
+	}
+}
+
+func TestDecodeIngestMalformed(t *testing.T) {
+
+	for name, body := range map[string][]byte{
+		"truncated tag":          {0x80},
+		"missing length":         {0x0a},
+		"short job":              {0x0a, 0x05, 'a'},
+		"short grouping entry":   {0x12, 0x02, 0x0a, 0x05},
+		"bad family":             {0x1a, 0x02, 0xff, 0xff},
+		"missing replace varint": {0x20},
+		"short unknown field":    {0x52, 0x03, 'x'},
+		"reserved wire type":     {0x0f},
+	} {
+		if _, err := decodeIngest(body); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+}
+
+func TestIngestValidates(t *testing.T) {
+
+	r, err := NewRelay(RelayConfig{Listen: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+
+	}
+
+	post := func(body []byte) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ingestPath, bytes.NewReader(body)))
+		return w.Code
+	}
+
+	for name, mf := range map[string]*dto.MetricFamily{
+		"metric name": ingestCounter("rows-total", "", "", 1),
+		"label name":  ingestCounter("rows_total", "bad-label", "x", 1),
+		"label value": ingestCounter("rows_total", "batch", "\xff", 1),
+	} {
+		if code := post(ingestBody(t, "loader", nil, []*dto.MetricFamily{mf}, false, nil)); code != http.StatusBadRequest {
+			t.Errorf("invalid %s: %d, want 400", name, code)
+		}
+	}
+	if code := post(ingestBody(t, "loader", map[string]string{"lang": "\xff"}, nil, false, nil)); code != http.StatusBadRequest {
+		t.Errorf("invalid grouping value: %d, want 400", code)
+	}
+	if code := post(ingestBody(t, "", nil, nil, false, nil)); code != http.StatusBadRequest {
+		t.Errorf("no job: %d, want 400", code)
+	}
+
+	r.mu.Lock()
+	groups := len(r.groups)
+	r.mu.Unlock()
+	if groups != 0 {
+		t.Fatalf("%d groups stored from invalid requests", groups)
+
+	}
+
+	if code := post(ingestBody(t, "loader", map[string]string{"instance": "host-1"}, []*dto.MetricFamily{ingestCounter("rows_total", "batch", "eft", 3)}, false, nil)); code != http.StatusOK {
+		t.Fatalf("valid request: %d, want 200", code)
+
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "rows_total" {
+			continue
+
+		}
+		if got := labelKey(mf.GetMetric()[0].GetLabel()); got != labelKey([]*dto.LabelPair{
+			{Name: proto.String("batch"), Value: proto.String("eft")},
+			{Name: proto.String(relayJobLabel), Value: proto.String("loader")},
+		}) {
+			t.Errorf("series labels %v, want batch and push_job", mf.GetMetric()[0].GetLabel())
+		}
+		return
+
+	}
+	t.Error("rows_total not relayed")
+}
@@ -0,0 +1,134 @@
+/*****************************************************************************
+*
+*	File			: logger.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Where the wrapper logs, push failures, retries, offline switches and
+*					: the like. A message plus key-value pairs, as in log/slog,
+*
+*					:   promwrap.Log.Warn("Push failed, retrying", "job", job, "err", err)
+*
+*					: By default a line on stdout, "msg key=value ...", from info up, see
+*					: NewStdoutLogger for another level. Point Log at the structured log
+*					: pipeline before New,
+*
+*					:   promwrap.Log = slog.Default()
+*					:   promwrap.Log = promwrap.ZerologLogger(zl)          // -tags zerolog
+*					:   promwrap.Log = promwrap.LogrusLogger(logrus.New()) // -tags logrus
+*
+*					: A *slog.Logger is a Logger as is. The zerolog and logrus adapters are
+*					: behind build tags so a build without them doesn't compile either in.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Logger takes the wrapper's log lines, msg followed by key-value pairs.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// Log is the wrapper's Logger, set it before New.
+var Log Logger = stdoutLogger{levelInfo}
+
+// Log levels of the stdout Logger, lowest first
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevels = map[string]int{"debug": levelDebug, "info": levelInfo, "warn": levelWarn, "error": levelError}
+
+// NewStdoutLogger returns the default Logger, logging level (debug, info, warn or error) and up.
+func NewStdoutLogger(level string) (Logger, error) {
+
+	l, ok := logLevels[level]
+	if !ok {
+		return nil, fmt.Errorf("log level %q, expected debug, info, warn or error", level)
+
+	}
+
+	return stdoutLogger{l}, nil
+}
+
+type stdoutLogger struct {
+	level int
+}
+
+func (s stdoutLogger) Debug(msg string, kv ...interface{}) {
+
+	s.log(levelDebug, msg, kv)
+}
+
+func (s stdoutLogger) Info(msg string, kv ...interface{}) {
+
+	s.log(levelInfo, msg, kv)
+}
+
+func (s stdoutLogger) Warn(msg string, kv ...interface{}) {
+
+	s.log(levelWarn, msg, kv)
+}
+
+func (s stdoutLogger) Error(msg string, kv ...interface{}) {
+
+	s.log(levelError, msg, kv)
+}
+
+func (s stdoutLogger) log(level int, msg string, kv []interface{}) {
+
+	if level >= s.level {
+		fmt.Println(logLine(msg, kv))
+	}
+}
+
+// logLine formats msg and kv as msg key=value ..., values with spaces quoted.
+func logLine(msg string, kv []interface{}) string {
+
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		key, value := fmt.Sprint(kv[i]), "(missing)"
+		if i+1 < len(kv) {
+			value = fmt.Sprint(kv[i+1])
+		}
+		if strings.ContainsAny(value, " \t\n\"") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+
+	return b.String()
+}
+
+// kvFields turns key-value pairs into a map, for the adapters taking fields that way.
+func kvFields(kv []interface{}) map[string]interface{} {
+
+	fields := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			fields[fmt.Sprint(kv[i])] = kv[i+1]
+		} else {
+			fields[fmt.Sprint(kv[i])] = "(missing)"
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,51 @@
+//go:build logrus
+
+/*****************************************************************************
+*
+*	File			: logger_logrus.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: logrus adapter, see logger.go, built with -tags logrus.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import "github.com/sirupsen/logrus"
+
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// LogrusLogger logs to l, a *logrus.Logger or *logrus.Entry, the key-value pairs as fields.
+func LogrusLogger(l logrus.FieldLogger) Logger {
+
+	return logrusLogger{l: l}
+}
+
+func (g logrusLogger) Debug(msg string, kv ...interface{}) {
+
+	g.l.WithFields(logrus.Fields(kvFields(kv))).Debug(msg)
+}
+
+func (g logrusLogger) Info(msg string, kv ...interface{}) {
+
+	g.l.WithFields(logrus.Fields(kvFields(kv))).Info(msg)
+}
+
+func (g logrusLogger) Warn(msg string, kv ...interface{}) {
+
+	g.l.WithFields(logrus.Fields(kvFields(kv))).Warn(msg)
+}
+
+func (g logrusLogger) Error(msg string, kv ...interface{}) {
+
+	g.l.WithFields(logrus.Fields(kvFields(kv))).Error(msg)
+}
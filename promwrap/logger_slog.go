@@ -0,0 +1,21 @@
+/*****************************************************************************
+*
+*	File			: logger_slog.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: A *slog.Logger is a Logger as is, see logger.go.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import "log/slog"
+
+var _ Logger = (*slog.Logger)(nil)
@@ -0,0 +1,51 @@
+//go:build zerolog
+
+/*****************************************************************************
+*
+*	File			: logger_zerolog.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: zerolog adapter, see logger.go, built with -tags zerolog.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import "github.com/rs/zerolog"
+
+type zerologLogger struct {
+	l zerolog.Logger
+}
+
+// ZerologLogger logs to l, the key-value pairs as fields.
+func ZerologLogger(l zerolog.Logger) Logger {
+
+	return zerologLogger{l: l}
+}
+
+func (z zerologLogger) Debug(msg string, kv ...interface{}) {
+
+	z.l.Debug().Fields(kvFields(kv)).Msg(msg)
+}
+
+func (z zerologLogger) Info(msg string, kv ...interface{}) {
+
+	z.l.Info().Fields(kvFields(kv)).Msg(msg)
+}
+
+func (z zerologLogger) Warn(msg string, kv ...interface{}) {
+
+	z.l.Warn().Fields(kvFields(kv)).Msg(msg)
+}
+
+func (z zerologLogger) Error(msg string, kv ...interface{}) {
+
+	z.l.Error().Fields(kvFields(kv)).Msg(msg)
+}
@@ -0,0 +1,104 @@
+/*****************************************************************************
+*
+*	File			: mirror.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Mirrors, other destinations every push is also sent to, at the same time
+*					: as the gateway, eg. remote_write while migrating off the Pushgateway.
+*					: A mirror sees every job's families as pushed, including the periodic
+*					: and async pushes, and whether or not the gateway took them.
+*
+*					: A failing mirror doesn't fail the push, it's reported and counted.
+*					: fs_etl_push_path_total{path,result} counts the outcome per destination,
+*					: path "pushgateway" or the mirror's name, so both sides of a migration
+*					: can be compared before the gateway is decommissioned.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const pathPushgateway = "pushgateway"
+
+// MirrorFunc sends one job's families to a mirror.
+type MirrorFunc func(job string, mfs []*dto.MetricFamily) error
+
+type mirror struct {
+	name string
+	send MirrorFunc
+}
+
+// Mirror sends every push to send as well, counted under path name.
+func (r *PushRouter) Mirror(name string, send MirrorFunc) error {
+
+	if name == pathPushgateway {
+		return fmt.Errorf("mirror name %s is taken", name)
+
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.mirrors {
+		if m.name == name {
+			return fmt.Errorf("mirror %s added twice", name)
+
+		}
+	}
+	r.mirrors = append(r.mirrors, mirror{name, send})
+
+	return nil
+}
+
+// mirror starts sending req to every mirror, the returned wait blocks until they're done.
+func (r *PushRouter) mirror(req pushRequest) (wait func()) {
+
+	r.mu.Lock()
+	mirrors := r.mirrors
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, m := range mirrors {
+		for i, jp := range r.jobs {
+			if req.errs[i] != nil {
+				continue
+
+			}
+
+			wg.Add(1)
+			go func(m mirror, job string, mfs []*dto.MetricFamily) {
+				defer wg.Done()
+
+				err := m.send(job, mfs)
+				r.countPath(m.name, err)
+				if err != nil {
+					reportFailure(fmt.Sprintf("Could not mirror job %s to %s:", job, m.name), err)
+				}
+			}(m, jp.job, req.mfs[i])
+		}
+	}
+
+	return wg.Wait
+}
+
+func (r *PushRouter) countPath(path string, err error) {
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.paths.WithLabelValues(path, result).Inc()
+}
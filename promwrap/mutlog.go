@@ -0,0 +1,148 @@
+/*****************************************************************************
+*
+*	File			: mutlog.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Debug mode mutation log, mutation_log. Every checked metric update,
+*					: Set/Add/Inc/Observe etc. (see strict.go), is recorded with its value and
+*					: caller in a ring buffer of the last mutation_log.size updates, to answer
+*					: "who set txn_count to 345 million?" during development.
+*
+*					: The daemon serves the buffer on GET /admin/mutations, oldest first,
+*					: ?metric=txn_count only shows the updates of that metric. Recording isn't
+*					: free, leave it off in production.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultMutationLogSize = 10000
+
+type MutationLogConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Size    int  `yaml:"size"` // updates kept, default 10000
+}
+
+type mutation struct {
+	At     time.Time
+	Op     string // set, add, inc, observe
+	Metric string
+	Labels []string
+	Value  float64
+	Caller string // file:line
+}
+
+func (mu mutation) String() string {
+
+	return fmt.Sprintf("%s %-7s %s{%s} %g %s", mu.At.Format(time.RFC3339Nano), mu.Op, mu.Metric, strings.Join(mu.Labels, ","), mu.Value, mu.Caller)
+}
+
+type MutationLog struct {
+	mu      sync.Mutex
+	entries []mutation
+	next    int
+	full    bool
+}
+
+// newMutationLog returns nil unless the mutation log is enabled.
+func NewMutationLog(c MutationLogConfig) *MutationLog {
+
+	if !c.Enabled {
+		return nil
+	}
+	if c.Size <= 0 {
+		c.Size = defaultMutationLogSize
+	}
+
+	return &MutationLog{entries: make([]mutation, c.Size)}
+}
+
+// record logs a successful update of c, called straight from the metrics methods so
+// the caller is 2 frames up. A no-op while the mutation log is off.
+func (m *Metrics) record(op string, c prometheus.Collector, v float64, lvs []string) {
+
+	m.recordAt(2, op, c, v, lvs)
+}
+
+// recordAt is record for the helpers behind the metrics methods, skip is the number of
+// frames up to the metrics method's caller.
+func (m *Metrics) recordAt(skip int, op string, c prometheus.Collector, v float64, lvs []string) {
+
+	if m.Mutations == nil {
+		return
+	}
+
+	mu := mutation{At: time.Now(), Op: op, Metric: describe(c), Labels: redactAll(lvs), Value: v}
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		mu.Caller = fmt.Sprintf("%s:%d", file, line)
+	}
+
+	m.Mutations.add(mu)
+}
+
+func (l *MutationLog) add(mu mutation) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = mu
+	l.next++
+	if l.next == len(l.entries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// Mutations returns the logged updates of metric, all of them for "", oldest first.
+func (l *MutationLog) Mutations(metric string) []mutation {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ordered := l.entries[:l.next]
+	if l.full {
+		ordered = append(append([]mutation{}, l.entries[l.next:]...), l.entries[:l.next]...)
+	}
+
+	var out []mutation
+	for _, mu := range ordered {
+		if metric == "" || mu.Metric == metric {
+			out = append(out, mu)
+
+		}
+	}
+
+	return out
+}
+
+func (l *MutationLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if l == nil {
+		http.Error(w, "mutation log disabled, see mutation_log.enabled", http.StatusNotFound)
+		return
+
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, mu := range l.Mutations(r.URL.Query().Get("metric")) {
+		fmt.Fprintln(w, mu)
+	}
+}
@@ -0,0 +1,96 @@
+/*****************************************************************************
+*
+*	File			: namespace.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metric name prefixes, namespace and subsystem, for applications other
+*					: than fs_loader reusing the wrapper. Metrics are named fs_... in code,
+*					: the namespace replaces the fs and the subsystem goes after it,
+*
+*					:   namespace: billing				fs_etl_duration_seconds -> billing_etl_duration_seconds
+*					:   subsystem: loader				fs_etl_duration_seconds -> fs_loader_etl_duration_seconds
+*
+*					: Names are rewritten as the families leave the process, on every push,
+*					: scrape, textfile and snapshot, so the config (suppress, pushgateway.jobs,
+*					: phases, derived, run_stats) keeps using the names as in code. Names
+*					: outside the fs namespace, eg. txn_count, are left alone.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultNamespace is the namespace of the metric names in code.
+const DefaultNamespace = "fs"
+
+var naming struct {
+	sync.RWMutex
+	prefix string // replaces "fs_", empty keeps it
+}
+
+// SetNamespace sets the namespace and subsystem, set from config before any gathering.
+// Empty ones keep the names as they are in code.
+func SetNamespace(namespace, subsystem string) error {
+
+	for what, v := range map[string]string{"namespace": namespace, "subsystem": subsystem} {
+		if v != "" && !model.IsValidLegacyMetricName(model.LabelValue(v)) {
+			return fmt.Errorf("%s %q, expected a valid metric name prefix", what, v)
+
+		}
+	}
+
+	naming.Lock()
+	defer naming.Unlock()
+
+	naming.prefix = ""
+	if namespace != "" || subsystem != "" {
+		if namespace == "" {
+			namespace = DefaultNamespace
+		}
+		naming.prefix = strings.TrimSuffix(namespace+"_"+subsystem, "_") + "_"
+	}
+
+	return nil
+}
+
+// Rename returns family name as it leaves the process, see SetNamespace.
+func Rename(name string) string {
+
+	naming.RLock()
+	defer naming.RUnlock()
+
+	if naming.prefix == "" || !strings.HasPrefix(name, DefaultNamespace+"_") {
+		return name
+
+	}
+
+	return naming.prefix + strings.TrimPrefix(name, DefaultNamespace+"_")
+}
+
+// renamed renames the families in mfs, in place.
+func renamed(mfs []*dto.MetricFamily) []*dto.MetricFamily {
+
+	for _, mf := range mfs {
+		if name := Rename(mf.GetName()); name != mf.GetName() {
+			mf.Name = proto.String(name)
+		}
+	}
+
+	return mfs
+}
@@ -0,0 +1,83 @@
+/*****************************************************************************
+*
+*	File			: native.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Native (sparse) histograms, native_histograms. The buckets follow the
+*					: observations, growing by at most bucket_factor each, so there are no
+*					: bucket boundaries to get wrong, eg. fs_api_duration_seconds.
+*
+*					: The classic buckets are kept alongside for servers that don't ingest
+*					: native histograms yet (Prometheus before 2.40, or without
+*					: --enable-feature=native-histograms), drop_classic leaves them out.
+*					: Only the protobuf format carries native histograms, the textfile, the
+*					: legacy gateway protocol (compat.go) and text scrapes only see the
+*					: classic buckets.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type NativeHistogramConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	BucketFactor float64       `yaml:"bucket_factor"` // growth of each bucket, default 1.1
+	MaxBuckets   uint32        `yaml:"max_buckets"`   // resolution is reduced beyond, default 160
+	ResetAfter   time.Duration `yaml:"reset_after"`   // with max_buckets hit, reset rather than reduce resolution, default 1h
+	DropClassic  bool          `yaml:"drop_classic"`  // native buckets only
+}
+
+func (c NativeHistogramConfig) validate() error {
+
+	if c.Enabled && c.BucketFactor != 0 && c.BucketFactor <= 1 {
+		return fmt.Errorf("native_histograms bucket_factor %g, expected above 1", c.BucketFactor)
+
+	}
+
+	return nil
+}
+
+// HistogramOpts returns opts with the buckets configured for it, if any (see calibrate.go),
+// as a native histogram when native_histograms is enabled.
+func (m *Metrics) HistogramOpts(opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+
+	if buckets, ok := m.Buckets[prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)]; ok {
+		opts.Buckets = buckets
+	}
+
+	c := m.Native
+	if !c.Enabled {
+		return opts
+	}
+
+	opts.NativeHistogramBucketFactor = c.BucketFactor
+	if opts.NativeHistogramBucketFactor == 0 {
+		opts.NativeHistogramBucketFactor = 1.1
+	}
+	opts.NativeHistogramMaxBucketNumber = c.MaxBuckets
+	if opts.NativeHistogramMaxBucketNumber == 0 {
+		opts.NativeHistogramMaxBucketNumber = 160
+	}
+	opts.NativeHistogramMinResetDuration = c.ResetAfter
+	if opts.NativeHistogramMinResetDuration == 0 {
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	if c.DropClassic {
+		opts.Buckets = nil
+	}
+
+	return opts
+}
@@ -0,0 +1,143 @@
+/*****************************************************************************
+*
+*	File			: offline.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Offline mode, for batch hosts on flaky networks. Once every push has
+*					: failed for pushgateway.offline_after, we stop trying the gateway and
+*					: write each push to pushgateway.offline_textfile instead, eg. for the
+*					: node_exporter textfile collector. Every offline_probe_interval a push
+*					: goes to the gateway again, the first one that makes it switches us
+*					: back online.
+*
+*					: fs_etl_push_offline is 1 while offline, switches are counted in
+*					: fs_etl_push_offline_switches_total{to}.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const defaultOfflineProbe = 30 * time.Second
+
+type offlineSink struct {
+	path  string
+	after time.Duration
+	probe time.Duration
+
+	offline  prometheus.Gauge
+	switches *prometheus.CounterVec
+
+	mu           sync.Mutex
+	failingSince time.Time // zero while pushes succeed
+	active       bool
+	lastProbe    time.Time
+}
+
+// newOfflineSink returns nil unless both offline_textfile and offline_after are set.
+func newOfflineSink(c PushgatewayConfig, reg prometheus.Registerer) (*offlineSink, error) {
+
+	if c.OfflineTextfile == "" || c.OfflineAfter <= 0 {
+		return nil, nil
+	}
+
+	o := &offlineSink{path: c.OfflineTextfile, after: c.OfflineAfter, probe: c.OfflineProbeInterval}
+	if o.probe <= 0 {
+		o.probe = defaultOfflineProbe
+	}
+
+	c2, err := RegisterOrExisting(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "fs_etl_push_offline",
+		Help: "1 while the Pushgateway is considered unreachable and pushes go to the offline textfile.",
+	}))
+	if err != nil {
+		return nil, err
+
+	}
+	o.offline = c2.(prometheus.Gauge)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_offline_switches_total",
+		Help: "The number of switches between pushing to the Pushgateway and the offline textfile.",
+	}, []string{"to"})); err != nil {
+		return nil, err
+
+	}
+	o.switches = c2.(*prometheus.CounterVec)
+
+	return o, nil
+}
+
+// skip reports whether this push should go straight to the textfile, ie. we're
+// offline and it's not time to probe the gateway yet.
+func (o *offlineSink) skip() bool {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.active || time.Since(o.lastProbe) >= o.probe {
+		o.lastProbe = time.Now()
+		return false
+
+	}
+
+	return true
+}
+
+// result records the outcome of a push to the gateway and reports whether we're offline.
+func (o *offlineSink) result(err error) bool {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch {
+	case err == nil:
+		o.failingSince = time.Time{}
+		if o.active {
+			o.active = false
+			o.offline.Set(0)
+			o.switches.WithLabelValues("online").Inc()
+			Log.Info("Pushgateway reachable again, back online")
+		}
+
+	case o.failingSince.IsZero():
+		o.failingSince = time.Now()
+
+	case !o.active && time.Since(o.failingSince) >= o.after:
+		o.active = true
+		o.lastProbe = time.Now()
+		o.offline.Set(1)
+		o.switches.WithLabelValues("offline").Inc()
+		Log.Warn("Pushgateway unreachable, going offline", "for", time.Since(o.failingSince).Round(time.Second), "textfile", o.path)
+
+	}
+
+	return o.active
+}
+
+// write writes the families of all jobs of req to the textfile.
+func (o *offlineSink) write(req pushRequest) error {
+
+	var mfs []*dto.MetricFamily
+	for _, jobMfs := range req.mfs {
+		mfs = append(mfs, jobMfs...)
+	}
+	sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+
+	return writeFamiliesFile(o.path, mfs, ExpositionConfig{})
+}
@@ -0,0 +1,136 @@
+/*****************************************************************************
+*
+*	File			: options.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Functional options for New, so each ETL job can set its own gateway
+*					: and job name in code,
+*
+*					:   w, err := promwrap.New(
+*					:       promwrap.WithPushgatewayURL("http://pushgateway:9091"),
+*					:       promwrap.WithJobName("fs_loader_eft"),
+*					:       promwrap.WithDefaultLabels(prometheus.Labels{"env": "prod"}),
+*					:   )
+*
+*					: Options apply in order, so WithConfig (the yaml) followed by the others
+*					: lets code override the config file.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Option func(*options)
+
+type options struct {
+	cfg      Config
+	registry *prometheus.Registry
+	labels   prometheus.Labels
+}
+
+// WithConfig replaces the whole Config, eg. as loaded from yaml.
+func WithConfig(c Config) Option {
+
+	return func(o *options) { o.cfg = c }
+}
+
+// WithPushgatewayURL sets the gateway pushed to, default http://127.0.0.1:9091.
+func WithPushgatewayURL(url string) Option {
+
+	return func(o *options) { o.cfg.Pushgateway.URL = url }
+}
+
+// WithSecondaryGateways adds gateways failed over to, or with fanOut pushed to as well, see gateways.go.
+func WithSecondaryGateways(fanOut bool, urls ...string) Option {
+
+	return func(o *options) {
+		o.cfg.Pushgateway.URLs = append(append([]string(nil), o.cfg.Pushgateway.URLs...), urls...)
+		o.cfg.Pushgateway.FanOut = fanOut
+	}
+}
+
+// WithJobName sets the default job pushed under, default pushgateway.
+func WithJobName(job string) Option {
+
+	return func(o *options) { o.cfg.Pushgateway.Job = job }
+}
+
+// WithBasicAuth pushes with HTTP basic auth.
+func WithBasicAuth(username, password string) Option {
+
+	return func(o *options) { o.cfg.Pushgateway.Username, o.cfg.Pushgateway.Password = username, password }
+}
+
+// WithGrouping adds a grouping key label to every push, see grouping.go.
+func WithGrouping(name, value string) Option {
+
+	return func(o *options) {
+		grouping := make(map[string]string, len(o.cfg.Pushgateway.Grouping)+1)
+		for k, v := range o.cfg.Pushgateway.Grouping {
+			grouping[k] = v
+		}
+		grouping[name] = value
+		o.cfg.Pushgateway.Grouping = grouping
+	}
+}
+
+// WithTLSConfig uses tc for HTTPS to the gateway, instead of pushgateway.tls.
+func WithTLSConfig(tc *tls.Config) Option {
+
+	return func(o *options) { o.cfg.Pushgateway.tlsConfig = tc }
+}
+
+// WithHTTPClient pushes through hc, eg. with a proxy, a tracing round tripper or its own
+// connection pool, rather than http.DefaultClient. TLS and WithTLSConfig don't apply,
+// configure them on hc's transport.
+func WithHTTPClient(hc *http.Client) Option {
+
+	return func(o *options) { o.cfg.Pushgateway.client = hc }
+}
+
+// WithListenAddress serves /metrics on addr, see Wrapper.Serve.
+func WithListenAddress(addr string) Option {
+
+	return func(o *options) { o.cfg.Pull.Listen = addr }
+}
+
+// WithNamespace prefixes the metric names with namespace and subsystem in place of
+// fs, see namespace.go.
+func WithNamespace(namespace, subsystem string) Option {
+
+	return func(o *options) { o.cfg.Namespace, o.cfg.Subsystem = namespace, subsystem }
+}
+
+// WithRegistry uses reg rather than a new registry, eg. to share it with other code.
+func WithRegistry(reg *prometheus.Registry) Option {
+
+	return func(o *options) { o.registry = reg }
+}
+
+// WithDefaultLabels adds labels to every metric registered through the wrapper,
+// including its own, on top of const_labels, see constlabels.go. Don't use job or
+// instance, the Pushgateway owns those.
+func WithDefaultLabels(labels prometheus.Labels) Option {
+
+	return func(o *options) {
+		if o.labels == nil {
+			o.labels = prometheus.Labels{}
+		}
+		for k, v := range labels {
+			o.labels[k] = v
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*****************************************************************************
+*
+*	File			: periodic.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Periodic pushing, pushgateway.interval. A background goroutine pushes
+*					: every interval, so the ETL loop only records metrics and never waits
+*					: on the network. The application leaves out its own intermediate
+*					: pushes when Periodic() says so, and calls Flush() to force a push,
+*					: eg. the final one of a batch, which also restarts the interval.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync"
+	"time"
+)
+
+type periodicPusher struct {
+	push     func() error
+	interval time.Duration
+
+	force chan chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPeriodicPusher(interval time.Duration, push func() error) *periodicPusher {
+
+	p := &periodicPusher{
+		push:     push,
+		interval: interval,
+		force:    make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+
+	return p
+}
+
+func (p *periodicPusher) run() {
+
+	defer close(p.done)
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			p.pushNow()
+
+		case ack := <-p.force:
+			p.pushNow()
+			t.Reset(p.interval)
+			close(ack)
+
+		case <-p.stop:
+			return
+
+		}
+	}
+}
+
+func (p *periodicPusher) pushNow() {
+
+	if err := p.push(); err != nil {
+		reportFailure("Could not push to Pushgateway:", err)
+	}
+}
+
+// flush pushes now and waits for it, a no-op once closed.
+func (p *periodicPusher) flush() {
+
+	ack := make(chan struct{})
+	select {
+	case p.force <- ack:
+		<-ack
+
+	case <-p.done:
+
+	}
+}
+
+// close stops the goroutine, without a last push, Flush for that.
+func (p *periodicPusher) close() {
+
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+}
+
+// Periodic reports whether a background goroutine pushes every pushgateway.interval.
+func (r *PushRouter) Periodic() bool {
+
+	return r.periodic != nil
+}
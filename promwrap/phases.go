@@ -0,0 +1,90 @@
+/*****************************************************************************
+*
+*	File			: phases.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Per phase grouping keys, pushgateway.phases. The families of a phase are
+*					: pushed under their job with grouping key phase=<phase>, a group of its
+*					: own on the gateway,
+*
+*					:   phases:
+*					:     sql: [fs_sql_duration_seconds, fs_sql_timeouts_total]
+*					:     api: [fs_api_duration_seconds]
+*
+*					: so the SQL, API and record series can be replaced (Push) or deleted
+*					: (DeletePhase) independently, eg. clearing the SQL series once the
+*					: SQL step was dropped from the job without touching the rest.
+*
+*					: A job pushes once per phase it has families in, the pusher shows up
+*					: as <job>/<phase> in the push_job label of the router's metrics.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/common/model"
+)
+
+const PhaseLabel = "phase"
+
+// phaseFamilies returns the phase of every family in a phase, a family may only be in one.
+func phaseFamilies(phases map[string][]string) (map[string]string, error) {
+
+	phaseOf := make(map[string]string)
+	for phase, families := range phases {
+		if !model.LabelValue(phase).IsValid() || phase == "" {
+			return nil, fmt.Errorf("pushgateway phase %q, expected a non empty label value", phase)
+
+		}
+
+		for _, name := range families {
+			if other, ok := phaseOf[name]; ok {
+				return nil, fmt.Errorf("metric family %s in both phase %s and %s", name, other, phase)
+
+			}
+			phaseOf[name] = phase
+		}
+	}
+
+	return phaseOf, nil
+}
+
+// jobPhases returns the phases with families pushed under job, sorted.
+func jobPhases(phaseOf map[string]string, jobOf func(name string) string, job string) []string {
+
+	seen := make(map[string]bool)
+	var phases []string
+	for name, phase := range phaseOf {
+		if jobOf(name) == job && !seen[phase] {
+			seen[phase] = true
+			phases = append(phases, phase)
+		}
+	}
+	sort.Strings(phases)
+
+	return phases
+}
+
+// DeletePhase deletes the phase's group of every job from the gateway. The next push
+// of the phase creates it again.
+func (r *PushRouter) DeletePhase(phase string) error {
+
+	n, err := r.delete(func(jp *jobPusher) bool { return phase != "" && jp.phase == phase })
+	if n == 0 && !r.disabled {
+		return fmt.Errorf("no pushes under phase %q", phase)
+
+	}
+
+	return err
+}
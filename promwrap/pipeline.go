@@ -0,0 +1,207 @@
+/*****************************************************************************
+*
+*	File			: pipeline.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metrics for pipelines built as goroutine stages connected by channels,
+*					: eg. read -> transform -> write, so the bottleneck stage stands out,
+*
+*					:   p, _ := w.Metrics.Pipeline("eft")
+*					:   read, transform := p.Stage("read"), p.Stage("transform")
+*					:   raw := make(chan Record, 100)
+*					:   promwrap.WatchQueue(p, "read_transform", raw)
+*
+*					:   // read stage                       // transform stage
+*					:   done := read.Begin()                rec, ok := promwrap.Recv(ctx, transform, raw)
+*					:   ...                                 done := transform.Begin()
+*					:   done()                              ...
+*					:   promwrap.Send(ctx, read, raw, rec)  done()
+*
+*					: exporting
+*
+*					:   fs_etl_pipeline_stage_in_flight{pipeline,stage}	records being worked on
+*					:   fs_etl_pipeline_queue_depth{pipeline,queue}	channel length, at gather
+*					:   fs_etl_pipeline_queue_capacity{pipeline,queue}
+*					:   fs_etl_pipeline_stage_stalls_total{pipeline,stage,reason}
+*					:   fs_etl_pipeline_stage_stall_seconds_total{pipeline,stage,reason}
+*
+*					: A stall is a Send that found its output queue full (reason blocked) or
+*					: a Recv that found its input queue empty (reason starved). The bottleneck
+*					: is the stage whose upstream is blocked and whose downstream is starved.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Stall reasons, the reason label of fs_etl_pipeline_stage_stalls_total
+const (
+	StallBlocked = "blocked" // output queue full, downstream is slower
+	StallStarved = "starved" // input queue empty, upstream is slower
+)
+
+type Pipeline struct {
+	name string
+	reg  prometheus.Registerer
+
+	inFlight     *prometheus.GaugeVec
+	stalls       *prometheus.CounterVec
+	stallSeconds *prometheus.CounterVec
+}
+
+// Stage is one stage of a pipeline.
+type Stage struct {
+	name     string
+	inFlight prometheus.Gauge
+	stalls   map[string]prometheus.Counter // by reason
+	seconds  map[string]prometheus.Counter
+}
+
+// Pipeline returns the metrics of pipeline name, the stages and queues added to it.
+func (m *Metrics) Pipeline(name string) (*Pipeline, error) {
+
+	p := &Pipeline{name: name, reg: m.reg}
+
+	c, err := RegisterOrExisting(m.reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fs_etl_pipeline_stage_in_flight",
+		Help: "Records being worked on by each pipeline stage.",
+	}, []string{"pipeline", "stage"}))
+	if err != nil {
+		return nil, err
+
+	}
+	p.inFlight = c.(*prometheus.GaugeVec)
+
+	if c, err = RegisterOrExisting(m.reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_pipeline_stage_stalls_total",
+		Help: "The number of times a pipeline stage found its output queue full (blocked) or its input queue empty (starved).",
+	}, []string{"pipeline", "stage", "reason"})); err != nil {
+		return nil, err
+
+	}
+	p.stalls = c.(*prometheus.CounterVec)
+
+	if c, err = RegisterOrExisting(m.reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_pipeline_stage_stall_seconds_total",
+		Help: "Time pipeline stages spent blocked on their output or starved on their input, in seconds.",
+	}, []string{"pipeline", "stage", "reason"})); err != nil {
+		return nil, err
+
+	}
+	p.stallSeconds = c.(*prometheus.CounterVec)
+
+	return p, nil
+}
+
+// Stage returns stage name of the pipeline.
+func (p *Pipeline) Stage(name string) *Stage {
+
+	s := &Stage{
+		name:     name,
+		inFlight: p.inFlight.WithLabelValues(p.name, name),
+		stalls:   make(map[string]prometheus.Counter),
+		seconds:  make(map[string]prometheus.Counter),
+	}
+	for _, reason := range []string{StallBlocked, StallStarved} {
+		s.stalls[reason] = p.stalls.WithLabelValues(p.name, name, reason)
+		s.seconds[reason] = p.stallSeconds.WithLabelValues(p.name, name, reason)
+	}
+
+	return s
+}
+
+// Begin marks a record in flight in the stage, until the returned done is called.
+func (s *Stage) Begin() (done func()) {
+
+	s.inFlight.Inc()
+
+	return s.inFlight.Dec
+}
+
+func (s *Stage) stalled(reason string, since time.Time) {
+
+	s.stalls[reason].Inc()
+	s.seconds[reason].Add(time.Since(since).Seconds())
+}
+
+// WatchQueue exports the depth and capacity of ch, the queue between two stages.
+func WatchQueue[T any](p *Pipeline, name string, ch chan T) error {
+
+	labels := prometheus.Labels{"pipeline": p.name, "queue": name}
+
+	if err := p.reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "fs_etl_pipeline_queue_depth",
+		Help:        "Records waiting in the queue between two pipeline stages.",
+		ConstLabels: labels,
+	}, func() float64 { return float64(len(ch)) })); err != nil {
+		return err
+
+	}
+
+	return p.reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "fs_etl_pipeline_queue_capacity",
+		Help:        "Capacity of the queue between two pipeline stages.",
+		ConstLabels: labels,
+	}, func() float64 { return float64(cap(ch)) }))
+}
+
+// Send sends v on ch for stage s, counting a stall if ch is full. It gives up when
+// ctx is done.
+func Send[T any](ctx context.Context, s *Stage, ch chan<- T, v T) error {
+
+	select {
+	case ch <- v:
+		return nil
+
+	default:
+
+	}
+
+	defer s.stalled(StallBlocked, time.Now())
+
+	select {
+	case ch <- v:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+
+	}
+}
+
+// Recv receives from ch for stage s, counting a stall if ch is empty. ok is false
+// once ch is closed or ctx is done.
+func Recv[T any](ctx context.Context, s *Stage, ch <-chan T) (v T, ok bool) {
+
+	select {
+	case v, ok = <-ch:
+		return v, ok
+
+	default:
+
+	}
+
+	defer s.stalled(StallStarved, time.Now())
+
+	select {
+	case v, ok = <-ch:
+		return v, ok
+
+	case <-ctx.Done():
+		return v, false
+
+	}
+}
@@ -0,0 +1,164 @@
+/*****************************************************************************
+*
+*	File			: preflight.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Optional startup probe of the Pushgateway, /-/ready for reachability and
+*					: /api/v1/status for the gateway version. Rather find out the gateway is
+*					: down at startup than on the first push halfway through a batch.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Preflight modes, pushgateway.preflight
+const (
+	PreflightOff  = ""
+	PreflightWarn = "warn" // log the problem and carry on
+	PreflightFail = "fail" // refuse to start
+)
+
+const defaultPreflightTimeout = 5 * time.Second
+
+// gatewayStatus is the part of the /api/v1/status response we care about.
+type gatewayStatus struct {
+	Status string `json:"status"`
+	Data   struct {
+		BuildInformation map[string]string `json:"build_information"`
+	} `json:"data"`
+}
+
+// Preflight checks the gateway at url is ready and returns its version. Gateways
+// older than 1.0 have no status API, they're reported as version "unknown".
+func Preflight(ctx context.Context, url string, timeout time.Duration) (string, error) {
+
+	return preflight(ctx, http.DefaultClient, url, timeout, "", "")
+}
+
+// preflight is Preflight through client, with basic auth unless username is empty.
+func preflight(ctx context.Context, client push.HTTPDoer, url string, timeout time.Duration, username, password string) (string, error) {
+
+	if timeout <= 0 {
+		timeout = defaultPreflightTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url = strings.TrimSuffix(url, "/")
+
+	if _, err := preflightGet(ctx, client, url+"/-/ready", username, password); err != nil {
+		return "", fmt.Errorf("pushgateway not ready: %w", err)
+
+	}
+
+	body, err := preflightGet(ctx, client, url+"/api/v1/status", username, password)
+	if err != nil {
+		return "unknown", nil
+
+	}
+
+	var status gatewayStatus
+	if err := json.Unmarshal(body, &status); err != nil || status.Data.BuildInformation["version"] == "" {
+		return "unknown", nil
+
+	}
+
+	return status.Data.BuildInformation["version"], nil
+}
+
+func preflightGet(ctx context.Context, client push.HTTPDoer, url, username, password string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+
+	}
+
+	return body, nil
+}
+
+// runPreflight probes the gateway as per c.Preflight, the error is only returned in fail mode.
+func RunPreflight(c PushgatewayConfig) error {
+
+	if c.Preflight == PreflightOff || c.Disabled {
+		return nil
+	}
+
+	client, err := c.httpClient()
+	if err != nil {
+		return err
+
+	}
+
+	// with more than one gateway, failover needs one of them, fan_out all of them
+	urls := c.GatewayURLs()
+	var failed []string
+	for _, u := range urls {
+		version, err := preflight(context.Background(), client, u, c.PreflightTimeout, c.Username, c.Password)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+
+		}
+		Log.Info("Pushgateway ready", "url", u, "version", version)
+	}
+
+	switch {
+	case len(failed) == 0:
+		return nil
+
+	case !c.FanOut && len(failed) < len(urls):
+		Log.Warn("Pushgateway preflight failed on some gateways, continuing", "err", strings.Join(failed, "; "))
+		return nil
+
+	}
+
+	err = fmt.Errorf("%s", strings.Join(failed, "; "))
+	if c.Preflight == PreflightFail {
+		return err
+
+	}
+	Log.Warn("Pushgateway preflight failed, continuing", "err", err)
+
+	return nil
+}
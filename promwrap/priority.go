@@ -0,0 +1,159 @@
+/*****************************************************************************
+*
+*	File			: priority.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metric family priority tiers, critical, normal (default) and debug, for
+*					: degraded mode pushing. Only the critical families, completion/success
+*					: timestamps, job state and error counts, are guaranteed delivery:
+*
+*					: - a push over pushgateway.max_push_bytes first loses its debug families,
+*					:   then its normal ones
+*					: - a failed push is retried once with only the critical families
+*
+*					: The retry is always an Add, so the families left out keep their previous
+*					: values on the gateway rather than disappearing. A degraded push sets
+*					: fs_etl_push_degraded{push_job} to 1, carried in the push itself, and
+*					: counts fs_etl_push_degraded_total{push_job,reason}.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Priority tiers, the pushgateway.priorities keys
+const (
+	tierCritical = "critical"
+	tierNormal   = "normal"
+	tierDebug    = "debug"
+)
+
+// Degraded push reasons, the "reason" label
+const (
+	degradedSize        = "size"
+	degradedPushFailure = "push_failure"
+)
+
+const degradedFamily = "fs_etl_push_degraded"
+
+// Critical whatever the config says.
+var defaultCritical = []string{
+	degradedFamily,
+	"fs_etl_batch_complete_timestamp_seconds",
+	"fs_etl_batch_success_timestamp_seconds",
+	"fs_etl_complete_timestamp_seconds",
+	"fs_etl_job_state",
+	"fs_etl_hook_failures_total",
+	"fs_etl_index_op_failures_total",
+	"fs_sql_timeouts_total",
+	"fs_etl_pg_deadlocks_total",
+}
+
+// priorities maps family names to their tier, families not in it are normal.
+type priorities map[string]string
+
+func newPriorities(tiers map[string][]string) (priorities, error) {
+
+	p := make(priorities)
+	for tier, families := range tiers {
+		switch tier {
+		case tierCritical, tierNormal, tierDebug:
+
+		default:
+			return nil, fmt.Errorf("pushgateway priorities: unknown tier %q, expected critical, normal or debug", tier)
+
+		}
+
+		for _, name := range families {
+			p[name] = tier
+		}
+	}
+
+	for _, name := range defaultCritical {
+		p[name] = tierCritical
+	}
+
+	return p, nil
+}
+
+func (p priorities) tier(name string) string {
+
+	if t, ok := p[name]; ok {
+		return t
+
+	}
+
+	return tierNormal
+}
+
+// only returns the families of mfs in one of the given tiers.
+func (p priorities) only(mfs []*dto.MetricFamily, tiers ...string) []*dto.MetricFamily {
+
+	var out []*dto.MetricFamily
+	for _, mf := range mfs {
+		t := p.tier(mf.GetName())
+		for _, keep := range tiers {
+			if t == keep {
+				out = append(out, mf)
+				break
+
+			}
+		}
+	}
+
+	return out
+}
+
+// pushSize is the size of mfs as pushed, length delimited protobuf.
+func pushSize(mfs []*dto.MetricFamily) int {
+
+	n := 0
+	for _, mf := range mfs {
+		size := proto.Size(mf)
+		n += protowire.SizeVarint(uint64(size)) + size
+	}
+
+	return n
+}
+
+// withDegraded replaces the fs_etl_push_degraded family in mfs with one holding
+// just job's state, so every job's push carries its own.
+func withDegraded(mfs []*dto.MetricFamily, job string, degraded bool) []*dto.MetricFamily {
+
+	v := 0.0
+	if degraded {
+		v = 1
+	}
+
+	out := make([]*dto.MetricFamily, 0, len(mfs)+1)
+	for _, mf := range mfs {
+		if mf.GetName() != degradedFamily {
+			out = append(out, mf)
+
+		}
+	}
+
+	return append(out, &dto.MetricFamily{
+		Name: proto.String(degradedFamily),
+		Help: proto.String("1 when the last push of the job only carried its higher priority metric families."),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Label: []*dto.LabelPair{{Name: proto.String("push_job"), Value: proto.String(job)}},
+			Gauge: &dto.Gauge{Value: proto.Float64(v)},
+		}},
+	})
+}
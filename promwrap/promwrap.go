@@ -0,0 +1,317 @@
+/*****************************************************************************
+*
+*	File			: promwrap.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The reusable part of the wrapper, importable as myapp/promwrap. An
+*					: application defines its own metrics, registers them through Metrics
+*					: (checked updates, label sanitizing, sealing, see strict.go) and pushes
+*					: the registry through the PushRouter (see router.go). New wires the
+*					: three up, see options.go for its options.
+*
+*					: The fs_etl example in the main package embeds *Metrics in its own
+*					: metrics struct, so its updates read m.Observe(m.sql_duration, ...).
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+// Package promwrap wraps client_golang for batch jobs pushing to a Pushgateway.
+package promwrap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config is the wrapper part of the application config, inlined at the top level
+// of the application's yaml.
+type Config struct {
+	Strict         bool `yaml:"strict"`           // dev mode, panic on metric misuse
+	RawLabelValues bool `yaml:"raw_label_values"` // don't sanitize label values
+
+	// Dev builds only (-tags dev), families that get a caller="file:line" label, see callers.go
+	CallerLabels []string `yaml:"caller_labels"`
+
+	// Hold off gathering while a Transaction() is in flight, see consistency.go
+	ConsistentGather bool `yaml:"consistent_gather"`
+
+	// regex -> replacement for label values and logged failures, see redact.go
+	Redact []RedactionRule `yaml:"redact"`
+
+	// Families kept out of pushes and scrapes, see suppress.go
+	Suppress SuppressConfig `yaml:"suppress"`
+
+	// yaml file declaring additional metrics, see definitions.go
+	MetricDefinitions string `yaml:"metric_definitions"`
+
+	// Gauges computed at gather time from other metrics, see derived.go
+	Derived []DerivedMetric `yaml:"derived"`
+
+	// Histograms that get min/avg/max gauges per run, see runstats.go
+	RunStats []string `yaml:"run_stats"`
+
+	// Histograms that get an exponential moving average gauge, name -> alpha, see ema.go
+	EMA map[string]float64 `yaml:"ema"`
+
+	// Labels on every metric, eg. env, region and app, see constlabels.go
+	ConstLabels map[string]string `yaml:"const_labels"`
+
+	// Prefix of the metric names in place of fs, see namespace.go
+	Namespace string `yaml:"namespace"`
+	Subsystem string `yaml:"subsystem"`
+
+	// Duration histograms as native (sparse) histograms, see native.go
+	NativeHistograms NativeHistogramConfig `yaml:"native_histograms"`
+
+	// Histogram buckets by family name, overriding the ones in code, and the trial
+	// runs recommending them, see calibrate.go
+	Buckets     map[string][]float64 `yaml:"buckets"`
+	Calibration CalibrationConfig    `yaml:"calibration"`
+
+	MutationLog MutationLogConfig `yaml:"mutation_log"`
+	Pushgateway PushgatewayConfig `yaml:"pushgateway"`
+
+	// /metrics served while the job runs and how it combines with pushing, see pull.go
+	Mode string     `yaml:"mode"` // push, pull, dual or relay
+	Pull PullConfig `yaml:"pull"`
+
+	// Taking the pushes of short lived loaders and pushing them merged, see relay.go
+	Relay RelayConfig `yaml:"relay"`
+}
+
+// ReportFailure reports failures the wrapper can't return, eg. of async pushes. The
+// application can replace it, eg. to suppress them during maintenance windows.
+// Either way the error has been through the redaction rules.
+var ReportFailure = func(msg string, err error) {
+
+	Log.Error(strings.TrimSuffix(msg, ":"), "err", err)
+}
+
+func reportFailure(msg string, err error) {
+
+	ReportFailure(msg, RedactErr(err))
+}
+
+// Metrics checks and records the updates of the application's metrics.
+type Metrics struct {
+	Strict    bool                  // panic on metric misuse, see strict.go
+	RawLabels bool                  // skip label value sanitization, see sanitize.go
+	Mutations *MutationLog          // nil unless the mutation log is enabled, see mutlog.go
+	Native    NativeHistogramConfig // see HistogramOpts
+	Buckets   map[string][]float64  // by family name, see HistogramOpts
+
+	reg prometheus.Registerer
+
+	late_observations *prometheus.CounterVec
+	label_sanitized   *prometheus.GaugeVec
+
+	mu          sync.Mutex
+	registered  map[prometheus.Collector]prometheus.Registerer // wrapped for caller labels, see callers.go
+	sanitized   map[string]bool
+	sealed      map[string]bool // batches sealed against late updates, see seal.go
+	batchIdx    map[prometheus.Collector]int
+	defined     map[string]prometheus.Collector // metric_definitions, see definitions.go
+	definitions map[string]MetricDefinition     // what they were defined as
+	runStats    *runStats                       // nil unless run_stats is set, see runstats.go
+	emas        *emas                           // nil unless ema is set, see ema.go
+	calibration *calibrator                     // nil unless calibrating, see calibrate.go
+}
+
+// NewMetrics returns the Metrics registering with reg, along with the wrapper's own metrics.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+
+	m := &Metrics{
+		reg:        reg,
+		registered: make(map[prometheus.Collector]prometheus.Registerer),
+
+		late_observations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_late_observations_total",
+			Help: "The number of metric updates rejected because their FS ETL batch was already sealed.",
+		}, []string{BatchLabel}),
+
+		label_sanitized: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fs_etl_label_sanitized_info",
+			Help: "Maps sanitized label values back to the original value they were derived from.",
+		}, []string{"sanitized", "original"}),
+	}
+
+	m.Register(m.late_observations, m.label_sanitized)
+
+	return m
+}
+
+// Wrapper is a registry, its Metrics and the PushRouter pushing it.
+type Wrapper struct {
+	*Metrics
+
+	Registry *prometheus.Registry
+	Pusher   *PushRouter
+	Relay    *Relay // nil unless mode relay, what the Pusher pushes then
+
+	mode  string
+	pull  PullConfig
+	relay RelayConfig
+}
+
+// processWide is the part of Config that applies to the whole process rather than a
+// Wrapper: redaction, caller labels, suppression, naming and consistent gathering.
+type processWide struct {
+	redact     []RedactionRule
+	callers    []string
+	suppress   SuppressConfig
+	namespace  string
+	subsystem  string
+	consistent bool
+}
+
+var (
+	processMu  sync.Mutex
+	processCfg *processWide // as set by the first New, nil before
+)
+
+// applyProcessWide installs c's process wide settings. A later New has to come with
+// the same ones, it would otherwise silently reconfigure the Wrappers made before it.
+func applyProcessWide(c Config) error {
+
+	p := processWide{c.Redact, c.CallerLabels, c.Suppress, c.Namespace, c.Subsystem, c.ConsistentGather}
+
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	if processCfg != nil {
+		if !reflect.DeepEqual(*processCfg, p) {
+			return errors.New("promwrap: New called again with different redact, caller_labels, suppress, namespace, subsystem or consistent_gather, these are process wide")
+
+		}
+		return nil
+
+	}
+
+	if err := SetRedactions(c.Redact); err != nil {
+		return err
+
+	}
+	ApplyCallerLabels(c.CallerLabels)
+	SetSuppressed(c.Suppress)
+	if err := SetNamespace(c.Namespace, c.Subsystem); err != nil {
+		return err
+
+	}
+	ConsistentGather = c.ConsistentGather
+	processCfg = &p
+
+	return nil
+}
+
+// New sets up the wrapper as per opts, by default on a new registry. The process wide
+// settings (see applyProcessWide) of every New in a process must match.
+func New(opts ...Option) (*Wrapper, error) {
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := o.cfg
+
+	if err := c.ApplyMode(); err != nil {
+		return nil, err
+
+	}
+	if err := applyProcessWide(c); err != nil {
+		return nil, err
+
+	}
+
+	w := &Wrapper{Registry: o.registry, mode: c.Mode, pull: c.Pull, relay: c.Relay}
+	if w.Registry == nil {
+		w.Registry = prometheus.NewRegistry()
+	}
+
+	labels, err := constLabels(c.ConstLabels, o.labels)
+	if err != nil {
+		return nil, err
+
+	}
+	var reg prometheus.Registerer = w.Registry
+	if len(labels) > 0 {
+		reg = prometheus.WrapRegistererWith(labels, w.Registry)
+	}
+
+	w.Metrics = NewMetrics(reg)
+	w.Strict = c.Strict
+	w.RawLabels = c.RawLabelValues
+	w.Mutations = NewMutationLog(c.MutationLog)
+
+	if err := c.NativeHistograms.validate(); err != nil {
+		return nil, err
+
+	}
+	w.Native = c.NativeHistograms
+
+	if err := validateBuckets(c.Buckets); err != nil {
+		return nil, err
+
+	}
+	w.Buckets = c.Buckets
+	if w.calibration, err = newCalibrator(c.Calibration); err != nil {
+		return nil, err
+
+	}
+
+	if c.MetricDefinitions != "" {
+		defs, err := LoadDefinitions(c.MetricDefinitions)
+		if err != nil {
+			return nil, err
+
+		}
+		if err := w.Define(defs...); err != nil {
+			return nil, fmt.Errorf("metric definitions %s: %w", c.MetricDefinitions, err)
+
+		}
+	}
+
+	if len(c.RunStats) > 0 {
+		w.TrackRunStats(c.RunStats...)
+	}
+
+	if len(c.EMA) > 0 {
+		if err := w.TrackEMA(c.EMA); err != nil {
+			return nil, err
+
+		}
+	}
+
+	if len(c.Derived) > 0 {
+		if err := w.Derive(c.Derived...); err != nil {
+			return nil, err
+
+		}
+	}
+
+	var g prometheus.Gatherer = w.Registry
+	if c.Mode == ModeRelay {
+		if w.Relay, err = NewRelay(c.Relay); err != nil {
+			return nil, err
+
+		}
+		g = w.Relay
+	}
+
+	if w.Pusher, err = newPushRouter(c.Pushgateway, reg, g); err != nil {
+		return nil, err
+
+	}
+
+	return w, nil
+}
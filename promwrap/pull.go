@@ -0,0 +1,168 @@
+/*****************************************************************************
+*
+*	File			: pull.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Pull mode, the registry served on /metrics while the job runs, so a
+*					: long running job can be scraped directly, instead of pushing (with
+*					: pushgateway.disabled) or as well as pushing. Served through
+*					: MetricsHandler rather than promhttp.Handler, so the exposition config
+*					: applies the same as in daemon mode.
+*
+*					: mode picks how the two combine,
+*
+*					:   push  pushes during and at the end of the run, the default
+*					:   pull  only serves /metrics, pushgateway.disabled
+*					:   dual  serves /metrics during the run and pushes once, at the end, for
+*					:         jobs running longer than a scrape interval that still want the
+*					:         last run on the gateway
+*
+*					: The intermediate pushes are the application's, it skips them when
+*					: FinalPushOnly() says so.
+*
+*					: The listener is opened up front, a port already in use is a startup
+*					: error rather than a log line halfway through the run.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Operating modes
+const (
+	ModePush = "push"
+	ModePull = "pull"
+	ModeDual = "dual"
+)
+
+// ApplyMode validates the mode, disables pushing in pull mode and the periodic pushes
+// in dual mode.
+func (c *Config) ApplyMode() error {
+
+	switch c.Mode {
+	case "", ModePush:
+
+	case ModeDual:
+		c.Pushgateway.Interval = 0 // only the final push
+
+	case ModePull:
+		c.Pushgateway.Disabled = true
+
+	case ModeRelay:
+		if c.Pushgateway.Interval <= 0 {
+			c.Pushgateway.Interval = defaultRelayInterval
+		}
+
+	default:
+		return fmt.Errorf("unknown mode %q, expected push, pull, dual or relay", c.Mode)
+
+	}
+
+	return nil
+}
+
+// FinalPushOnly reports whether only the final push of a run goes out, dual mode.
+func (c Config) FinalPushOnly() bool {
+
+	return c.Mode == ModeDual
+}
+
+type PullConfig struct {
+	Listen string `yaml:"listen"` // eg. :9100, empty disables
+	Path   string `yaml:"path"`   // default /metrics
+}
+
+// MetricsServer serves a registry for scraping, see Serve.
+type MetricsServer struct {
+	srv  *http.Server
+	addr string
+}
+
+// Serve starts serving g on c.Listen, nil when pull mode is disabled.
+func Serve(c PullConfig, g prometheus.Gatherer, e ExpositionConfig) (*MetricsServer, error) {
+
+	if c.Listen == "" {
+		return nil, nil
+
+	}
+	if c.Path == "" {
+		c.Path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(c.Path, MetricsHandler(Consistent(g), e))
+
+	return listen(c.Listen, mux)
+}
+
+// listen serves h on addr.
+func listen(addr string, h http.Handler) (*MetricsServer, error) {
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+
+	}
+
+	s := &MetricsServer{
+		srv:  &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second},
+		addr: ln.Addr().String(),
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			reportFailure("Metrics server failed:", err)
+		}
+	}()
+
+	return s, nil
+}
+
+// Addr returns the address served on, eg. the port picked for :0.
+func (s *MetricsServer) Addr() string {
+
+	if s == nil {
+		return ""
+	}
+	return s.addr
+}
+
+// Close stops the server, letting a scrape in flight finish.
+func (s *MetricsServer) Close() error {
+
+	if s == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.srv.Shutdown(ctx)
+}
+
+// Serve serves the wrapper's registry as per its pull config, nil when disabled.
+func (w *Wrapper) Serve(e ExpositionConfig) (*MetricsServer, error) {
+
+	if w.pull.Listen == "" && (w.mode == ModePull || w.mode == ModeDual) {
+		return nil, fmt.Errorf("mode %s needs pull.listen", w.mode)
+
+	}
+
+	return Serve(w.pull, w.Registry, e)
+}
@@ -0,0 +1,272 @@
+/*****************************************************************************
+*
+*	File			: pushdisk.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: On-disk ring buffer behind the async push queue, pushgateway.queue_dir.
+*					: Every queued push is also written to one of queue_size+1 slot files (the
+*					: queue plus the push being sent), and removed once it's been delivered. On
+*					: the next start whatever is left over, ie. pushes that were queued when we
+*					: died during a gateway outage, is queued again, oldest first.
+*
+*					: A slot file is a header line, then per job a line with the job name and
+*					: the sizes of its families (length delimited protobuf) and gather error,
+*					: a size of -1 for a job that was delivered already (spool.go).
+*					: Pushes for a different set of jobs, the routing changed since, are dropped.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+const diskQueueMagic = "fs_etl_push v1"
+
+type diskQueue struct {
+	dir   string
+	jobs  []string // job names, in r.jobs order
+	slots []uint64 // seq held by each slot, 0 is empty
+	next  uint64   // seq of the next push
+}
+
+func openDiskQueue(dir string, size int, jobs []string) (*diskQueue, error) {
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+
+	}
+
+	return &diskQueue{dir: dir, jobs: jobs, slots: make([]uint64, size+1), next: 1}, nil
+}
+
+func (d *diskQueue) slotPath(slot int) string {
+
+	return filepath.Join(d.dir, fmt.Sprintf("slot-%03d.push", slot))
+}
+
+// store gives req the next seq, unless it has one (coalesced), and writes it to its slot,
+// overwriting the oldest push if that slot is still in use.
+func (d *diskQueue) store(req *pushRequest) error {
+
+	if req.seq == 0 {
+		req.seq = d.next
+		d.next++
+	}
+	slot := int(req.seq % uint64(len(d.slots)))
+	d.slots[slot] = req.seq
+
+	return writePush(d.slotPath(slot), req, d.jobs)
+}
+
+// writePush writes req, pushed to jobs, to path, see the format above. Also used by
+// the spool, see spool.go.
+func writePush(path string, req *pushRequest, jobs []string) error {
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %t %d\n", diskQueueMagic, req.seq, req.replace, len(jobs))
+	for i, job := range jobs {
+		var fams bytes.Buffer
+		for _, mf := range req.mfs[i] {
+			if _, err := protodelim.MarshalTo(&fams, mf); err != nil {
+				return err
+
+			}
+		}
+		if req.skip != nil && req.skip[i] {
+			fmt.Fprintf(&buf, "%s -1 0\n", job)
+			continue
+
+		}
+
+		errText := ""
+		if req.errs[i] != nil {
+			errText = req.errs[i].Error()
+		}
+
+		fmt.Fprintf(&buf, "%s %d %d\n", job, fams.Len(), len(errText))
+		buf.Write(fams.Bytes())
+		buf.WriteString(errText)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// remove drops the slot of a dropped push, unless a later push took it over.
+func (d *diskQueue) remove(seq uint64) error {
+
+	slot := int(seq % uint64(len(d.slots)))
+	if seq == 0 || d.slots[slot] != seq {
+		return nil
+
+	}
+
+	return d.clear(slot)
+}
+
+// delivered drops the slots of seq and everything older, a failed push stays on disk
+// until a later one makes it, it's the snapshot the next start would otherwise lose.
+func (d *diskQueue) delivered(seq uint64) error {
+
+	for slot, s := range d.slots {
+		if s != 0 && s <= seq {
+			if err := d.clear(slot); err != nil {
+				return err
+
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *diskQueue) clear(slot int) error {
+
+	d.slots[slot] = 0
+
+	if err := os.Remove(d.slotPath(slot)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+
+	}
+
+	return nil
+}
+
+// load returns the pushes left over by a previous run, oldest first, and takes over their slots.
+func (d *diskQueue) load() ([]pushRequest, error) {
+
+	files, err := filepath.Glob(filepath.Join(d.dir, "slot-*.push"))
+	if err != nil {
+		return nil, err
+
+	}
+
+	var reqs []pushRequest
+	for _, path := range files {
+		req, err := d.read(path)
+		if err != nil {
+			Log.Warn("Dropping queued push", "path", path, "err", err)
+			os.Remove(path)
+			continue
+
+		}
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].seq < reqs[j].seq })
+
+	// The slots may be numbered differently if queue_size changed, rewrite them all.
+	for _, path := range files {
+		os.Remove(path)
+	}
+	for i := range reqs {
+		reqs[i].seq = 0
+		if err := d.store(&reqs[i]); err != nil {
+			return nil, err
+
+		}
+	}
+
+	return reqs, nil
+}
+
+func (d *diskQueue) read(path string) (pushRequest, error) {
+
+	return readPush(path, d.jobs)
+}
+
+// readPush reads a push written by writePush, for the same jobs.
+func readPush(path string, jobs []string) (pushRequest, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return pushRequest{}, err
+
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	var req pushRequest
+	var n int
+	if _, err := fmt.Fscanf(br, diskQueueMagic+" %d %t %d\n", &req.seq, &req.replace, &n); err != nil {
+		return pushRequest{}, fmt.Errorf("bad header: %w", err)
+
+	}
+	if n != len(jobs) {
+		return pushRequest{}, fmt.Errorf("queued for %d jobs, routing now has %d", n, len(jobs))
+
+	}
+
+	req.mfs = make([][]*dto.MetricFamily, n)
+	req.errs = make([]error, n)
+	for i := range jobs {
+		var job string
+		var famsLen, errLen int
+		if _, err := fmt.Fscanf(br, "%s %d %d\n", &job, &famsLen, &errLen); err != nil {
+			return pushRequest{}, fmt.Errorf("bad job header: %w", err)
+
+		}
+		if job != jobs[i] {
+			return pushRequest{}, fmt.Errorf("queued for job %s, routing now has %s", job, jobs[i])
+
+		}
+		if famsLen < 0 {
+			if req.skip == nil {
+				req.skip = make([]bool, n)
+			}
+			req.skip[i] = true
+			continue
+
+		}
+
+		fams := bufio.NewReader(io.LimitReader(br, int64(famsLen)))
+		for {
+			mf := &dto.MetricFamily{}
+			if err := protodelim.UnmarshalFrom(fams, mf); err != nil {
+				if err == io.EOF {
+					break
+
+				}
+				return pushRequest{}, fmt.Errorf("job %s: %w", job, err)
+
+			}
+			req.mfs[i] = append(req.mfs[i], mf)
+		}
+
+		errText := make([]byte, errLen)
+		if _, err := io.ReadFull(br, errText); err != nil {
+			return pushRequest{}, fmt.Errorf("job %s: %w", job, err)
+
+		}
+		if errLen > 0 {
+			req.errs[i] = errors.New(strings.TrimSpace(string(errText)))
+		}
+	}
+
+	return req, nil
+}
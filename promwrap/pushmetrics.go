@@ -0,0 +1,111 @@
+/*****************************************************************************
+*
+*	File			: pushmetrics.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The router's metrics about its own pushes, so a misbehaving gateway
+*					: shows up on a dashboard rather than only in the job's stdout,
+*
+*					:   fs_etl_push_attempts_total{push_job}
+*					:   fs_etl_push_failures_total{push_job}
+*					:   fs_etl_push_duration_seconds{push_job}
+*					:   fs_etl_push_last_success_timestamp_seconds{push_job}
+*					:   fs_etl_push_payload_bytes{push_job}
+*
+*					: Every attempt counts, retries and the critical only retry included.
+*					: Pushed with the job's metrics, they describe the pushes before the
+*					: one carrying them.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type pushMetrics struct {
+	attempts    *prometheus.CounterVec
+	failures    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	lastSuccess *prometheus.GaugeVec
+	payload     *prometheus.GaugeVec
+}
+
+func newPushMetrics(reg prometheus.Registerer) (*pushMetrics, error) {
+
+	p := &pushMetrics{}
+
+	c, err := RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_attempts_total",
+		Help: "The number of pushes to the pushgateway, retries included, per job.",
+	}, []string{"push_job"}))
+	if err != nil {
+		return nil, err
+
+	}
+	p.attempts = c.(*prometheus.CounterVec)
+
+	if c, err = RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_failures_total",
+		Help: "The number of pushes no pushgateway took, per job.",
+	}, []string{"push_job"})); err != nil {
+		return nil, err
+
+	}
+	p.failures = c.(*prometheus.CounterVec)
+
+	if c, err = RegisterOrExisting(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fs_etl_push_duration_seconds",
+		Help:    "The duration of a push, failovers included, per job.",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"push_job"})); err != nil {
+		return nil, err
+
+	}
+	p.duration = c.(*prometheus.HistogramVec)
+
+	if c, err = RegisterOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fs_etl_push_last_success_timestamp_seconds",
+		Help: "When the job's last successful push was made, unix time.",
+	}, []string{"push_job"})); err != nil {
+		return nil, err
+
+	}
+	p.lastSuccess = c.(*prometheus.GaugeVec)
+
+	if c, err = RegisterOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fs_etl_push_payload_bytes",
+		Help: "The size of the job's last push, length delimited protobuf.",
+	}, []string{"push_job"})); err != nil {
+		return nil, err
+
+	}
+	p.payload = c.(*prometheus.GaugeVec)
+
+	return p, nil
+}
+
+// observe records a push of job, size bytes, that started at start and ended in err.
+func (p *pushMetrics) observe(job string, size int, start time.Time, err error) {
+
+	p.attempts.WithLabelValues(job).Inc()
+	p.duration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	p.payload.WithLabelValues(job).Set(float64(size))
+
+	if err != nil {
+		p.failures.WithLabelValues(job).Inc()
+		return
+
+	}
+	p.lastSuccess.WithLabelValues(job).SetToCurrentTime()
+}
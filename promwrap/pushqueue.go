@@ -0,0 +1,313 @@
+/*****************************************************************************
+*
+*	File			: pushqueue.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Async pushing, pushgateway.async. Add()/Push() gather and queue the push,
+*					: a background worker sends them, so a slow gateway doesn't hold up the
+*					: batch. The queue is bounded, when it's full queue_full_policy decides:
+*
+*					:   block		the producer waits for room (default)
+*					:   drop_oldest	the oldest queued push is dropped
+*					:   drop_newest	this push is dropped, what's queued is kept
+*					:   coalesce	the newest queued push is replaced by this one, it's a
+*					:				later snapshot of the same metrics anyway
+*
+*					: A push that isn't queued, dropped by drop_newest or arriving after the
+*					: queue was closed, is returned as an error from Add()/Push().
+*
+*					: Each is counted in fs_etl_push_queue_full_total{policy}, with the time
+*					: blocked in fs_etl_push_queue_blocked_seconds_total and the pushes lost
+*					: in fs_etl_push_queue_dropped_total{policy}.
+*
+*					: With queue_dir set the queue is also kept on disk, see pushdisk.go, pushes
+*					: restored from a previous run are counted in fs_etl_push_queue_restored_total.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Queue full policies, pushgateway.queue_full_policy
+const (
+	queueBlock      = "block"
+	queueDropOldest = "drop_oldest"
+	queueDropNewest = "drop_newest"
+	queueCoalesce   = "coalesce"
+)
+
+const defaultQueueSize = 16
+
+var (
+	errQueueClosed  = errors.New("push queue closed, push not sent")
+	errQueueDropped = errors.New("push queue full, push dropped by the drop_newest policy")
+)
+
+type pushQueue struct {
+	size    int
+	policy  string
+	deliver func(pushRequest) error
+
+	length   prometheus.Gauge
+	full     *prometheus.CounterVec
+	dropped  *prometheus.CounterVec
+	blocked  prometheus.Counter
+	restored prometheus.Counter
+
+	disk    *diskQueue // nil unless queue_dir is set
+	mu      sync.Mutex
+	cond    *sync.Cond // queue changed: item added/taken, worker idle, closed
+	items   []pushRequest
+	busy    bool // the worker is sending
+	closed  bool
+	stopped chan struct{}
+}
+
+// newPushQueue starts the worker, with dir set the pushes a previous run left in dir are queued first.
+func newPushQueue(size int, policy, dir string, jobs []string, reg prometheus.Registerer, deliver func(pushRequest) error) (*pushQueue, error) {
+
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	if policy == "" {
+		policy = queueBlock
+	}
+
+	q := &pushQueue{
+		size:    size,
+		policy:  policy,
+		deliver: deliver,
+		stopped: make(chan struct{}),
+		length: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_push_queue_length",
+			Help: "Number of pushes waiting in the async push queue.",
+		}),
+		full: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_push_queue_full_total",
+			Help: "The number of pushes that found the async push queue full, by queue full policy.",
+		}, []string{"policy"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_push_queue_dropped_total",
+			Help: "The number of queued pushes dropped or coalesced away because the queue was full.",
+		}, []string{"policy"}),
+		blocked: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fs_etl_push_queue_blocked_seconds_total",
+			Help: "Time producers spent waiting for room in the async push queue, in seconds.",
+		}),
+		restored: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fs_etl_push_queue_restored_total",
+			Help: "The number of pushes restored from the on-disk queue of a previous run.",
+		}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	c, err := RegisterOrExisting(reg, q.length)
+	if err != nil {
+		return nil, err
+
+	}
+	q.length = c.(prometheus.Gauge)
+
+	if c, err = RegisterOrExisting(reg, q.full); err != nil {
+		return nil, err
+
+	}
+	q.full = c.(*prometheus.CounterVec)
+
+	if c, err = RegisterOrExisting(reg, q.dropped); err != nil {
+		return nil, err
+
+	}
+	q.dropped = c.(*prometheus.CounterVec)
+
+	if c, err = RegisterOrExisting(reg, q.blocked); err != nil {
+		return nil, err
+
+	}
+	q.blocked = c.(prometheus.Counter)
+
+	if c, err = RegisterOrExisting(reg, q.restored); err != nil {
+		return nil, err
+
+	}
+	q.restored = c.(prometheus.Counter)
+
+	if dir != "" {
+		if q.disk, err = openDiskQueue(dir, size, jobs); err != nil {
+			return nil, err
+
+		}
+		if q.items, err = q.disk.load(); err != nil {
+			return nil, err
+
+		}
+		for len(q.items) > q.size {
+			q.dropOldest()
+		}
+		if len(q.items) > 0 {
+			Log.Info("Restored queued pushes", "pushes", len(q.items), "dir", dir)
+		}
+		q.restored.Add(float64(len(q.items)))
+		q.length.Set(float64(len(q.items)))
+	}
+
+	go q.run()
+
+	return q, nil
+}
+
+// enqueue queues req, an error means it won't be sent.
+func (q *pushQueue) enqueue(req pushRequest) error {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errQueueClosed
+
+	}
+
+	if len(q.items) >= q.size {
+		q.full.WithLabelValues(q.policy).Inc()
+
+		switch q.policy {
+		case queueDropOldest:
+			q.dropOldest()
+			q.dropped.WithLabelValues(q.policy).Inc()
+
+		case queueDropNewest:
+			q.dropped.WithLabelValues(q.policy).Inc()
+			return errQueueDropped
+
+		case queueCoalesce:
+			last := &q.items[len(q.items)-1]
+			req.replace = req.replace || last.replace
+			req.seq = last.seq
+			q.store(&req)
+			*last = req
+			q.dropped.WithLabelValues(q.policy).Inc()
+			q.cond.Broadcast()
+			return nil
+
+		default:
+			start := time.Now()
+			for len(q.items) >= q.size && !q.closed {
+				q.cond.Wait()
+			}
+			q.blocked.Add(time.Since(start).Seconds())
+
+			// closed while we waited, the worker may be gone already
+			if q.closed {
+				return errQueueClosed
+
+			}
+		}
+	}
+
+	q.store(&req)
+	q.items = append(q.items, req)
+	q.length.Set(float64(len(q.items)))
+	q.cond.Broadcast()
+
+	return nil
+}
+
+// store writes req to the on-disk queue, if any. A push we can't write is still
+// queued, it just won't survive a restart. The caller holds q.mu.
+func (q *pushQueue) store(req *pushRequest) {
+
+	if q.disk == nil {
+		return
+	}
+
+	if err := q.disk.store(req); err != nil {
+		Log.Error("Could not write push to the on-disk queue", "err", err)
+	}
+}
+
+// dropOldest drops the oldest queued push, the caller holds q.mu.
+func (q *pushQueue) dropOldest() {
+
+	if q.disk != nil {
+		if err := q.disk.remove(q.items[0].seq); err != nil {
+			Log.Error("Could not remove push from the on-disk queue", "err", err)
+		}
+	}
+	q.items = q.items[1:]
+}
+
+func (q *pushQueue) run() {
+
+	defer close(q.stopped)
+
+	q.mu.Lock()
+	for {
+		for len(q.items) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.items) == 0 {
+			q.mu.Unlock()
+			return
+
+		}
+
+		req := q.items[0]
+		q.items = q.items[1:]
+		q.length.Set(float64(len(q.items)))
+		q.busy = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		err := q.deliver(req)
+		if err != nil {
+			reportFailure("Could not push to Pushgateway:", err)
+		}
+
+		q.mu.Lock()
+		if err == nil && q.disk != nil {
+			if err := q.disk.delivered(req.seq); err != nil {
+				Log.Error("Could not remove push from the on-disk queue", "err", err)
+			}
+		}
+		q.busy = false
+		q.cond.Broadcast()
+	}
+}
+
+// flush waits until everything queued so far has been sent. That holds after
+// close() too, the worker keeps delivering until the queue is empty and
+// broadcasts after each push, nothing is queued once closed is set.
+func (q *pushQueue) flush() {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) > 0 || q.busy {
+		q.cond.Wait()
+	}
+}
+
+// close sends what's still queued and stops the worker.
+func (q *pushQueue) close() {
+
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.stopped
+}
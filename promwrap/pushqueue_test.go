@@ -0,0 +1,92 @@
+/*****************************************************************************
+*
+*	File			: pushqueue_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Tests of the async push queue, flush() while close() is still
+*					: draining the queue.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestFlushAfterClose checks flush() keeps waiting for the worker once the
+// queue is closed, instead of returning with pushes still undelivered.
+func TestFlushAfterClose(t *testing.T) {
+
+	release := make(chan struct{})
+	var sent atomic.Int32
+
+	q, err := newPushQueue(4, queueBlock, "", nil, prometheus.NewRegistry(), func(pushRequest) error {
+		<-release
+		sent.Add(1)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := q.enqueue(spoolPush(float64(i))); err != nil {
+			t.Fatal(err)
+
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		q.close()
+		close(closed)
+	}()
+
+	// wait for close() to have set closed, the worker is still held up
+	for {
+		q.mu.Lock()
+		c := q.closed
+		q.mu.Unlock()
+		if c {
+			break
+
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	flushed := make(chan struct{})
+	go func() {
+		q.flush()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		t.Fatalf("flush returned with %d of 3 pushes sent", sent.Load())
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-flushed
+	<-closed
+
+	if n := sent.Load(); n != 3 {
+		t.Fatalf("sent %d pushes, want 3", n)
+
+	}
+}
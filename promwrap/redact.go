@@ -0,0 +1,93 @@
+/*****************************************************************************
+*
+*	File			: redact.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Redaction rules, redact, a list of regex -> replacement applied to every
+*					: label value and logged failure before it leaves the process. Account
+*					: numbers end up in file names, file names end up in labels and errors.
+*
+*					:   redact:
+*					:     - pattern: '\d{10,12}'
+*					:       replacement: '<account>'
+*
+*					: Rules apply in order, on the label value as given, before sanitizing,
+*					: so fs_etl_label_sanitized_info only ever sees the redacted value.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type RedactionRule struct {
+	Pattern     string `yaml:"pattern"`     // Go regexp
+	Replacement string `yaml:"replacement"` // may use $1 etc.
+}
+
+type redaction struct {
+	re   *regexp.Regexp
+	with string
+}
+
+// redactions are process wide, the loggers don't have a Metrics to hand.
+var redactions []redaction
+
+// SetRedactions compiles and installs rules, replacing the previous ones. Like the
+// other process wide settings this has to happen before the updates start.
+func SetRedactions(rules []RedactionRule) error {
+
+	compiled := make([]redaction, 0, len(rules))
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("redact pattern %q: %w", r.Pattern, err)
+
+		}
+		compiled = append(compiled, redaction{re, r.Replacement})
+	}
+	redactions = compiled
+
+	return nil
+}
+
+// Redact applies the redaction rules to s.
+func Redact(s string) string {
+
+	for _, r := range redactions {
+		s = r.re.ReplaceAllString(s, r.with)
+	}
+
+	return s
+}
+
+// RedactErr returns err with its message redacted, nil stays nil.
+func RedactErr(err error) error {
+
+	if err == nil || len(redactions) == 0 {
+		return err
+
+	}
+
+	return redactedError{Redact(err.Error()), err}
+}
+
+// redactedError keeps the original for errors.Is/As, only the message is redacted.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e redactedError) Error() string { return e.msg }
+
+func (e redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,634 @@
+/*****************************************************************************
+*
+*	File			: relay.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Relay mode, mode: relay. A long lived instance of the wrapper takes the
+*					: pushes of many short lived loader processes on the same host, in place
+*					: of the pushgateway, and pushes their merged metrics every
+*					: pushgateway.interval, so the gateway sees one group rather than one
+*					: per process and the loaders never wait on it,
+*
+*					:   relay:                      # the relay's promwrap.yaml
+*					:     listen: 127.0.0.1:9191
+*					:     retire: 10m
+*
+*					:   pushgateway:                # the loaders'
+*					:     url: http://127.0.0.1:9191
+*					:     auto_instance: true
+*
+*					: It speaks the pushgateway's push API, PUT/POST/DELETE
+*					: /metrics/job/<job>{/<label>/<value>}, protobuf or text, and takes
+*					: an IngestRequest on /api/v1/ingest, see ingest.go. Every loader
+*					: is a group, hence auto_instance, its job becomes a push_job label of its
+*					: series ("job" is the gateway's), its grouping labels other than instance
+*					: labels as they are. Merged over the loaders of a job, counters,
+*					: histogram buckets and summary counts and sums add up (summary
+*					: quantiles can't be merged and are dropped), gauges take the latest
+*					: push. A loader quiet for retire, or deleting its group, is retired:
+*					: its counters, histograms and summaries are folded into the totals, so
+*					: they never go down, its gauges are dropped.
+
+*					: A retired loader that turns out to be alive pushes its cumulative
+*					: totals again, so the relay keeps what it folded in as the loader's
+*					: baseline, for relayBaselineKeep, and counts only what it added since.
+*					: A series below its baseline is taken to have restarted and counts in
+*					: full. A loader deleting its group is done, its baseline is dropped.
+*
+*					: What the relay pushes is what the loaders sent, plus
+*					: fs_etl_relay_pushes_total{method,result} and fs_etl_relay_loaders, the
+*					: relay's own registry isn't pushed.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ModeRelay = "relay"
+
+	defaultRelayInterval = 15 * time.Second
+	defaultRelayRetire   = 10 * time.Minute
+	relayMaxBody         = 32 << 20
+	relayBaselineKeep    = 24 * time.Hour
+	relayJobLabel        = "push_job" // "job" is reserved by the pushgateway
+)
+
+type RelayConfig struct {
+	Listen string        `yaml:"listen"` // eg. 127.0.0.1:9191, the loaders' pushgateway.url
+	Retire time.Duration `yaml:"retire"` // fold a loader into the totals once quiet this long, default 10m
+}
+
+// Relay merges the pushes of the loaders, see ServeHTTP and Gather.
+type Relay struct {
+	retire time.Duration
+
+	own     *prometheus.Registry
+	pushes  *prometheus.CounterVec
+	loaders prometheus.Gauge
+
+	mu        sync.Mutex
+	groups    map[string]*relayGroup       // by grouping key path
+	retired   map[string]*dto.MetricFamily // folded counters, histograms and summaries
+	baselines map[string]*relayBaseline    // retired loaders by grouping key path
+}
+
+// relayGroup is one loader's metrics, as last pushed.
+type relayGroup struct {
+	families map[string]*dto.MetricFamily
+	baseline map[string]*dto.MetricFamily // folded in already when it was retired before
+	at       time.Time
+}
+
+// relayBaseline is what a retired loader had pushed, folded into the totals, by family.
+type relayBaseline struct {
+	families map[string]*dto.MetricFamily
+	at       time.Time
+}
+
+func NewRelay(c RelayConfig) (*Relay, error) {
+
+	if c.Listen == "" {
+		return nil, fmt.Errorf("mode relay needs relay.listen")
+
+	}
+	if c.Retire <= 0 {
+		c.Retire = defaultRelayRetire
+	}
+
+	r := &Relay{
+		retire: c.Retire,
+		own:    prometheus.NewRegistry(),
+		pushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_relay_pushes_total",
+			Help: "The number of loader pushes taken by the relay, by method and result.",
+		}, []string{"method", "result"}),
+		loaders: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "fs_etl_relay_loaders",
+			Help: "The number of loaders the relay holds metrics of, not yet retired.",
+		}),
+		groups:    make(map[string]*relayGroup),
+		retired:   make(map[string]*dto.MetricFamily),
+		baselines: make(map[string]*relayBaseline),
+	}
+	r.own.MustRegister(r.pushes, r.loaders)
+
+	return r, nil
+}
+
+// ServeHTTP takes a loader's push, as the pushgateway would.
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	switch req.URL.Path {
+	case "/-/ready", "/-/healthy":
+		return
+
+	case "/api/v1/status":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"build_information":{"version":"relay"}}}`)
+		return
+
+	case ingestPath:
+		r.ingest(w, req)
+		return
+
+	}
+
+	labels, err := relayGrouping(req.URL.EscapedPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+
+	}
+	key := req.URL.EscapedPath()
+
+	var status int
+	switch req.Method {
+	case http.MethodPut, http.MethodPost:
+		status, err = r.push(key, labels, req)
+
+	case http.MethodDelete:
+		r.mu.Lock()
+		r.retireGroup(key, false)
+		r.mu.Unlock()
+		status = http.StatusAccepted
+
+	default:
+		status, err = http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method)
+
+	}
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+		http.Error(w, err.Error(), status)
+
+	} else {
+		w.WriteHeader(status)
+
+	}
+	r.pushes.WithLabelValues(req.Method, result).Inc()
+}
+
+// push decodes a push and stores it, see store.
+func (r *Relay) push(key string, labels []*dto.LabelPair, req *http.Request) (int, error) {
+
+	var families []*dto.MetricFamily
+	dec := expfmt.NewDecoder(io.LimitReader(req.Body, relayMaxBody), expfmt.ResponseFormat(req.Header))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); errors.Is(err, io.EOF) {
+			break
+
+		} else if err != nil {
+			return http.StatusBadRequest, err
+
+		}
+		families = append(families, mf)
+	}
+
+	r.store(key, labels, families, req.Method == http.MethodPut)
+
+	if req.Method == http.MethodPut {
+		return http.StatusOK, nil
+
+	}
+
+	return http.StatusAccepted, nil
+}
+
+// store adds the grouping labels to families and replaces the group's families with
+// them, all of them with replace (PUT), the ones pushed otherwise (POST).
+func (r *Relay) store(key string, labels []*dto.LabelPair, families []*dto.MetricFamily, replace bool) {
+
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			metric.Label = withGrouping(metric.GetLabel(), labels)
+			metric.TimestampMs = nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g := r.groups[key]
+	if g == nil || replace {
+		var baseline map[string]*dto.MetricFamily
+		if g != nil {
+			baseline = g.baseline
+
+		} else if b := r.baselines[key]; b != nil {
+			baseline = b.families
+			delete(r.baselines, key)
+
+		}
+		g = &relayGroup{families: make(map[string]*dto.MetricFamily), baseline: baseline}
+		r.groups[key] = g
+	}
+	for _, mf := range families {
+		g.families[mf.GetName()] = mf
+	}
+	g.at = time.Now()
+	r.loaders.Set(float64(len(r.groups)))
+}
+
+// relayGrouping returns the grouping labels of a push path, the job as push_job and
+// instance left out.
+func relayGrouping(path string) ([]*dto.LabelPair, error) {
+
+	parts := strings.Split(strings.TrimPrefix(path, "/metrics/"), "/")
+	if !strings.HasPrefix(path, "/metrics/") || len(parts)%2 != 0 || strings.TrimSuffix(parts[0], "@base64") != "job" {
+		return nil, fmt.Errorf("expected /metrics/job/<job>{/<label>/<value>}, got %s", path)
+
+	}
+
+	var labels []*dto.LabelPair
+	for i := 0; i < len(parts); i += 2 {
+		name, value := parts[i], parts[i+1]
+		var err error
+		if strings.HasSuffix(name, "@base64") {
+			name = strings.TrimSuffix(name, "@base64")
+			var b []byte
+			b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+			value = string(b)
+
+		} else {
+			value, err = url.QueryUnescape(value)
+
+		}
+		if err != nil {
+			return nil, fmt.Errorf("grouping label %s: %w", name, err)
+
+		}
+		switch name {
+		case "instance":
+			continue
+
+		case "job":
+			name = relayJobLabel
+
+		}
+		labels = append(labels, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+	}
+
+	return labels, nil
+}
+
+// withGrouping adds the grouping labels a series doesn't have yet, sorted by name.
+func withGrouping(lps, grouping []*dto.LabelPair) []*dto.LabelPair {
+
+	if len(grouping) == 0 {
+		return lps
+	}
+
+	has := make(map[string]bool, len(lps))
+	for _, lp := range lps {
+		has[lp.GetName()] = true
+	}
+	for _, lp := range grouping {
+		if !has[lp.GetName()] {
+			lps = append(lps, lp)
+		}
+	}
+	sort.Slice(lps, func(i, j int) bool { return lps[i].GetName() < lps[j].GetName() })
+
+	return lps
+}
+
+// retireGroup folds the group's totals into retired and drops it, keeping what it
+// pushed as its baseline with keep, the caller holds r.mu.
+func (r *Relay) retireGroup(key string, keep bool) {
+
+	g, ok := r.groups[key]
+	if !ok {
+		return
+	}
+
+	merged := newRelayMerge()
+	for _, mf := range r.retired {
+		merged.add(mf, time.Time{})
+	}
+	baseline := make(map[string]*dto.MetricFamily, len(g.baseline)+len(g.families))
+	for name, mf := range g.baseline {
+		baseline[name] = mf
+	}
+	for _, mf := range g.families {
+		if mf.GetType() != dto.MetricType_GAUGE && mf.GetType() != dto.MetricType_UNTYPED {
+			merged.add(sinceBaseline(mf, g.baseline[mf.GetName()]), time.Time{})
+			baseline[mf.GetName()] = mf
+		}
+	}
+
+	r.retired = make(map[string]*dto.MetricFamily)
+	for _, mf := range merged.families() {
+		r.retired[mf.GetName()] = mf
+	}
+
+	if keep {
+		r.baselines[key] = &relayBaseline{families: baseline, at: time.Now()}
+	}
+
+	delete(r.groups, key)
+	r.loaders.Set(float64(len(r.groups)))
+}
+
+// Gather retires the quiet loaders and returns the loaders' merged metrics, along
+// with the relay's own.
+func (r *Relay) Gather() ([]*dto.MetricFamily, error) {
+
+	r.mu.Lock()
+	for key, g := range r.groups {
+		if time.Since(g.at) > r.retire {
+			r.retireGroup(key, true)
+		}
+	}
+	for key, b := range r.baselines {
+		if time.Since(b.at) > relayBaselineKeep {
+			delete(r.baselines, key)
+		}
+	}
+
+	merged := newRelayMerge()
+	for _, mf := range r.retired {
+		merged.add(mf, time.Time{})
+	}
+	for _, g := range r.groups {
+		for _, mf := range g.families {
+			merged.add(sinceBaseline(mf, g.baseline[mf.GetName()]), g.at)
+		}
+	}
+	r.mu.Unlock()
+
+	own, err := r.own.Gather()
+	mfs := append(merged.families(), own...)
+	sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+
+	if len(merged.errs) > 0 {
+		err = fmt.Errorf("relay: %s", strings.Join(merged.errs, "; "))
+
+	}
+
+	return mfs, err
+}
+
+// relayMerge adds up metric families series by series, see relay.go's description.
+type relayMerge struct {
+	byName map[string]*dto.MetricFamily
+	series map[string]map[string]*relaySeries // family name -> label key
+	errs   []string
+}
+
+type relaySeries struct {
+	metric *dto.Metric
+	at     time.Time
+}
+
+func newRelayMerge() *relayMerge {
+
+	return &relayMerge{byName: make(map[string]*dto.MetricFamily), series: make(map[string]map[string]*relaySeries)}
+}
+
+// add merges mf, pushed at at, copying what it keeps.
+func (m *relayMerge) add(mf *dto.MetricFamily, at time.Time) {
+
+	name := mf.GetName()
+	out, ok := m.byName[name]
+	if !ok {
+		out = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+		m.byName[name] = out
+		m.series[name] = make(map[string]*relaySeries)
+
+	} else if out.GetType() != mf.GetType() {
+		m.errs = append(m.errs, fmt.Sprintf("%s pushed as both %s and %s", name, out.GetType(), mf.GetType()))
+		return
+
+	}
+
+	for _, metric := range mf.GetMetric() {
+		key := labelKey(metric.GetLabel())
+		s, ok := m.series[name][key]
+		if !ok {
+			s = &relaySeries{metric: relayCopy(metric, mf.GetType()), at: at}
+			m.series[name][key] = s
+			out.Metric = append(out.Metric, s.metric)
+			continue
+
+		}
+
+		if err := s.merge(metric, mf.GetType(), at); err != nil {
+			m.errs = append(m.errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+}
+
+func (m *relayMerge) families() []*dto.MetricFamily {
+
+	mfs := make([]*dto.MetricFamily, 0, len(m.byName))
+	for _, mf := range m.byName {
+		sort.Slice(mf.Metric, func(i, j int) bool { return labelKey(mf.Metric[i].GetLabel()) < labelKey(mf.Metric[j].GetLabel()) })
+		mfs = append(mfs, mf)
+	}
+	sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+
+	return mfs
+}
+
+func labelKey(lps []*dto.LabelPair) string {
+
+	var b strings.Builder
+	for _, lp := range lps {
+		b.WriteString(lp.GetName())
+		b.WriteByte(0xfe)
+		b.WriteString(lp.GetValue())
+		b.WriteByte(0xff)
+	}
+
+	return b.String()
+}
+
+// relayCopy copies the parts of metric that merge, summary quantiles and native
+// histogram buckets left out.
+func relayCopy(metric *dto.Metric, t dto.MetricType) *dto.Metric {
+
+	out := &dto.Metric{Label: metric.Label}
+	switch t {
+	case dto.MetricType_COUNTER:
+		out.Counter = &dto.Counter{Value: proto.Float64(metric.GetCounter().GetValue())}
+
+	case dto.MetricType_GAUGE:
+		out.Gauge = &dto.Gauge{Value: proto.Float64(metric.GetGauge().GetValue())}
+
+	case dto.MetricType_UNTYPED:
+		out.Untyped = &dto.Untyped{Value: proto.Float64(metric.GetUntyped().GetValue())}
+
+	case dto.MetricType_SUMMARY:
+		s := metric.GetSummary()
+		out.Summary = &dto.Summary{SampleCount: proto.Uint64(s.GetSampleCount()), SampleSum: proto.Float64(s.GetSampleSum())}
+
+	case dto.MetricType_HISTOGRAM:
+		h := metric.GetHistogram()
+		out.Histogram = &dto.Histogram{SampleCount: proto.Uint64(h.GetSampleCount()), SampleSum: proto.Float64(h.GetSampleSum())}
+		for _, b := range h.GetBucket() {
+			out.Histogram.Bucket = append(out.Histogram.Bucket, &dto.Bucket{
+				UpperBound:      proto.Float64(b.GetUpperBound()),
+				CumulativeCount: proto.Uint64(b.GetCumulativeCount()),
+			})
+		}
+
+	}
+
+	return out
+}
+
+// sinceBaseline returns mf less base, what the same loader had pushed when it was
+// retired. A series below its baseline restarted and is taken as is.
+func sinceBaseline(mf, base *dto.MetricFamily) *dto.MetricFamily {
+
+	t := mf.GetType()
+	if base == nil || base.GetType() != t || t == dto.MetricType_GAUGE || t == dto.MetricType_UNTYPED {
+		return mf
+
+	}
+
+	bases := make(map[string]*dto.Metric, len(base.GetMetric()))
+	for _, metric := range base.GetMetric() {
+		bases[labelKey(metric.GetLabel())] = metric
+	}
+
+	out := &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+	for _, metric := range mf.GetMetric() {
+		b, ok := bases[labelKey(metric.GetLabel())]
+		if !ok || !relayAtLeast(metric, b, t) {
+			out.Metric = append(out.Metric, metric)
+			continue
+
+		}
+
+		delta := relayCopy(metric, t)
+		switch t {
+		case dto.MetricType_COUNTER:
+			*delta.Counter.Value -= b.GetCounter().GetValue()
+
+		case dto.MetricType_SUMMARY:
+			*delta.Summary.SampleCount -= b.GetSummary().GetSampleCount()
+			*delta.Summary.SampleSum -= b.GetSummary().GetSampleSum()
+
+		case dto.MetricType_HISTOGRAM:
+			for i, bucket := range b.GetHistogram().GetBucket() {
+				*delta.Histogram.Bucket[i].CumulativeCount -= bucket.GetCumulativeCount()
+			}
+			*delta.Histogram.SampleCount -= b.GetHistogram().GetSampleCount()
+			*delta.Histogram.SampleSum -= b.GetHistogram().GetSampleSum()
+
+		}
+		out.Metric = append(out.Metric, delta)
+	}
+
+	return out
+}
+
+// relayAtLeast reports whether metric carries on from base, rather than having restarted.
+func relayAtLeast(metric, base *dto.Metric, t dto.MetricType) bool {
+
+	switch t {
+	case dto.MetricType_COUNTER:
+		return metric.GetCounter().GetValue() >= base.GetCounter().GetValue()
+
+	case dto.MetricType_SUMMARY:
+		return metric.GetSummary().GetSampleCount() >= base.GetSummary().GetSampleCount()
+
+	case dto.MetricType_HISTOGRAM:
+		h, b := metric.GetHistogram(), base.GetHistogram()
+		if h.GetSampleCount() < b.GetSampleCount() || len(h.GetBucket()) != len(b.GetBucket()) {
+			return false
+
+		}
+		for i, bucket := range h.GetBucket() {
+			if bucket.GetUpperBound() != b.GetBucket()[i].GetUpperBound() || bucket.GetCumulativeCount() < b.GetBucket()[i].GetCumulativeCount() {
+				return false
+
+			}
+		}
+		return true
+
+	}
+
+	return false
+}
+
+// merge adds metric, pushed at at, to the series.
+func (s *relaySeries) merge(metric *dto.Metric, t dto.MetricType, at time.Time) error {
+
+	out := s.metric
+	switch t {
+	case dto.MetricType_COUNTER:
+		*out.Counter.Value += metric.GetCounter().GetValue()
+
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		if at.After(s.at) {
+			latest := relayCopy(metric, t)
+			out.Gauge, out.Untyped = latest.Gauge, latest.Untyped
+			s.at = at
+		}
+
+	case dto.MetricType_SUMMARY:
+		*out.Summary.SampleCount += metric.GetSummary().GetSampleCount()
+		*out.Summary.SampleSum += metric.GetSummary().GetSampleSum()
+
+	case dto.MetricType_HISTOGRAM:
+		h := metric.GetHistogram()
+		if len(h.GetBucket()) != len(out.Histogram.Bucket) {
+			return fmt.Errorf("histogram buckets differ between loaders")
+
+		}
+		for i, b := range h.GetBucket() {
+			if b.GetUpperBound() != out.Histogram.Bucket[i].GetUpperBound() {
+				return fmt.Errorf("histogram buckets differ between loaders")
+
+			}
+		}
+		for i, b := range h.GetBucket() {
+			*out.Histogram.Bucket[i].CumulativeCount += b.GetCumulativeCount()
+		}
+		*out.Histogram.SampleCount += h.GetSampleCount()
+		*out.Histogram.SampleSum += h.GetSampleSum()
+
+	}
+
+	return nil
+}
+
+// ServeRelay starts taking the loaders' pushes on relay.listen, mode relay only.
+func (w *Wrapper) ServeRelay() (*MetricsServer, error) {
+
+	if w.Relay == nil {
+		return nil, fmt.Errorf("mode %s doesn't relay", w.mode)
+
+	}
+
+	return listen(w.relay.Listen, w.Relay)
+}
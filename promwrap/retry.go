@@ -0,0 +1,142 @@
+/*****************************************************************************
+*
+*	File			: retry.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Retries of failed pushes with exponential backoff, pushgateway.retry,
+*					: so a gateway restart or a network blip doesn't cost a batch its metrics.
+*					: The delay starts at initial_delay and is multiplied by backoff_factor
+*					: after every attempt, up to max_delay, for at most max_attempts attempts.
+*
+*					: A 4xx response, eg. a push clashing with the gateway's existing metrics,
+*					: fails the same way every time and isn't retried, 429 excepted.
+*					: Without async the batch waits for the retries, with async the push worker.
+*
+*					: Retries are counted in fs_etl_push_retries_total{push_job}.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultRetryDelay    = time.Second
+	defaultRetryBackoff  = 2.0
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+type RetryConfig struct {
+	MaxAttempts   int           `yaml:"max_attempts"`   // including the first one, 0 or 1 doesn't retry
+	InitialDelay  time.Duration `yaml:"initial_delay"`  // default 1s
+	BackoffFactor float64       `yaml:"backoff_factor"` // default 2
+	MaxDelay      time.Duration `yaml:"max_delay"`      // default 30s
+}
+
+type retrier struct {
+	RetryConfig
+	retries *prometheus.CounterVec
+	sleep   func(context.Context, time.Duration) error
+}
+
+func newRetrier(c RetryConfig, reg prometheus.Registerer) (*retrier, error) {
+
+	if c.MaxAttempts <= 1 {
+		return nil, nil
+	}
+
+	if c.InitialDelay <= 0 {
+		c.InitialDelay = defaultRetryDelay
+	}
+	if c.BackoffFactor < 1 {
+		c.BackoffFactor = defaultRetryBackoff
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = defaultRetryMaxDelay
+	}
+
+	c2, err := RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_retries_total",
+		Help: "The number of retried pushes after a failure, per job.",
+	}, []string{"push_job"}))
+	if err != nil {
+		return nil, err
+
+	}
+
+	return &retrier{RetryConfig: c, retries: c2.(*prometheus.CounterVec), sleep: sleepContext}, nil
+}
+
+// sleepContext sleeps for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+
+	}
+}
+
+// do runs push until it succeeds, fails permanently, runs out of attempts or ctx is
+// done, the last error is returned. A nil retrier tries once.
+func (r *retrier) do(ctx context.Context, job string, push func() error) error {
+
+	err := push()
+	if r == nil {
+		return err
+
+	}
+
+	delay := r.InitialDelay
+	for attempt := 2; err != nil && attempt <= r.MaxAttempts && !permanent(err) && ctx.Err() == nil; attempt++ {
+		Log.Warn("Push failed, retrying", "job", job, "retry", attempt-1, "of", r.MaxAttempts-1, "in", delay, "err", RedactErr(err))
+		if r.sleep(ctx, delay) != nil {
+			break
+
+		}
+		r.retries.WithLabelValues(job).Inc()
+
+		err = push()
+
+		delay = time.Duration(float64(delay) * r.BackoffFactor)
+		if delay > r.MaxDelay {
+			delay = r.MaxDelay
+		}
+	}
+
+	return err
+}
+
+// permanent reports whether the gateway rejected the push in a way a retry won't fix,
+// push.Pusher only gives us the status code in the error text.
+func permanent(err error) bool {
+
+	msg := err.Error()
+	i := strings.Index(msg, "unexpected status code ")
+	if i < 0 {
+		return false
+
+	}
+	code := msg[i+len("unexpected status code "):]
+
+	return strings.HasPrefix(code, "4") && !strings.HasPrefix(code, "429")
+}
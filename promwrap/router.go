@@ -0,0 +1,922 @@
+/*****************************************************************************
+*
+*	File			: router.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Manages one pusher per job name, routing metric families to the job
+*					: they are configured for, eg fs_loader_ingest, fs_loader_transform.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultGatewayURL = "http://127.0.0.1:9091"
+	defaultJobName    = "pushgateway"
+)
+
+type PushgatewayConfig struct {
+	URL string `yaml:"url"`
+
+	// Secondary gateways, failed over to in order, or with FanOut all pushed to, see gateways.go
+	URLs   []string `yaml:"urls"`
+	FanOut bool     `yaml:"fan_out"`
+
+	Job string `yaml:"job"` // receives all families not routed to one of the jobs below
+
+	// Don't push at all, Add/Push are no-ops, eg. for a developer's laptop
+	Disabled bool `yaml:"disabled"`
+
+	// HTTP basic auth, eg. from PROMWRAP_USERNAME/PROMWRAP_PASSWORD, empty username disables
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// HTTPS towards the gateway, see tls.go
+	TLS       TLSConfig    `yaml:"tls"`
+	tlsConfig *tls.Config  // WithTLSConfig, takes precedence over TLS
+	client    *http.Client // WithHTTPClient, takes precedence over both
+
+	// job name -> metric family names pushed under that job
+	Jobs map[string][]string `yaml:"jobs"`
+
+	// Skip a push identical to the previous one within this window, 0 disables
+	DedupWindow time.Duration `yaml:"dedup_window"`
+
+	// tolerate (default) or fail, whether a batch whose final push failed fails the
+	// process exit code, for pipelines where missing telemetry is unacceptable.
+	FailurePolicy string `yaml:"failure_policy"`
+
+	// Push from a background worker through a queue of QueueSize, with QueueFullPolicy
+	// block (default), drop_oldest, drop_newest or coalesce, see pushqueue.go
+	Async           bool   `yaml:"async"`
+	QueueSize       int    `yaml:"queue_size"`
+	QueueFullPolicy string `yaml:"queue_full_policy"`
+	QueueDir        string `yaml:"queue_dir"` // keep the queue on disk, see pushdisk.go
+
+	// Keep failed pushes, at most SpoolSize, and replay them once the gateway is back, see spool.go
+	SpoolDir  string `yaml:"spool_dir"`
+	SpoolSize int    `yaml:"spool_size"`
+
+	// Push from a background goroutine every Interval, 0 leaves the pushes to the
+	// application, see periodic.go
+	Interval time.Duration `yaml:"interval"`
+
+	// Extra grouping key labels of every push, so parallel workers pushing the same job
+	// don't overwrite each other, auto_instance adds instance=<hostname>-<pid>, see grouping.go
+	Grouping     map[string]string `yaml:"grouping"`
+	AutoInstance bool              `yaml:"auto_instance"`
+
+	// phase -> metric families pushed under the phase=<phase> grouping key of their
+	// job, so each phase can be replaced or deleted on its own, see phases.go
+	Phases map[string][]string `yaml:"phases"`
+
+	// Offline mode, see offline.go. After OfflineAfter of failing pushes they go to
+	// OfflineTextfile instead, probing the gateway every OfflineProbeInterval.
+	OfflineTextfile      string        `yaml:"offline_textfile"`
+	OfflineAfter         time.Duration `yaml:"offline_after"`
+	OfflineProbeInterval time.Duration `yaml:"offline_probe_interval"`
+
+	// Deadline of every Add/Push, retries included, 0 waits as long as the gateway takes.
+	// AddContext/PushContext take the caller's deadline as well.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Retry failed pushes with exponential backoff, see retry.go
+	Retry RetryConfig `yaml:"retry"`
+
+	// Degraded mode, see priority.go. Families per tier, critical, normal (default) or
+	// debug, and a cap on the size of a single push, 0 is unlimited.
+	Priorities   map[string][]string `yaml:"priorities"`
+	MaxPushBytes int                 `yaml:"max_push_bytes"`
+
+	// HMAC sign every push for a verifying proxy, see signing.go
+	Signing SigningConfig `yaml:"signing"`
+
+	// Delete the job's groups this long after a run's final push, once Prometheus scraped
+	// it, for one-shot jobs whose last values shouldn't linger, 0 disables, see Cleanup
+	CleanupAfter time.Duration `yaml:"cleanup_after"`
+
+	// Pre 1.0 gateway protocol, legacy, or auto to detect it at startup, see compat.go
+	Compat string `yaml:"compat"`
+
+	// Probe the gateway at startup, off (default), warn or fail, see preflight.go
+	Preflight        string        `yaml:"preflight"`
+	PreflightTimeout time.Duration `yaml:"preflight_timeout"`
+}
+
+const (
+	PolicyTolerate = "tolerate"
+	PolicyFail     = "fail"
+)
+
+func (c PushgatewayConfig) validate() error {
+
+	switch c.FailurePolicy {
+	case "", PolicyTolerate, PolicyFail:
+
+	default:
+		return fmt.Errorf("pushgateway failure_policy %q, expected tolerate or fail", c.FailurePolicy)
+
+	}
+
+	switch c.QueueFullPolicy {
+	case "", queueBlock, queueDropOldest, queueDropNewest, queueCoalesce:
+
+	default:
+		return fmt.Errorf("pushgateway queue_full_policy %q, expected block, drop_oldest, drop_newest or coalesce", c.QueueFullPolicy)
+
+	}
+
+	for _, u := range c.URLs {
+		if u == "" || u == c.GatewayURL() {
+			return fmt.Errorf("pushgateway urls %v, expected gateways other than url %s", c.URLs, c.GatewayURL())
+
+		}
+	}
+
+	switch c.Preflight {
+	case PreflightOff, PreflightWarn, PreflightFail:
+
+	default:
+		return fmt.Errorf("pushgateway preflight %q, expected warn or fail", c.Preflight)
+
+	}
+
+	return nil
+}
+
+// PushStats are counted over all jobs since the router was created.
+type PushStats struct {
+	Attempts   int
+	Failures   int
+	Degraded   int  // job pushes that only carried their higher priority families
+	Offline    int  // pushes written to the offline textfile instead
+	LastFailed bool // the most recent Add/Push failed for at least one job
+	LastErr    error
+}
+
+// Since returns the attempts and failures since an earlier snapshot.
+func (s PushStats) Since(before PushStats) PushStats {
+
+	s.Attempts -= before.Attempts
+	s.Failures -= before.Failures
+	s.Degraded -= before.Degraded
+	s.Offline -= before.Offline
+
+	return s
+}
+
+func (c PushgatewayConfig) GatewayURL() string {
+
+	if c.URL == "" {
+		return defaultGatewayURL
+
+	}
+
+	return c.URL
+}
+
+func (c PushgatewayConfig) JobName() string {
+
+	if c.Job == "" {
+		return defaultJobName
+
+	}
+
+	return c.Job
+}
+
+// familyFilter only lets through the metric families keep() says yes to, by their
+// names in code, and renames them as per the namespace, see namespace.go.
+type familyFilter struct {
+	g    prometheus.Gatherer
+	keep func(name string) bool
+}
+
+func (f familyFilter) Gather() ([]*dto.MetricFamily, error) {
+
+	mfs, err := f.g.Gather()
+
+	out := mfs[:0]
+	for _, mf := range mfs {
+		if f.keep(mf.GetName()) {
+			out = append(out, mf)
+
+		}
+	}
+
+	return renamed(out), err
+}
+
+// snapshotGatherer hands the pusher the families we already gathered, so what gets
+// pushed is exactly what was checked for duplicates.
+type snapshotGatherer struct {
+	mfs []*dto.MetricFamily
+}
+
+func (s *snapshotGatherer) Gather() ([]*dto.MetricFamily, error) {
+
+	return s.mfs, nil
+}
+
+type jobPusher struct {
+	name     string // job, or job/phase, see phases.go
+	job      string
+	phase    string
+	gatherer prometheus.Gatherer // the families routed to this job
+	keep     func(name string) bool
+	snapshot snapshotGatherer
+	gateways []gateway // primary first, see gateways.go
+
+	mu     sync.Mutex
+	last   uint64 // hash of the last successful push
+	lastAt time.Time
+}
+
+type PushRouter struct {
+	jobs        []*jobPusher // push order, default job last
+	disabled    bool
+	timeout     time.Duration
+	dedupWindow time.Duration
+	duplicates  *prometheus.CounterVec
+	queue       *pushQueue      // nil unless async
+	offline     *offlineSink    // nil unless offline mode is configured
+	retry       *retrier        // nil unless retries are configured
+	spool       *spool          // nil unless spool_dir is set
+	periodic    *periodicPusher // nil unless interval is set
+	legacy      bool            // pre 1.0 gateway, see compat.go
+	grouping    [][2]string     // name, value, see grouping.go
+	mirrors     []mirror        // see mirror.go
+	paths       *prometheus.CounterVec
+
+	fanOut        bool // push to every gateway rather than fail over, see gateways.go
+	gatewayPushes *prometheus.CounterVec
+	pushes        *pushMetrics
+
+	priorities    priorities
+	maxPushBytes  int
+	degraded      *prometheus.GaugeVec
+	degradedTotal *prometheus.CounterVec
+
+	mu      sync.Mutex
+	stats   PushStats
+	final   *prometheus.Registry // attached for the next push only, see final.go
+	onError []func(error)
+}
+
+func NewPushRouter(c PushgatewayConfig, reg *prometheus.Registry) (*PushRouter, error) {
+
+	return newPushRouter(c, reg, reg)
+}
+
+// newPushRouter registers the router's own metrics with reg and pushes g.
+func newPushRouter(c PushgatewayConfig, reg prometheus.Registerer, g prometheus.Gatherer) (*PushRouter, error) {
+
+	c.URL = c.GatewayURL()
+	c.Job = c.JobName()
+
+	if err := c.validate(); err != nil {
+		return nil, err
+
+	}
+
+	r := &PushRouter{
+		disabled:    c.Disabled,
+		timeout:     c.Timeout,
+		dedupWindow: c.DedupWindow,
+		duplicates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fs_etl_push_duplicates_suppressed_total",
+			Help: "The number of pushes skipped because they were identical to the previous push.",
+		}, []string{"push_job"}), // "job" is reserved by the pushgateway
+	}
+
+	c2, err := RegisterOrExisting(reg, r.duplicates)
+	if err != nil {
+		return nil, err
+
+	}
+	r.duplicates = c2.(*prometheus.CounterVec)
+
+	if r.priorities, err = newPriorities(c.Priorities); err != nil {
+		return nil, err
+
+	}
+	r.maxPushBytes = c.MaxPushBytes
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: degradedFamily,
+		Help: "1 when the last push of the job only carried its higher priority metric families.",
+	}, []string{"push_job"})); err != nil {
+		return nil, err
+
+	}
+	r.degraded = c2.(*prometheus.GaugeVec)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_degraded_total",
+		Help: "The number of pushes that only carried their higher priority metric families, by reason.",
+	}, []string{"push_job", "reason"})); err != nil {
+		return nil, err
+
+	}
+	r.degradedTotal = c2.(*prometheus.CounterVec)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_push_path_total",
+		Help: "The number of job pushes per destination, the pushgateway or a mirror, by result.",
+	}, []string{"path", "result"})); err != nil {
+		return nil, err
+
+	}
+	r.paths = c2.(*prometheus.CounterVec)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fs_etl_pushgateway_pushes_total",
+		Help: "The number of job pushes per pushgateway, failovers included, by result.",
+	}, []string{"gateway", "result"})); err != nil {
+		return nil, err
+
+	}
+	r.gatewayPushes = c2.(*prometheus.CounterVec)
+	r.fanOut = c.FanOut
+
+	if r.pushes, err = newPushMetrics(reg); err != nil {
+		return nil, err
+
+	}
+
+	if r.offline, err = newOfflineSink(c, reg); err != nil {
+		return nil, err
+
+	}
+
+	if r.retry, err = newRetrier(c.Retry, reg); err != nil {
+		return nil, err
+
+	}
+
+	hc, err := c.httpClient()
+	if err != nil {
+		return nil, err
+
+	}
+
+	var client push.HTTPDoer = hc
+	signer, err := newSigningClient(c.Signing, hc)
+	if err != nil {
+		return nil, err
+
+	} else if signer != nil {
+		client = signer
+
+	}
+
+	if r.legacy, err = c.legacyGateway(client); err != nil {
+		return nil, err
+
+	}
+
+	// family -> job, a family may only be routed to one job
+	routed := make(map[string]string)
+	var jobs []string
+	for job, families := range c.Jobs {
+		if job == c.Job {
+			return nil, fmt.Errorf("job %q is the default job, it can't also be routed", job)
+
+		}
+
+		for _, name := range families {
+			if other, ok := routed[name]; ok {
+				return nil, fmt.Errorf("metric family %s routed to both %s and %s", name, other, job)
+
+			}
+			routed[name] = job
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+
+	jobOf := func(name string) string {
+		if job, ok := routed[name]; ok {
+			return job
+		}
+		return c.Job
+	}
+
+	phaseOf, err := phaseFamilies(c.Phases)
+	if err != nil {
+		return nil, err
+
+	}
+
+	if r.grouping, err = c.groupingKey(); err != nil {
+		return nil, err
+
+	}
+
+	if r.legacy {
+		for _, kv := range r.grouping {
+			if err := legacyName("grouping value", kv[1]); err != nil {
+				return nil, err
+
+			}
+		}
+		for _, job := range append(jobs, c.Job) {
+			if err := legacyName("job", job); err != nil {
+				return nil, err
+
+			}
+		}
+		for phase := range c.Phases {
+			if err := legacyName("phase", phase); err != nil {
+				return nil, err
+
+			}
+		}
+	}
+
+	// per job its phases, then the families in no phase, skipped for a routed job
+	// with all of its families in a phase
+	for _, job := range append(jobs, c.Job) {
+		job := job
+		for _, phase := range jobPhases(phaseOf, jobOf, job) {
+			phase := phase
+			r.add(c, job, phase, client, familyFilter{consistentGatherer{g}, func(name string) bool { return jobOf(name) == job && phaseOf[name] == phase }})
+		}
+
+		unphased := job == c.Job
+		for _, name := range c.Jobs[job] {
+			if _, ok := phaseOf[name]; !ok {
+				unphased = true
+			}
+		}
+		if unphased {
+			r.add(c, job, "", client, familyFilter{consistentGatherer{g}, func(name string) bool { _, ok := phaseOf[name]; return jobOf(name) == job && !ok }})
+		}
+	}
+
+	names := make([]string, len(r.jobs))
+	for i, jp := range r.jobs {
+		names[i] = jp.name
+	}
+
+	if r.spool, err = newSpool(c, names, reg); err != nil {
+		return nil, err
+
+	}
+
+	if c.Async {
+		if r.queue, err = newPushQueue(c.QueueSize, c.QueueFullPolicy, c.QueueDir, names, reg, r.deliverQueued); err != nil {
+			return nil, err
+
+		}
+	}
+
+	if c.Interval > 0 && !c.Disabled {
+		r.periodic = newPeriodicPusher(c.Interval, r.Add)
+	}
+
+	return r, nil
+}
+
+// registerOrExisting registers c, or returns the identical collector registered earlier,
+// eg. by a previous router on the same registry.
+func RegisterOrExisting(reg prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+
+	if err := reg.Register(c); err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			return nil, err
+
+		}
+		return are.ExistingCollector, nil
+
+	}
+
+	return c, nil
+}
+
+func (r *PushRouter) add(c PushgatewayConfig, job, phase string, client push.HTTPDoer, f familyFilter) {
+
+	jp := &jobPusher{name: job, job: job, phase: phase, gatherer: f, keep: f.keep}
+	if phase != "" {
+		jp.name = job + "/" + phase
+	}
+
+	for _, u := range c.GatewayURLs() {
+		p := push.New(u, job).Gatherer(&jp.snapshot).Client(client)
+		if r.legacy {
+			p.Format(expfmt.NewFormat(expfmt.TypeTextPlain))
+		}
+		for _, kv := range r.grouping {
+			p.Grouping(kv[0], kv[1])
+		}
+		if phase != "" {
+			p.Grouping(PhaseLabel, phase)
+		}
+		if c.Username != "" {
+			p.BasicAuth(c.Username, c.Password)
+		}
+		jp.gateways = append(jp.gateways, gateway{name: gatewayName(u), pusher: p})
+	}
+
+	r.jobs = append(r.jobs, jp)
+}
+
+// Add pushes every job, see push.Pusher.Add. All jobs are attempted even if one fails.
+// In async mode the push is queued and Add returns straight away, see Flush.
+func (r *PushRouter) Add() error {
+
+	return r.each(context.Background(), false)
+}
+
+// Push pushes every job, replacing all of the job's metrics, see push.Pusher.Push.
+func (r *PushRouter) Push() error {
+
+	return r.each(context.Background(), true)
+}
+
+// AddContext is Add, giving up when ctx is done, so a hung gateway can't stall the
+// caller. The jobs not pushed by then fail with ctx's error. Queued pushes in async
+// mode don't take ctx, they outlive the call.
+func (r *PushRouter) AddContext(ctx context.Context) error {
+
+	return r.each(ctx, false)
+}
+
+// PushContext is Push, giving up when ctx is done, see AddContext.
+func (r *PushRouter) PushContext(ctx context.Context) error {
+
+	return r.each(ctx, true)
+}
+
+// Delete deletes the groups of every job from the gateway, see push.Pusher.Delete.
+func (r *PushRouter) Delete() error {
+
+	_, err := r.delete(func(*jobPusher) bool { return true })
+
+	return err
+}
+
+// Cleanup deletes the groups of every job after wait, time for Prometheus to scrape the
+// last push, so a one-shot job's last values don't linger on the gateway forever. The
+// periodic and queued pushes are stopped and flushed first, they'd recreate the groups.
+func (r *PushRouter) Cleanup(wait time.Duration) error {
+
+	if r.periodic != nil {
+		r.periodic.close()
+	}
+	if r.queue != nil {
+		r.queue.flush()
+	}
+
+	time.Sleep(wait)
+
+	return r.Delete()
+}
+
+// delete deletes the groups of the jobs match() says yes to, returning how many.
+// All of them are attempted even if one fails.
+func (r *PushRouter) delete(match func(*jobPusher) bool) (int, error) {
+
+	if r.disabled {
+		return 0, nil
+	}
+
+	var failed []string
+	n := 0
+	for _, jp := range r.jobs {
+		if !match(jp) {
+			continue
+
+		}
+		n++
+
+		jp.mu.Lock()
+		err := r.deleteGroup(jp)
+		jp.last = 0 // the next push isn't a duplicate
+		jp.mu.Unlock()
+
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("job %s: %v", jp.name, err))
+
+		}
+	}
+
+	if len(failed) > 0 {
+		return n, fmt.Errorf("%s", strings.Join(failed, "; "))
+
+	}
+
+	return n, nil
+}
+
+// Flush waits for the queued pushes to be sent, a no-op unless async. In periodic
+// mode it pushes first.
+func (r *PushRouter) Flush() {
+
+	if r.periodic != nil {
+		r.periodic.flush()
+	}
+	if r.queue != nil {
+		r.queue.flush()
+	}
+}
+
+// Close stops the periodic pushes, flushes the queue and stops the push worker.
+func (r *PushRouter) Close() {
+
+	if r.periodic != nil {
+		r.periodic.close()
+	}
+	if r.queue != nil {
+		r.queue.close()
+	}
+}
+
+func (r *PushRouter) Stats() PushStats {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+// OnPushError calls fn with the error of every failed Add/Push, periodic and queued
+// pushes included, after any retries, eg. to page someone or stop the job. fn runs on
+// the pushing goroutine, keep it short. The error has been through the redaction rules.
+func (r *PushRouter) OnPushError(fn func(error)) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onError = append(r.onError, fn)
+}
+
+// pushFailed calls the OnPushError hooks with err.
+func (r *PushRouter) pushFailed(err error) {
+
+	r.mu.Lock()
+	hooks := r.onError
+	r.mu.Unlock()
+
+	err = RedactErr(err)
+	for _, fn := range hooks {
+		fn(err)
+	}
+}
+
+// pushRequest is one Add/Push, the families of every job gathered at the same time.
+type pushRequest struct {
+	seq     uint64 // on-disk queue sequence, 0 when not on disk
+	replace bool
+	mfs     [][]*dto.MetricFamily // per job, in r.jobs order
+	errs    []error
+	skip    []bool // per job, delivered already, nil for none, see spool.go
+}
+
+func (r *PushRouter) each(ctx context.Context, replace bool) error {
+
+	if r.disabled {
+		return nil
+	}
+
+	final := r.takeFinal()
+
+	req := pushRequest{replace: replace, mfs: make([][]*dto.MetricFamily, len(r.jobs)), errs: make([]error, len(r.jobs))}
+	for i, jp := range r.jobs {
+		g := jp.gatherer
+		if final != nil {
+			g = prometheus.Gatherers{g, familyFilter{final, jp.keep}}
+		}
+		req.mfs[i], req.errs[i] = g.Gather()
+	}
+
+	if r.queue != nil {
+		return r.queue.enqueue(req)
+
+	}
+
+	return r.deliver(ctx, req)
+}
+
+// deliverQueued delivers a push taken off the async queue.
+func (r *PushRouter) deliverQueued(req pushRequest) error {
+
+	return r.deliver(context.Background(), req)
+}
+
+func (r *PushRouter) deliver(ctx context.Context, req pushRequest) error {
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	wait := r.mirror(req)
+	defer wait()
+
+	if r.offline != nil && r.offline.skip() {
+		return r.deliverOffline(req)
+
+	}
+
+	// the spooled pushes first, the gateway still down the push joins them, see spool.go
+	var err error
+	if r.spool != nil {
+		err = r.spool.replay(func(spooled pushRequest) ([]bool, error) { return r.sendJobs(ctx, spooled) })
+	}
+	var delivered []bool
+	if err == nil {
+		delivered, err = r.sendJobs(ctx, req)
+	}
+	if err != nil && r.spool != nil {
+		spooled := req
+		spooled.skip = delivered // only the jobs that failed
+		r.spool.add(spooled)
+	}
+
+	if r.offline != nil && r.offline.result(err) {
+		return r.deliverOffline(req)
+
+	}
+
+	if err != nil {
+		r.pushFailed(err)
+	}
+
+	return err
+}
+
+// sendJobs pushes the families of every job in req, other than the ones it skips,
+// counting the attempts. It returns which jobs are delivered, the skipped ones included.
+func (r *PushRouter) sendJobs(ctx context.Context, req pushRequest) ([]bool, error) {
+
+	delivered := make([]bool, len(r.jobs))
+	var failed []string
+	attempts, degraded := 0, 0
+	for i, jp := range r.jobs {
+		if req.skip != nil && req.skip[i] {
+			delivered[i] = true
+			continue
+
+		}
+
+		attempts++
+		err := req.errs[i]
+		if err == nil {
+			var d bool
+			if d, err = r.send(ctx, jp, req.mfs[i], req.replace); d {
+				degraded++
+			}
+			r.countPath(pathPushgateway, err)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("job %s: %v", jp.name, err))
+
+		} else {
+			delivered[i] = true
+
+		}
+	}
+
+	var err error
+	if len(failed) > 0 {
+		err = fmt.Errorf("%s", strings.Join(failed, "; "))
+
+	}
+
+	r.mu.Lock()
+	r.stats.Attempts += attempts
+	r.stats.Failures += len(failed)
+	r.stats.Degraded += degraded
+	r.stats.LastFailed = err != nil
+	r.stats.LastErr = err
+	r.mu.Unlock()
+
+	return delivered, err
+}
+
+// deliverOffline writes req to the offline textfile. It still counts as a failed
+// push, the gateway didn't get it.
+func (r *PushRouter) deliverOffline(req pushRequest) error {
+
+	err := fmt.Errorf("pushgateway offline, written to %s", r.offline.path)
+	if werr := r.offline.write(req); werr != nil {
+		err = fmt.Errorf("pushgateway offline, writing %s: %w", r.offline.path, werr)
+
+	}
+
+	r.mu.Lock()
+	r.stats.Offline++
+	r.stats.LastFailed = true
+	r.stats.LastErr = err
+	r.mu.Unlock()
+
+	r.pushFailed(err)
+
+	return err
+}
+
+// send pushes one job's families, unless identical to what we pushed less than dedupWindow ago,
+// the demo used to flush twice per loop with nothing in between. Over max_push_bytes or when
+// the push fails, after any retries, it degrades to the higher priority families, see priority.go.
+func (r *PushRouter) send(ctx context.Context, jp *jobPusher, mfs []*dto.MetricFamily, replace bool) (degraded bool, err error) {
+
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+
+	if r.legacy {
+		mfs = withoutTimestamps(mfs)
+	}
+
+	sum := hashFamilies(mfs)
+	if r.dedupWindow > 0 && sum == jp.last && time.Since(jp.lastAt) < r.dedupWindow {
+		r.duplicates.WithLabelValues(jp.name).Inc()
+		return false, nil
+
+	}
+
+	payload, reason := mfs, ""
+	if r.maxPushBytes > 0 && pushSize(payload) > r.maxPushBytes {
+		reason = degradedSize
+		payload = r.priorities.only(mfs, tierCritical, tierNormal)
+		if pushSize(payload) > r.maxPushBytes {
+			payload = r.priorities.only(mfs, tierCritical)
+		}
+	}
+
+	err = r.retry.do(ctx, jp.name, func() error {
+		return r.push(ctx, jp, withDegraded(payload, jp.name, reason != ""), replace && reason == "")
+	})
+	if err != nil && reason == "" && ctx.Err() == nil {
+		critical := r.priorities.only(mfs, tierCritical)
+		if len(critical) > 0 {
+			Log.Warn("Push failed, retrying with critical metrics only", "job", jp.name, "err", RedactErr(err))
+			if r.push(ctx, jp, withDegraded(critical, jp.name, true), false) == nil {
+				reason, err = degradedPushFailure, nil
+
+			}
+		}
+	}
+
+	if err != nil {
+		return false, err
+
+	}
+
+	if reason == "" {
+		jp.last, jp.lastAt = sum, time.Now()
+		r.degraded.WithLabelValues(jp.name).Set(0)
+		return false, nil
+
+	}
+
+	// what went out isn't mfs, the next identical full push mustn't be deduped
+	jp.last = 0
+	r.degraded.WithLabelValues(jp.name).Set(1)
+	r.degradedTotal.WithLabelValues(jp.name, reason).Inc()
+
+	return true, nil
+}
+
+func hashFamilies(mfs []*dto.MetricFamily) uint64 {
+
+	h := fnv.New64a()
+	opts := proto.MarshalOptions{Deterministic: true}
+	for _, mf := range mfs {
+		b, err := opts.Marshal(mf)
+		if err != nil {
+			return 0 // never matches a real push
+
+		}
+		h.Write(b)
+	}
+
+	return h.Sum64()
+}
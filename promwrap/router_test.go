@@ -0,0 +1,68 @@
+/*****************************************************************************
+*
+*	File			: router_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Tests of the push router, dedup_window after a degraded push.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestDedupAfterDegradedPush checks a full push identical to one that went out
+// degraded isn't taken for a duplicate, the gateway only has the degraded one.
+func TestDedupAfterDegradedPush(t *testing.T) {
+
+	g := &spoolGateway{down: make(map[string]bool), pushes: make(map[string]int)}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "a_total", Help: "a_total"}))
+
+	// the router's own metrics registered apart, they'd change every push
+	r, err := newPushRouter(PushgatewayConfig{URL: srv.URL, Job: "a", DedupWindow: time.Minute, MaxPushBytes: 1}, prometheus.NewRegistry(), reg)
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	defer r.Close()
+
+	if err := r.Add(); err != nil {
+		t.Fatal(err)
+
+	}
+
+	// room for the full push now, same metrics
+	r.maxPushBytes = 0
+	if err := r.Add(); err != nil {
+		t.Fatal(err)
+
+	}
+	if err := r.Add(); err != nil {
+		t.Fatal(err)
+
+	}
+
+	// the degraded push and the full one, the third is the duplicate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pushes["a"] != 2 {
+		t.Errorf("pushes %d, want 2", g.pushes["a"])
+	}
+}
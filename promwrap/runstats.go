@@ -0,0 +1,183 @@
+/*****************************************************************************
+*
+*	File			: runstats.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Min/avg/max of a run's observations, for the run_stats histograms. A
+*					: job that's done in 3 seconds is pushed once or twice, Prometheus never
+*					: sees enough of its histograms for histogram_quantile or a rate, so we
+*					: push the plain numbers alongside,
+*
+*					:   fs_etl_operations_seconds -> fs_etl_operations_run_min_seconds
+*					:                                fs_etl_operations_run_avg_seconds
+*					:                                fs_etl_operations_run_max_seconds
+*
+*					: with the histogram's labels. Computed from the observations made
+*					: through Observe, reset per batch by ResetRunStats when it starts again.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// runStats is an unchecked collector, the label names differ per histogram.
+type runStats struct {
+	tracked map[string]bool // histogram names
+
+	mu     sync.Mutex
+	names  map[prometheus.Collector]string // "" for histograms not tracked
+	series map[string]*runSeries           // name + label values
+}
+
+type runSeries struct {
+	name     string
+	labels   []*dto.LabelPair
+	batch    string
+	min, max float64
+	sum      float64
+	count    int
+}
+
+// TrackRunStats exports the min/avg/max per run of the named histograms.
+func (m *Metrics) TrackRunStats(names ...string) {
+
+	s := &runStats{
+		tracked: make(map[string]bool, len(names)),
+		names:   make(map[prometheus.Collector]string),
+		series:  make(map[string]*runSeries),
+	}
+	for _, name := range names {
+		s.tracked[name] = true
+	}
+
+	m.reg.MustRegister(s)
+
+	m.mu.Lock()
+	m.runStats = s
+	m.mu.Unlock()
+}
+
+// ResetRunStats forgets the observations of batch, when it starts again.
+func (m *Metrics) ResetRunStats(batch string) {
+
+	m.mu.Lock()
+	s := m.runStats
+	m.mu.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, rs := range s.series {
+		if rs.batch == batch {
+			delete(s.series, key)
+		}
+	}
+}
+
+// observeRun records v, observed on o of h, if h is tracked.
+func (m *Metrics) observeRun(h *prometheus.HistogramVec, o prometheus.Observer, v float64, lvs []string) {
+
+	m.mu.Lock()
+	s := m.runStats
+	m.mu.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	name, ok := s.names[h]
+	s.mu.Unlock()
+	if !ok {
+		name = describe(h)
+		if !s.tracked[name] {
+			name = ""
+		}
+		s.mu.Lock()
+		s.names[h] = name
+		s.mu.Unlock()
+	}
+	if name == "" {
+		return
+	}
+
+	batch := ""
+	if i := m.batchIndex(h); i >= 0 && i < len(lvs) {
+		batch = lvs[i]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := name + "\xff" + strings.Join(lvs, "\xff")
+	rs := s.series[key]
+	if rs == nil {
+		var pb dto.Metric
+		if mt, ok := o.(prometheus.Metric); !ok || mt.Write(&pb) != nil {
+			return
+
+		}
+		rs = &runSeries{name: name, labels: pb.GetLabel(), batch: batch, min: v, max: v}
+		s.series[key] = rs
+	}
+
+	if v < rs.min {
+		rs.min = v
+	}
+	if v > rs.max {
+		rs.max = v
+	}
+	rs.sum += v
+	rs.count++
+}
+
+func (s *runStats) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (s *runStats) Collect(ch chan<- prometheus.Metric) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rs := range s.series {
+		base := strings.TrimSuffix(rs.name, "_seconds")
+
+		names := make([]string, len(rs.labels))
+		values := make([]string, len(rs.labels))
+		for i, lp := range rs.labels {
+			names[i], values[i] = lp.GetName(), lp.GetValue()
+		}
+
+		for _, stat := range []struct {
+			suffix string
+			help   string
+			value  float64
+		}{
+			{"_run_min_seconds", "Smallest observation of %s in the current run.", rs.min},
+			{"_run_avg_seconds", "Average observation of %s in the current run.", rs.sum / float64(rs.count)},
+			{"_run_max_seconds", "Largest observation of %s in the current run.", rs.max},
+		} {
+			desc := prometheus.NewDesc(base+stat.suffix, fmt.Sprintf(stat.help, rs.name), names, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, stat.value, values...)
+		}
+	}
+}
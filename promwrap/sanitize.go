@@ -0,0 +1,153 @@
+/*****************************************************************************
+*
+*	File			: sanitize.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Label value sanitization. Batch names come from file names containing
+*					: spaces, slashes and unicode, these are cleaned up before being used as
+*					: label values, the original value is kept in fs_etl_label_sanitized_info.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxLabelValueLen = 128  // runes, longer values are truncated
+	maxSanitizedKept = 1000 // cap on the sanitized -> original series we keep
+)
+
+// SanitizeLabelValue maps any string onto a safe label value: valid UTF-8, letters,
+// digits and -_.: only, everything else replaced with '_', and at most maxLabelValueLen
+// runes long. It is idempotent, sanitizing a sanitized value returns it unchanged.
+func SanitizeLabelValue(v string) string {
+
+	var b strings.Builder
+	b.Grow(len(v))
+
+	n := 0
+	for _, r := range v { // invalid UTF-8 comes through as utf8.RuneError
+		if n == maxLabelValueLen {
+			break
+
+		}
+
+		switch {
+		case r == utf8.RuneError:
+			r = '_'
+
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+
+		case r == '-', r == '_', r == '.', r == ':':
+
+		default:
+			r = '_'
+
+		}
+
+		b.WriteRune(r)
+		n++
+	}
+
+	return b.String()
+}
+
+// Sanitize redacts (see redact.go) and cleans up the label values, recording the
+// original of any value that changed.
+func (m *Metrics) Sanitize(lvs []string) []string {
+
+	lvs = redactAll(lvs)
+
+	if m.RawLabels {
+		return lvs
+
+	}
+
+	var out []string
+	for i, v := range lvs {
+		s := SanitizeLabelValue(v)
+		if s == v {
+			continue
+
+		}
+
+		if out == nil {
+			out = append([]string(nil), lvs...)
+		}
+		out[i] = s
+		m.recordSanitized(s, v)
+	}
+
+	if out == nil {
+		return lvs
+
+	}
+
+	return out
+}
+
+func (m *Metrics) recordSanitized(sanitized, original string) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sanitized == nil {
+		m.sanitized = make(map[string]bool)
+	}
+
+	// by both, two originals sanitizing to the same value show up as two series
+	key := sanitized + "\xff" + original
+	if m.sanitized[key] || len(m.sanitized) >= maxSanitizedKept {
+		return
+
+	}
+	m.sanitized[key] = true
+
+	// The original still has to be valid exposition, so replace any invalid UTF-8
+	// and cap the length, it's there for humans to read.
+	original = strings.ToValidUTF8(original, "�")
+	if len(original) > 4*maxLabelValueLen {
+		original = original[:4*maxLabelValueLen]
+		original = strings.ToValidUTF8(original, "")
+
+	}
+
+	m.label_sanitized.WithLabelValues(sanitized, original).Set(1)
+}
+
+// redactAll returns lvs redacted, lvs itself if nothing changed.
+func redactAll(lvs []string) []string {
+
+	if len(redactions) == 0 {
+		return lvs
+	}
+
+	var out []string
+	for i, v := range lvs {
+		if r := Redact(v); r != v {
+			if out == nil {
+				out = append([]string(nil), lvs...)
+			}
+			out[i] = r
+		}
+	}
+
+	if out == nil {
+		return lvs
+
+	}
+
+	return out
+}
@@ -0,0 +1,123 @@
+/*****************************************************************************
+*
+*	File			: seal.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Sealing a batch's metrics once it's done, job.Seal() after the final push.
+*					: Any metric with a "batch" label takes part.
+*					: Later updates of any metric with a batch label for that batch, or
+*					: job.Update() calls, are rejected and counted in
+*					: fs_etl_late_observations_total{batch}. These are stray goroutines still
+*					: writing after the numbers have been reported, the updates would only
+*					: show up in the next run's push.
+*
+*					: Starting the batch again unseals it.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const BatchLabel = "batch"
+
+// SetSealed seals or unseals batch, job.Seal() and starting the batch again.
+func (m *Metrics) SetSealed(batch string, sealed bool) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sealed == nil {
+		m.sealed = make(map[string]bool)
+	}
+	m.sealed[batch] = sealed
+}
+
+// IsSealed reports whether updates for batch are rejected.
+func (m *Metrics) IsSealed(batch string) bool {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sealed[batch]
+}
+
+// Late counts a rejected update for batch and returns the error to report.
+func (m *Metrics) Late(batch, what string) error {
+
+	// not through Inc, the counter has a batch label itself
+	m.late_observations.WithLabelValues(batch).Inc()
+
+	return fmt.Errorf("%s: update after batch %s was sealed", what, batch)
+}
+
+// checkSealed rejects an update of c for the given label values if its batch is sealed.
+func (m *Metrics) checkSealed(c prometheus.Collector, lvs []string) error {
+
+	i := m.batchIndex(c)
+	if i < 0 || i >= len(lvs) || !m.IsSealed(lvs[i]) {
+		return nil
+
+	}
+
+	return m.Late(lvs[i], describe(c))
+}
+
+// batchIndex returns the position of the batch label of c, -1 if it has none.
+// Desc has no accessor for its labels, so like describe() we pick them out of
+// Desc.String(), once per collector.
+func (m *Metrics) batchIndex(c prometheus.Collector) int {
+
+	m.mu.Lock()
+	i, ok := m.batchIdx[c]
+	m.mu.Unlock()
+	if ok {
+		return i
+
+	}
+
+	ch := make(chan *prometheus.Desc, 1)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+
+	i = -1
+	for d := range ch {
+		desc := d.String()
+		start := strings.LastIndex(desc, "variableLabels: {")
+		if i >= 0 || start < 0 {
+			continue
+
+		}
+		labels := strings.TrimSuffix(desc[start+len("variableLabels: {"):], "}}")
+		for n, l := range strings.Split(labels, ",") {
+			if l == BatchLabel || l == "c("+BatchLabel+")" {
+				i = n
+				break
+
+			}
+		}
+	}
+
+	m.mu.Lock()
+	if m.batchIdx == nil {
+		m.batchIdx = make(map[prometheus.Collector]int)
+	}
+	m.batchIdx[c] = i
+	m.mu.Unlock()
+
+	return i
+}
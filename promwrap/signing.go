@@ -0,0 +1,115 @@
+/*****************************************************************************
+*
+*	File			: signing.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Optional HMAC-SHA256 signing of pushes, pushgateway.signing, for the
+*					: regulated environments where the metrics feed compliance reporting. A
+*					: sidecar/proxy in front of the gateway verifies the signature and rejects
+*					: anything that was tampered with on the way.
+*
+*					: Every push carries X-Promwrap-Timestamp (unix seconds) and the signature
+*					: header, "sha256=" and the hex HMAC of
+*
+*					:   method \n path \n timestamp \n body
+*
+*					: with the key read from signing.key_file, so the key stays out of the
+*					: config. The timestamp lets the verifier reject replays.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+	defaultSignatureHeader = "X-Promwrap-Signature"
+	signatureTimestamp     = "X-Promwrap-Timestamp"
+)
+
+type SigningConfig struct {
+	KeyFile string `yaml:"key_file"` // empty disables signing
+	Header  string `yaml:"header"`   // default X-Promwrap-Signature
+}
+
+// signingClient signs every request before handing it to client.
+type signingClient struct {
+	client push.HTTPDoer
+	key    []byte
+	header string
+}
+
+// newSigningClient returns nil when signing isn't configured.
+func newSigningClient(c SigningConfig, client push.HTTPDoer) (*signingClient, error) {
+
+	if c.KeyFile == "" {
+		return nil, nil
+	}
+
+	key, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("pushgateway signing key: %w", err)
+
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("pushgateway signing key %s is empty", c.KeyFile)
+
+	}
+
+	s := &signingClient{client: client, key: key, header: c.Header}
+	if s.header == "" {
+		s.header = defaultSignatureHeader
+	}
+
+	return s, nil
+}
+
+func (s *signingClient) Do(req *http.Request) (*http.Response, error) {
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(signatureTimestamp, ts)
+	req.Header.Set(s.header, "sha256="+s.sign(req.Method, req.URL.EscapedPath(), ts, body))
+
+	return s.client.Do(req)
+}
+
+func (s *signingClient) sign(method, path, ts string, body []byte) string {
+
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", method, path, ts)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,193 @@
+/*****************************************************************************
+*
+*	File			: spool.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Spool of failed pushes, pushgateway.spool_dir. A push that failed, after
+*					: any retries, is written to the spool, and replayed, oldest first, ahead
+*					: of the next push that finds the gateway back, this run's or the next
+*					: run's. So a batch that's done in minutes doesn't lose its only push to a
+*					: 30 second gateway blip, the next run delivers it.
+*
+*					: Only the jobs a push failed for are spooled, the ones delivered would
+*					: otherwise be pushed twice, with add double counting. Replaying stops at
+*					: the first push that fails again, keeping what of it was delivered by
+*					: then, a push made while the spool isn't empty joins the end of it. At
+*					: most spool_size (default 100) pushes are kept, the oldest dropped. Files
+*					: are in the on-disk queue's format, see pushdisk.go, pushes for a
+*					: different routing are dropped.
+*
+*					: fs_etl_push_spooled is the number of pushes waiting,
+*					: fs_etl_push_spool_replayed_total and fs_etl_push_spool_dropped_total
+*					: count the ones delivered late and the ones given up on.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultSpoolSize = 100
+
+type spool struct {
+	dir  string
+	size int
+	jobs []string // job names, in r.jobs order
+
+	spooled  prometheus.Gauge
+	replayed prometheus.Counter
+	dropped  prometheus.Counter
+
+	mu   sync.Mutex
+	seqs []uint64 // spooled, oldest first
+}
+
+// newSpool returns nil unless spool_dir is set, picking up what a previous run left.
+func newSpool(c PushgatewayConfig, jobs []string, reg prometheus.Registerer) (*spool, error) {
+
+	if c.SpoolDir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(c.SpoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("spool_dir: %w", err)
+
+	}
+
+	s := &spool{dir: c.SpoolDir, size: c.SpoolSize, jobs: jobs}
+	if s.size <= 0 {
+		s.size = defaultSpoolSize
+	}
+
+	c2, err := RegisterOrExisting(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "fs_etl_push_spooled",
+		Help: "The number of failed pushes in the spool, waiting to be replayed.",
+	}))
+	if err != nil {
+		return nil, err
+
+	}
+	s.spooled = c2.(prometheus.Gauge)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "fs_etl_push_spool_replayed_total",
+		Help: "The number of spooled pushes delivered once the pushgateway was back.",
+	})); err != nil {
+		return nil, err
+
+	}
+	s.replayed = c2.(prometheus.Counter)
+
+	if c2, err = RegisterOrExisting(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "fs_etl_push_spool_dropped_total",
+		Help: "The number of spooled pushes dropped, the spool full or the push unreadable.",
+	})); err != nil {
+		return nil, err
+
+	}
+	s.dropped = c2.(prometheus.Counter)
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "spool-*.push"))
+	if err != nil {
+		return nil, err
+
+	}
+	for _, path := range files {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "spool-"), ".push"), 10, 64)
+		if err != nil {
+			continue
+
+		}
+		s.seqs = append(s.seqs, seq)
+	}
+	sort.Slice(s.seqs, func(i, j int) bool { return s.seqs[i] < s.seqs[j] })
+	s.spooled.Set(float64(len(s.seqs)))
+
+	if len(s.seqs) > 0 {
+		Log.Info("Spooled pushes to replay", "pushes", len(s.seqs), "dir", s.dir)
+	}
+
+	return s, nil
+}
+
+func (s *spool) path(seq uint64) string {
+
+	return filepath.Join(s.dir, fmt.Sprintf("spool-%020d.push", seq))
+}
+
+// add spools req, dropping the oldest push when full.
+func (s *spool) add(req pushRequest) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq := uint64(1)
+	if len(s.seqs) > 0 {
+		seq = s.seqs[len(s.seqs)-1] + 1
+	}
+	req.seq = seq
+	req.errs = make([]error, len(req.errs)) // gather errors were reported with the push, replay what was gathered
+	if err := writePush(s.path(seq), &req, s.jobs); err != nil {
+		Log.Error("Could not spool push", "err", err)
+		return
+
+	}
+	s.seqs = append(s.seqs, seq)
+
+	for len(s.seqs) > s.size {
+		os.Remove(s.path(s.seqs[0]))
+		s.seqs = s.seqs[1:]
+		s.dropped.Inc()
+	}
+	s.spooled.Set(float64(len(s.seqs)))
+}
+
+// replay delivers the spooled pushes oldest first, stopping at the first that fails.
+// deliver returns which of a push's jobs are delivered, see sendJobs.
+func (s *spool) replay(deliver func(pushRequest) ([]bool, error)) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.seqs) > 0 {
+		path := s.path(s.seqs[0])
+		req, err := readPush(path, s.jobs)
+		if err != nil {
+			Log.Warn("Dropping spooled push", "path", path, "err", err)
+			s.dropped.Inc()
+
+		} else if delivered, err := deliver(req); err != nil {
+			req.skip = delivered
+			if werr := writePush(path, &req, s.jobs); werr != nil {
+				Log.Error("Could not update spooled push", "path", path, "err", werr)
+			}
+			return err
+
+		} else {
+			s.replayed.Inc()
+
+		}
+
+		os.Remove(path)
+		s.seqs = s.seqs[1:]
+		s.spooled.Set(float64(len(s.seqs)))
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+/*****************************************************************************
+*
+*	File			: spool_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Tests of the spool of failed pushes, replay order, replay by the next
+*					: run and pushes that failed for some of their jobs only.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// spoolPush returns a push for a single job of one counter, valued v.
+func spoolPush(v float64) pushRequest {
+
+	mf := &dto.MetricFamily{
+		Name:   proto.String("spool_test_total"),
+		Type:   dto.MetricType_COUNTER.Enum(),
+		Metric: []*dto.Metric{{Counter: &dto.Counter{Value: proto.Float64(v)}}},
+	}
+
+	return pushRequest{mfs: [][]*dto.MetricFamily{{mf}}, errs: make([]error, 1)}
+}
+
+// replayed replays s, returning the counter values delivered in order.
+func replayed(t *testing.T, s *spool) []float64 {
+
+	t.Helper()
+
+	var got []float64
+	err := s.replay(func(req pushRequest) ([]bool, error) {
+		got = append(got, req.mfs[0][0].GetMetric()[0].GetCounter().GetValue())
+		return []bool{true}, nil
+	})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+
+	}
+
+	return got
+}
+
+func TestSpoolReplayOrder(t *testing.T) {
+
+	s, err := newSpool(PushgatewayConfig{SpoolDir: t.TempDir(), SpoolSize: 3}, []string{"a"}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+
+	}
+
+	for v := 1; v <= 4; v++ {
+		s.add(spoolPush(float64(v)))
+	}
+
+	// spool_size 3, the oldest is dropped
+	if got := replayed(t, s); len(got) != 3 || got[0] != 2 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("replayed %v, want [2 3 4]", got)
+	}
+	if got := replayed(t, s); len(got) != 0 {
+		t.Errorf("replayed %v again", got)
+	}
+}
+
+func TestSpoolReplayStopsAtFailure(t *testing.T) {
+
+	s, err := newSpool(PushgatewayConfig{SpoolDir: t.TempDir()}, []string{"a"}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+
+	}
+
+	s.add(spoolPush(1))
+	s.add(spoolPush(2))
+
+	calls := 0
+	err = s.replay(func(req pushRequest) ([]bool, error) {
+		calls++
+		return []bool{false}, errors.New("gateway down")
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("replay err %v after %d pushes, want the first failure", err, calls)
+
+	}
+
+	if got := replayed(t, s); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("replayed %v, want [1 2]", got)
+	}
+}
+
+func TestSpoolReplayAfterRestart(t *testing.T) {
+
+	dir := t.TempDir()
+	s, err := newSpool(PushgatewayConfig{SpoolDir: dir}, []string{"a"}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	s.add(spoolPush(1))
+	s.add(spoolPush(2))
+
+	// the next run
+	s, err = newSpool(PushgatewayConfig{SpoolDir: dir}, []string{"a"}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	if got := replayed(t, s); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("replayed %v, want [1 2]", got)
+	}
+
+	// routed differently by now, dropped
+	s.add(spoolPush(3))
+	s, err = newSpool(PushgatewayConfig{SpoolDir: dir}, []string{"a", "b"}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	if err := s.replay(func(req pushRequest) ([]bool, error) {
+		t.Errorf("replayed a push of another routing")
+		return []bool{true, true}, nil
+	}); err != nil {
+		t.Fatal(err)
+
+	}
+}
+
+// spoolGateway counts the pushes per job, failing the ones to the jobs in down.
+type spoolGateway struct {
+	mu     sync.Mutex
+	down   map[string]bool
+	pushes map[string]int
+}
+
+func (g *spoolGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method == http.MethodGet {
+		return
+	}
+
+	job := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/metrics/job/"), "/", 2)[0]
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.down[job] {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+
+	}
+	g.pushes[job]++
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestSpoolPartialFailure(t *testing.T) {
+
+	g := &spoolGateway{down: map[string]bool{"b": true}, pushes: make(map[string]int)}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	for _, name := range []string{"a_total", "b_total"} {
+		reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: name, Help: name}))
+	}
+
+	r, err := NewPushRouter(PushgatewayConfig{URL: srv.URL, Job: "a", Jobs: map[string][]string{"b": {"b_total"}}, SpoolDir: t.TempDir()}, reg)
+	if err != nil {
+		t.Fatal(err)
+
+	}
+	defer r.Close()
+
+	if err := r.Add(); err == nil {
+		t.Fatal("push with job b down succeeded")
+
+	}
+
+	g.mu.Lock()
+	g.down["b"] = false
+	g.mu.Unlock()
+
+	if err := r.Add(); err != nil {
+		t.Fatalf("push with the gateway back: %v", err)
+
+	}
+
+	// a: the first push and the second, b: the spooled push and the second
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pushes["a"] != 2 || g.pushes["b"] != 2 {
+		t.Errorf("pushes a %d, b %d, want 2 each", g.pushes["a"], g.pushes["b"])
+	}
+}
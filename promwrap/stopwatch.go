@@ -0,0 +1,104 @@
+/*****************************************************************************
+*
+*	File			: stopwatch.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: A stopwatch that can be paused during known waits, eg. rate limiter
+*					: sleeps, so the time spent working and the time spent being throttled
+*					: can be recorded separately,
+*
+*					:   sw := promwrap.StartStopwatch()
+*					:   ... work ...
+*					:   sw.Sleep(throttle)           // or sw.Pause() ... sw.Resume()
+*					:   m.Observe(m.rec_duration, sw.Elapsed(), batch)
+*					:   m.Observe(m.rec_wait, sw.Paused(), batch)
+*
+*					: Measured on the monotonic clock, like time.Since.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync"
+	"time"
+)
+
+type Stopwatch struct {
+	mu       sync.Mutex
+	start    time.Time
+	pausedAt time.Time // zero while running
+	paused   time.Duration
+}
+
+func StartStopwatch() *Stopwatch {
+
+	return &Stopwatch{start: time.Now()}
+}
+
+// Pause stops the clock, pausing a paused stopwatch does nothing.
+func (s *Stopwatch) Pause() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pausedAt.IsZero() {
+		s.pausedAt = time.Now()
+	}
+}
+
+// Resume restarts the clock, resuming a running stopwatch does nothing.
+func (s *Stopwatch) Resume() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.pausedAt.IsZero() {
+		s.paused += time.Since(s.pausedAt)
+		s.pausedAt = time.Time{}
+	}
+}
+
+// Sleep sleeps for d with the stopwatch paused.
+func (s *Stopwatch) Sleep(d time.Duration) {
+
+	s.Pause()
+	defer s.Resume()
+
+	time.Sleep(d)
+}
+
+// Elapsed is the time since the start, excluding the pauses.
+func (s *Stopwatch) Elapsed() time.Duration {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Since(s.start) - s.pausedLocked()
+}
+
+// Paused is the time spent paused, including a pause still in progress.
+func (s *Stopwatch) Paused() time.Duration {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pausedLocked()
+}
+
+func (s *Stopwatch) pausedLocked() time.Duration {
+
+	if s.pausedAt.IsZero() {
+		return s.paused
+
+	}
+
+	return s.paused + time.Since(s.pausedAt)
+}
@@ -0,0 +1,366 @@
+/*****************************************************************************
+*
+*	File			: strict.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Checked metric updates. Invalid label counts, negative counter adds
+*					: and updates to unregistered metrics are reported, and in strict mode
+*					: panic immediately with the caller, instead of silently misbehaving.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Register registers the collectors and remembers them, so that updates to
+// unregistered metrics can be caught. In dev builds the collectors may get a
+// caller label, see callers.go.
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+
+	for _, c := range cs {
+		r := callerRegisterer(m.reg, c, 1)
+		r.MustRegister(c)
+
+		m.mu.Lock()
+		m.registered[c] = r
+		m.mu.Unlock()
+	}
+}
+
+// Unregister undoes Register, later updates of c are reported as misuse.
+func (m *Metrics) Unregister(c prometheus.Collector) {
+
+	m.mu.Lock()
+	r, ok := m.registered[c]
+	delete(m.registered, c)
+	m.mu.Unlock()
+
+	if ok {
+		r.Unregister(c)
+	}
+}
+
+// IsRegistered reports whether c was registered through Register.
+func (m *Metrics) IsRegistered(c prometheus.Collector) bool {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.registered[c]
+
+	return ok
+}
+
+// Misuse reports err against the caller of the metrics method, in strict mode it panics.
+func (m *Metrics) Misuse(err error) error {
+
+	return m.misuseAt(2, err)
+}
+
+// misuseAt is Misuse for the helpers behind the metrics methods, skip is the number of
+// frames up to the metrics method's caller.
+func (m *Metrics) misuseAt(skip int, err error) error {
+
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		err = fmt.Errorf("%s:%d: %w", file, line, err)
+
+	}
+
+	if m.Strict {
+		panic(err)
+
+	}
+
+	Log.Error("Metric misuse", "err", RedactErr(err))
+
+	return err
+}
+
+// Check returns an error unless c was registered through Register.
+func (m *Metrics) Check(c prometheus.Collector) error {
+
+	if !m.IsRegistered(c) {
+		return fmt.Errorf("update of unregistered metric %s", describe(c))
+
+	}
+
+	return nil
+}
+
+// Observe records d against the duration histogram for the given label values.
+// Our histograms are all in seconds, the conversion happens here so callers can't
+// get the unit wrong. Measure d with time.Since, which uses the monotonic clock, a
+// negative d means wall clock times were subtracted and is rejected.
+func (m *Metrics) Observe(h *prometheus.HistogramVec, d time.Duration, lvs ...string) error {
+
+	return m.observe(h, d, nil, lvs)
+}
+
+// observe is Observe, with an exemplar unless nil, see exemplar.go.
+func (m *Metrics) observe(h *prometheus.HistogramVec, d time.Duration, exemplar prometheus.Labels, lvs []string) error {
+
+	if err := m.Check(h); err != nil {
+		return m.misuseAt(2, err)
+
+	}
+
+	if err := m.checkSealed(h, lvs); err != nil {
+		return m.misuseAt(2, err)
+
+	}
+
+	if d < 0 {
+		return m.misuseAt(2, fmt.Errorf("%s: negative duration %s", describe(h), d))
+
+	}
+
+	o, err := h.GetMetricWithLabelValues(m.Sanitize(lvs)...)
+	if err != nil {
+		return m.misuseAt(2, fmt.Errorf("%s: %w", describe(h), err))
+
+	}
+	if exemplar != nil {
+		o.(prometheus.ExemplarObserver).ObserveWithExemplar(d.Seconds(), exemplar)
+
+	} else {
+		o.Observe(d.Seconds())
+
+	}
+	m.observeRun(h, o, d.Seconds(), lvs)
+	m.observeEMA(h, o, d.Seconds(), lvs)
+	m.calibrate(h, d.Seconds())
+	m.recordAt(2, "observe", h, d.Seconds(), lvs)
+
+	return nil
+}
+
+// ObserveQuantiles records d against the duration summary for the given label values,
+// see Observe and summary.go.
+func (m *Metrics) ObserveQuantiles(s *prometheus.SummaryVec, d time.Duration, lvs ...string) error {
+
+	if err := m.Check(s); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if err := m.checkSealed(s, lvs); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if d < 0 {
+		return m.Misuse(fmt.Errorf("%s: negative duration %s", describe(s), d))
+
+	}
+
+	o, err := s.GetMetricWithLabelValues(m.Sanitize(lvs)...)
+	if err != nil {
+		return m.Misuse(fmt.Errorf("%s: %w", describe(s), err))
+
+	}
+	o.Observe(d.Seconds())
+	m.record("observe", s, d.Seconds(), lvs)
+
+	return nil
+}
+
+// ObserveSummary records d against a plain (label less) duration summary.
+func (m *Metrics) ObserveSummary(s prometheus.Summary, d time.Duration) error {
+
+	if err := m.Check(s); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if d < 0 {
+		return m.Misuse(fmt.Errorf("%s: negative duration %s", describe(s), d))
+
+	}
+	s.Observe(d.Seconds())
+	m.record("observe", s, d.Seconds(), nil)
+
+	return nil
+}
+
+// Add adds v, which may not be negative, to the counter for the given label values.
+func (m *Metrics) Add(c *prometheus.CounterVec, v float64, lvs ...string) error {
+
+	return m.add(c, v, nil, lvs)
+}
+
+// add is Add, with an exemplar unless nil, see exemplar.go.
+func (m *Metrics) add(c *prometheus.CounterVec, v float64, exemplar prometheus.Labels, lvs []string) error {
+
+	if err := m.Check(c); err != nil {
+		return m.misuseAt(2, err)
+
+	}
+
+	if err := m.checkSealed(c, lvs); err != nil {
+		return m.misuseAt(2, err)
+
+	}
+
+	if v < 0 {
+		return m.misuseAt(2, fmt.Errorf("%s: negative counter add %g", describe(c), v))
+
+	}
+
+	ctr, err := c.GetMetricWithLabelValues(m.Sanitize(lvs)...)
+	if err != nil {
+		return m.misuseAt(2, fmt.Errorf("%s: %w", describe(c), err))
+
+	}
+	if exemplar != nil {
+		ctr.(prometheus.ExemplarAdder).AddWithExemplar(v, exemplar)
+
+	} else {
+		ctr.Add(v)
+
+	}
+	m.recordAt(2, "add", c, v, lvs)
+
+	return nil
+}
+
+// Inc increments the counter for the given label values.
+func (m *Metrics) Inc(c *prometheus.CounterVec, lvs ...string) error {
+
+	if err := m.Check(c); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if err := m.checkSealed(c, lvs); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	ctr, err := c.GetMetricWithLabelValues(m.Sanitize(lvs)...)
+	if err != nil {
+		return m.Misuse(fmt.Errorf("%s: %w", describe(c), err))
+
+	}
+	ctr.Inc()
+	m.record("inc", c, 1, lvs)
+
+	return nil
+}
+
+// Set sets the gauge for the given label values to v.
+func (m *Metrics) Set(g *prometheus.GaugeVec, v float64, lvs ...string) error {
+
+	if err := m.Check(g); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if err := m.checkSealed(g, lvs); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	gg, err := g.GetMetricWithLabelValues(m.Sanitize(lvs)...)
+	if err != nil {
+		return m.Misuse(fmt.Errorf("%s: %w", describe(g), err))
+
+	}
+	gg.Set(v)
+	m.record("set", g, v, lvs)
+
+	return nil
+}
+
+// SetGauge sets a plain (label less) gauge to v.
+func (m *Metrics) SetGauge(g prometheus.Gauge, v float64) error {
+
+	if err := m.Check(g); err != nil {
+		return m.Misuse(err)
+
+	}
+	g.Set(v)
+	m.record("set", g, v, nil)
+
+	return nil
+}
+
+// SetDuration sets a plain (label less) seconds gauge to d, see Observe.
+func (m *Metrics) SetDuration(g prometheus.Gauge, d time.Duration) error {
+
+	if err := m.Check(g); err != nil {
+		return m.Misuse(err)
+
+	}
+
+	if d < 0 {
+		return m.Misuse(fmt.Errorf("%s: negative duration %s", describe(g), d))
+
+	}
+	g.Set(d.Seconds())
+	m.record("set", g, d.Seconds(), nil)
+
+	return nil
+}
+
+// SetToCurrentTime sets a plain gauge to the current unix time in seconds.
+func (m *Metrics) SetToCurrentTime(g prometheus.Gauge) error {
+
+	if err := m.Check(g); err != nil {
+		return m.Misuse(err)
+
+	}
+	now := float64(time.Now().UnixNano()) / 1e9
+	g.Set(now)
+	m.record("set", g, now, nil)
+
+	return nil
+}
+
+// describe returns the metric name of c for error messages, the name of its first Desc.
+func describe(c prometheus.Collector) string {
+
+	ch := make(chan *prometheus.Desc, 1)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+
+	var name string
+	for d := range ch {
+		if name != "" {
+			continue
+
+		}
+		name = descName(d)
+	}
+
+	return name
+}
+
+// descName returns the fully qualified name of d, Desc has no accessor for the name
+// so we pick it out of Desc.String().
+func descName(d *prometheus.Desc) string {
+
+	name := d.String()
+	if _, rest, ok := strings.Cut(name, `fqName: "`); ok {
+		name, _, _ = strings.Cut(rest, `"`)
+
+	}
+
+	return name
+}
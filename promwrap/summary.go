@@ -0,0 +1,60 @@
+/*****************************************************************************
+*
+*	File			: summary.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Summaries, client side quantiles, eg. the p50/p90/p99 of the SQL and API
+*					: durations, next to or instead of the histograms. The objectives map each
+*					: quantile to its allowed error, 0.99: 0.001 is the 99th percentile give
+*					: or take 0.1%. A summary has no objectives unless given some, ours default
+*					: to DefaultObjectives.
+*
+*					: Quantiles can't be aggregated across series or jobs, use a histogram
+*					: where that matters. Observed through m.ObserveQuantiles/ObserveSummary.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultObjectives are the p50, p90 and p99.
+var DefaultObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+// ValidateObjectives checks every quantile and its error are between 0 and 1.
+func ValidateObjectives(objectives map[float64]float64) error {
+
+	for q, e := range objectives {
+		if q <= 0 || q >= 1 {
+			return fmt.Errorf("objective quantile %g, expected between 0 and 1", q)
+
+		}
+		if e <= 0 || e >= 1 {
+			return fmt.Errorf("objective %g error %g, expected between 0 and 1", q, e)
+
+		}
+	}
+
+	return nil
+}
+
+// NewSummaryVec is prometheus.NewSummaryVec with DefaultObjectives when opts has none.
+func NewSummaryVec(opts prometheus.SummaryOpts, labels []string) *prometheus.SummaryVec {
+
+	if len(opts.Objectives) == 0 {
+		opts.Objectives = DefaultObjectives
+	}
+
+	return prometheus.NewSummaryVec(opts, labels)
+}
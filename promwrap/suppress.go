@@ -0,0 +1,86 @@
+/*****************************************************************************
+*
+*	File			: suppress.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Metric family suppression, families kept out of every push, scrape,
+*					: textfile and snapshot. Still registered and updated as usual, they
+*					: just never leave the process, eg. the debug families in production.
+*
+*					: only, when set, is the complete list of families let through, drop is
+*					: applied after it.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+type SuppressConfig struct {
+	Only []string `yaml:"only"` // empty lets every family through
+	Drop []string `yaml:"drop"`
+}
+
+var suppression struct {
+	sync.RWMutex
+	only map[string]bool
+	drop map[string]bool
+}
+
+// SetSuppressed replaces the suppressed families, set from config before any gathering.
+func SetSuppressed(c SuppressConfig) {
+
+	suppression.Lock()
+	defer suppression.Unlock()
+
+	suppression.only = nil
+	if len(c.Only) > 0 {
+		suppression.only = make(map[string]bool, len(c.Only))
+		for _, name := range c.Only {
+			suppression.only[name] = true
+		}
+	}
+
+	suppression.drop = make(map[string]bool, len(c.Drop))
+	for _, name := range c.Drop {
+		suppression.drop[name] = true
+	}
+}
+
+// Suppressed is whether family name is kept out of pushes and scrapes.
+func Suppressed(name string) bool {
+
+	suppression.RLock()
+	defer suppression.RUnlock()
+
+	if suppression.only != nil && !suppression.only[name] {
+		return true
+
+	}
+
+	return suppression.drop[name]
+}
+
+// unsuppressed filters the suppressed families out of mfs, in place.
+func unsuppressed(mfs []*dto.MetricFamily) []*dto.MetricFamily {
+
+	out := mfs[:0]
+	for _, mf := range mfs {
+		if !Suppressed(mf.GetName()) {
+			out = append(out, mf)
+		}
+	}
+
+	return out
+}
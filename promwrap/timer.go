@@ -0,0 +1,70 @@
+/*****************************************************************************
+*
+*	File			: timer.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Times a call into a duration histogram, instead of juggling time.Now()
+*					: and time.Since() at every call site,
+*
+*					:   t := w.Metrics.StartTimer("fs_etl_load_seconds", "eft")
+*					:   defer t.ObserveDuration()
+*
+*					: StartTimer takes a defined histogram (see definitions.go), NewTimer
+*					: anything else that takes a duration. A timer observes once, calling
+*					: ObserveDuration again returns the duration observed the first time.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"sync"
+	"time"
+)
+
+type Timer struct {
+	start   time.Time
+	observe func(d time.Duration)
+
+	once sync.Once
+	d    time.Duration
+}
+
+// NewTimer starts a timer that hands its duration to observe.
+func NewTimer(observe func(d time.Duration)) *Timer {
+
+	return &Timer{start: time.Now(), observe: observe}
+}
+
+// StartTimer starts a timer for the defined histogram name and label values, an
+// undefined name is reported as misuse, see Histogram.
+func (m *Metrics) StartTimer(name string, lvs ...string) *Timer {
+
+	h := m.Histogram(name)
+
+	return NewTimer(func(d time.Duration) { m.Observe(h, d, lvs...) })
+}
+
+// Elapsed is the time since the start, the timer keeps running.
+func (t *Timer) Elapsed() time.Duration {
+
+	return time.Since(t.start)
+}
+
+// ObserveDuration stops the timer and observes the time since the start, once.
+func (t *Timer) ObserveDuration() time.Duration {
+
+	t.once.Do(func() {
+		t.d = time.Since(t.start)
+		t.observe(t.d)
+	})
+
+	return t.d
+}
@@ -0,0 +1,110 @@
+/*****************************************************************************
+*
+*	File			: tls.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: TLS towards the pushgateway, eg. behind an HTTPS reverse proxy with a
+*					: private CA, pushgateway.tls. ca_file adds to the trusted roots, cert_file
+*					: and key_file present a client certificate (mTLS). Used for the pushes
+*					: and the preflight probe alike, along with any basic auth.
+*
+*					: In code, promwrap.WithTLSConfig(cfg) takes a ready *tls.Config instead.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package promwrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"` // PEM bundle, on top of the system roots
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`          // when it differs from the URL's host
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // testing only
+}
+
+// build returns the *tls.Config as configured, nil when nothing is.
+func (c TLSConfig) build() (*tls.Config, error) {
+
+	if c == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("pushgateway tls ca_file: %w", err)
+
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("pushgateway tls ca_file %s: no certificates found", c.CAFile)
+
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("pushgateway tls client certificate: %w", err)
+
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// httpClient returns the client for talking to the gateway, the caller's, or
+// http.DefaultClient unless TLS is configured.
+func (c PushgatewayConfig) httpClient() (*http.Client, error) {
+
+	if c.client != nil {
+		return c.client, nil
+
+	}
+
+	tc := c.tlsConfig
+	if tc == nil {
+		var err error
+		if tc, err = c.TLS.build(); err != nil {
+			return nil, err
+
+		}
+	}
+
+	if tc == nil {
+		return http.DefaultClient, nil
+
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tc
+
+	return &http.Client{Transport: t}, nil
+}
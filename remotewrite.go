@@ -0,0 +1,206 @@
+/*****************************************************************************
+*
+*	File			: remotewrite.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Minimal Prometheus remote_write (1.0) client. The WriteRequest protobuf is
+*					: small enough that we encode it by hand rather than pull in prompb and
+*					: its dependencies.
+*
+*					: With dual_write every push is mirrored to remote_write as well, see
+*					: promwrap/mirror.go, for the migration window before the gateway is
+*					: decommissioned. The families are written as Prometheus would have
+*					: scraped them off the gateway, with the job label of the push.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type RemoteWriteConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Send every push to remote_write as well as to the gateway
+	DualWrite bool `yaml:"dual_write"`
+}
+
+type rwLabel struct {
+	Name, Value string
+}
+
+type rwSample struct {
+	Value     float64
+	Timestamp time.Time
+}
+
+type rwSeries struct {
+	Labels  []rwLabel // must include __name__
+	Samples []rwSample
+}
+
+type RemoteWriter struct {
+	url    string
+	client *http.Client
+}
+
+func NewRemoteWriter(c RemoteWriteConfig) *RemoteWriter {
+
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+
+	return &RemoteWriter{url: c.URL, client: &http.Client{Timeout: c.Timeout}}
+}
+
+// Write sends the series as one WriteRequest.
+func (rw *RemoteWriter) Write(ctx context.Context, series []rwSeries) error {
+
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rw.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := rw.client.Do(req)
+	if err != nil {
+		return err
+
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote_write %s: %s: %s", rw.url, resp.Status, bytes.TrimSpace(msg))
+
+	}
+
+	return nil
+}
+
+// WriteFamilies sends the families pushed for job, a promwrap.MirrorFunc.
+func (rw *RemoteWriter) WriteFamilies(job string, mfs []*dto.MetricFamily) error {
+
+	return rw.Write(context.Background(), familySeries(job, mfs, time.Now()))
+}
+
+// familySeries flattens mfs into series at now, histograms and summaries into their
+// _bucket/quantile, _sum and _count series.
+func familySeries(job string, mfs []*dto.MetricFamily, now time.Time) []rwSeries {
+
+	var series []rwSeries
+	for _, mf := range mfs {
+		for _, mt := range mf.GetMetric() {
+			labels := []rwLabel{{"job", job}}
+			for _, lp := range mt.GetLabel() {
+				labels = append(labels, rwLabel{lp.GetName(), lp.GetValue()})
+			}
+
+			add := func(name string, v float64, extra ...rwLabel) {
+				ls := append([]rwLabel{{"__name__", name}}, labels...)
+				series = append(series, rwSeries{Labels: append(ls, extra...), Samples: []rwSample{{v, now}}})
+			}
+
+			name := mf.GetName()
+			switch {
+			case mt.Counter != nil:
+				add(name, mt.GetCounter().GetValue())
+
+			case mt.Gauge != nil:
+				add(name, mt.GetGauge().GetValue())
+
+			case mt.Untyped != nil:
+				add(name, mt.GetUntyped().GetValue())
+
+			case mt.Histogram != nil:
+				h := mt.GetHistogram()
+				for _, b := range h.GetBucket() {
+					add(name+"_bucket", float64(b.GetCumulativeCount()), rwLabel{"le", fmt.Sprint(b.GetUpperBound())})
+				}
+				add(name+"_bucket", float64(h.GetSampleCount()), rwLabel{"le", "+Inf"})
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+
+			case mt.Summary != nil:
+				sm := mt.GetSummary()
+				for _, q := range sm.GetQuantile() {
+					add(name, q.GetValue(), rwLabel{"quantile", fmt.Sprint(q.GetQuantile())})
+				}
+				add(name+"_sum", sm.GetSampleSum())
+				add(name+"_count", float64(sm.GetSampleCount()))
+
+			}
+		}
+	}
+
+	return series
+}
+
+// encodeWriteRequest encodes prometheus.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; } // ms
+func encodeWriteRequest(series []rwSeries) []byte {
+
+	var out []byte
+	for _, s := range series {
+		labels := append([]rwLabel(nil), s.Labels...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+		var ts []byte
+		for _, l := range labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.Value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+
+		for _, smp := range s.Samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.Value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.Timestamp.UnixMilli()))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, ts)
+	}
+
+	return out
+}
@@ -0,0 +1,143 @@
+/*****************************************************************************
+*
+*	File			: replica.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Read replica routing. A named database can point at another one as its
+*					: replica, dbs.Reader() then hands out the replica for read queries as
+*					: long as its replication lag stays within max_replica_lag, falling back
+*					: to the primary otherwise.
+*
+*					: The lag is measured on the replica, at most every replicaLagTTL, and
+*					: exported as fs_etl_db_replica_lag_seconds{db}. Routing decisions are
+*					: counted in fs_etl_db_read_routes_total{db,route}.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"myapp/fsetl"
+)
+
+// Routing decisions, the "route" label
+const (
+	routeReplica            = "replica"
+	routePrimaryLag         = "primary_lag"         // replica too far behind
+	routePrimaryUnavailable = "primary_unavailable" // couldn't measure the replica's lag
+)
+
+const replicaLagTTL = 5 * time.Second
+
+type replicaRoute struct {
+	replica string
+	maxLag  time.Duration
+
+	mu        sync.Mutex
+	lag       time.Duration
+	err       error
+	checkedAt time.Time
+}
+
+// replicaLag returns the replica's replay lag, 0 when it has replayed all it received,
+// so an idle primary doesn't make the replica look behind.
+func replicaLag(ctx context.Context, replica *fsetl.DB) (time.Duration, error) {
+
+	var secs float64
+	err := replica.DB.QueryRowContext(ctx, `SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&secs)
+
+	return time.Duration(secs * float64(time.Second)), err
+}
+
+// lagOf returns the cached lag of the route's replica, measuring it when stale.
+func (r *replicaRoute) lagOf(ctx context.Context, name string, replica *fsetl.DB) (time.Duration, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < replicaLagTTL {
+		return r.lag, r.err
+
+	}
+
+	r.lag, r.err = replicaLag(ctx, replica)
+	r.checkedAt = time.Now()
+	if r.err == nil {
+		m.Set(m.ReplicaLag, r.lag.Seconds(), name)
+	}
+
+	return r.lag, r.err
+}
+
+// checkReplicas verifies every configured replica is one of the named databases.
+func (d *Databases) checkReplicas() error {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, r := range d.routes {
+		if _, ok := d.dbs[r.replica]; !ok || r.replica == name {
+			return fmt.Errorf("database %s: replica %s is not one of the other databases", name, r.replica)
+
+		}
+	}
+
+	return nil
+}
+
+// Reader returns the handle to use for read queries against the named database, its
+// replica when it has one that's not lagging too far behind, the database itself otherwise.
+func (d *Databases) Reader(ctx context.Context, name string) (*fsetl.DB, error) {
+
+	primary, err := d.Get(name)
+	if err != nil {
+		return nil, err
+
+	}
+
+	d.mu.Lock()
+	r := d.routes[name]
+	d.mu.Unlock()
+	if r == nil {
+		return primary, nil
+	}
+
+	replica, err := d.Get(r.replica)
+	if err != nil {
+		return nil, fmt.Errorf("database %s replica: %w", name, err)
+
+	}
+
+	lag, err := r.lagOf(ctx, r.replica, replica)
+	switch {
+	case err != nil:
+		reportFailure(fmt.Sprintf("Could not measure replication lag of %s, reading from %s:", r.replica, name), err)
+		m.Inc(m.ReadRoutes, name, routePrimaryUnavailable)
+		return primary, nil
+
+	case lag > r.maxLag:
+		m.Inc(m.ReadRoutes, name, routePrimaryLag)
+		return primary, nil
+
+	}
+
+	m.Inc(m.ReadRoutes, name, routeReplica)
+
+	return replica, nil
+}
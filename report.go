@@ -0,0 +1,138 @@
+/*****************************************************************************
+*
+*	File			: report.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Final job report, printed at the end of every batch run.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+)
+
+type JobReport struct {
+	Audit  runAudit
+	State  fsetl.State // terminal lifecycle state, see fsetl/state.go
+	Result runResult
+	Pushes promwrap.PushStats // this batch's pushes only
+	Policy string             // push failure policy, tolerate or fail
+}
+
+// PushFailed is true when the batch's final push didn't make it to the gateway.
+func (r JobReport) PushFailed() bool {
+
+	return r.Pushes.LastFailed
+}
+
+// ExitCode maps the batch's lifecycle state onto the exit code protocol, see exitcode.go.
+func (r JobReport) ExitCode() int {
+
+	switch r.State {
+	case fsetl.StatePartial:
+		return exitPartial
+
+	case fsetl.StateFailed:
+		if r.Result.InfraErrors == 0 && r.Result.DataErrors > 0 {
+			return exitDataError
+
+		}
+		return exitInfraError
+
+	case fsetl.StateCancelled, fsetl.StateTimedOut:
+		return exitInfraError
+
+	}
+
+	if r.PushFailed() && r.Policy == promwrap.PolicyFail {
+		return exitPushFailure
+
+	}
+
+	return exitSuccess
+}
+
+// Print writes the report to stdout, record ids and errors redacted as per redact.
+func (r JobReport) Print() {
+
+	fmt.Println("Job report:")
+	fmt.Printf("  batch            : %s (job %s)\n", r.Audit.Batch, r.Audit.Job)
+	fmt.Printf("  status           : %s\n", r.Audit.Status)
+	fmt.Printf("  records          : %d\n", r.Audit.Records)
+	fmt.Printf("  iterations       : %d ok, %d data errors, %d infrastructure errors\n", r.Result.Succeeded, r.Result.DataErrors, r.Result.InfraErrors)
+	fmt.Printf("  duration         : %s\n", r.Audit.Duration().Round(time.Millisecond))
+	fmt.Printf("  pushes           : %d attempted, %d failed, %d degraded, %d offline\n", r.Pushes.Attempts, r.Pushes.Failures, r.Pushes.Degraded, r.Pushes.Offline)
+
+	switch {
+	case !r.PushFailed():
+		fmt.Println("  final push       : ok")
+
+	case r.Policy == promwrap.PolicyFail:
+		fmt.Printf("  final push       : FAILED, failing the job as per push failure policy (%v)\n", promwrap.RedactErr(r.Pushes.LastErr))
+
+	default:
+		fmt.Printf("  final push       : failed, tolerated as per push failure policy (%v)\n", promwrap.RedactErr(r.Pushes.LastErr))
+
+	}
+
+	if r.Audit.Err != "" {
+		fmt.Printf("  error            : %s\n", promwrap.Redact(r.Audit.Err))
+	}
+
+	for i, rec := range r.Result.Slowest {
+		label := ""
+		if i == 0 {
+			label = "slowest records"
+		}
+
+		names := make([]string, 0, len(rec.Phases))
+		for name := range rec.Phases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		phases := make([]string, len(names))
+		for j, name := range names {
+			phases[j] = fmt.Sprintf("%s %s", name, rec.Phases[name].Round(time.Millisecond))
+		}
+
+		fmt.Printf("  %-16s : %s %s (%s)\n", label, promwrap.Redact(rec.ID), rec.Duration.Round(time.Millisecond), strings.Join(phases, ", "))
+	}
+
+	if len(r.Result.Throughput) > 0 {
+		minutes := make([]string, len(r.Result.Throughput))
+		for i, n := range r.Result.Throughput {
+			minutes[i] = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		fmt.Printf("  records/minute   : %s\n", strings.Join(minutes, " "))
+	}
+
+	if c := r.Result.Cost; c != nil {
+		fmt.Printf("  estimated cost   : $%.4f (cpu $%.4f, transfer $%.4f, db $%.4f)\n", c.Total(), c.CPU, c.Transfer, c.DB)
+	}
+
+	for i, reg := range r.Result.Regressions {
+		label := ""
+		if i == 0 {
+			label = "regressions"
+		}
+		fmt.Printf("  %-16s : %s p95 %.3fs, baseline %.3fs\n", label, reg.Phase, reg.P95, reg.Baseline)
+	}
+
+	fmt.Printf("  exit code        : %d\n", r.ExitCode())
+}
@@ -0,0 +1,92 @@
+/*****************************************************************************
+*
+*	File			: retrybudget.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Retries of failed backup calls, run.retries per call, drawn from a
+*					: budget for the whole batch, run.retry_budget. Once the budget is spent
+*					: the batch fails right away, a flapping downstream fails the job fast
+*					: rather than stretching it by hours of retries. Exported as
+*
+*					:   fs_etl_retry_budget{batch}			the batch's budget
+*					:   fs_etl_retry_budget_remaining{batch}	retries left
+*
+*					: so an alert can fire on eg. less than 20% left, before the job fails.
+*					: Data errors fail the same way every time and aren't retried.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// retryBudget is the retries the batch has left, unlimited when size is 0.
+type retryBudget struct {
+	batch     string
+	size      int
+	remaining int
+}
+
+func newRetryBudget(batch string, size int) *retryBudget {
+
+	b := &retryBudget{batch: batch, size: size, remaining: size}
+	if size > 0 {
+		m.Set(m.RetryBudget, float64(size), batch)
+		m.Set(m.RetryRemaining, float64(size), batch)
+
+	} else {
+		m.RetryBudget.DeleteLabelValues(batch)
+		m.RetryRemaining.DeleteLabelValues(batch)
+
+	}
+
+	return b
+}
+
+// take takes one retry from the budget, failing once it's spent.
+func (b *retryBudget) take() error {
+
+	if b.size == 0 {
+		return nil
+	}
+	if b.remaining == 0 {
+		return fmt.Errorf("%w, all %d retries of batch %s used", errRetryBudgetExhausted, b.size, b.batch)
+
+	}
+
+	b.remaining--
+	m.Set(m.RetryRemaining, float64(b.remaining), b.batch)
+
+	return nil
+}
+
+// withRetries calls fn, retrying an infrastructure error up to retries times as long as
+// the budget lasts. An exhausted budget wraps the last error.
+func withRetries(b *retryBudget, retries int, fn func() (int, error)) (int, error) {
+
+	for attempt := 0; ; attempt++ {
+		n, err := fn()
+		if err == nil || isDataError(err) || attempt >= retries {
+			return n, err
+
+		}
+
+		if berr := b.take(); berr != nil {
+			return n, fmt.Errorf("%w: %v", berr, err)
+
+		}
+		reportFailure(fmt.Sprintf("Retrying, attempt %d of %d failed:", attempt+1, retries+1), err)
+	}
+}
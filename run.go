@@ -0,0 +1,277 @@
+/*****************************************************************************
+*
+*	File			: run.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: What main does once the config is loaded and promwrap.New has set up
+*					: the registry and pusher, moved out of main.go. setup creates the fs_etl
+*					: metrics and opens the databases, run then runs one of
+*
+*					:   a subcommand	archive, backfill, lint-buckets, calibrate
+*					:   the A/B run		ab_test.enabled
+*					:   the relay		relay.listen, see promwrap/relay.go
+*					:   the daemon		daemon.enabled, see daemon.go
+*					:   a single batch	the default, serving /metrics in pull mode
+*
+*					: and returns the exit code, see exitcode.go.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+)
+
+// setup creates the metrics and everything the batch needs on top of wrap.
+func setup(cfg Config, wrap *promwrap.Wrapper, startup *startupTimer) error {
+
+	var err error
+
+	reg, pusher = wrap.Registry, wrap.Pusher
+
+	if cfg.RemoteWrite.DualWrite && cfg.RemoteWrite.URL != "" {
+		pusher.Mirror("remote_write", NewRemoteWriter(cfg.RemoteWrite).WriteFamilies)
+	}
+
+	m = fsetl.NewMetrics(wrap.Metrics)
+	m.FileBuckets = cfg.FileMetrics.NumBuckets()
+	m.SetThroughputMinutes(cfg.Run.ThroughputMinutes)
+	if len(cfg.SummaryObjectives) > 0 {
+		if err := m.TrackQuantiles(cfg.SummaryObjectives); err != nil {
+			return fmt.Errorf("invalid summary_objectives: %w", err)
+
+		}
+	}
+	if cfg.DropLegacyTimestamps {
+		m.Unregister(m.CompletionTime)
+	}
+
+	// through m, not reg, so they carry const_labels and WithDefaultLabels as well
+	if c := NewCgroupCollector(cfg.Cgroup); c != nil {
+		m.Register(c)
+	}
+
+	snapshots, err = newArchive(cfg.Archive)
+	if err != nil {
+		return fmt.Errorf("setting up the snapshot archive: %w", err)
+
+	}
+	startup.Done(phaseRegistry)
+
+	if cfg.Pushgateway.Preflight != promwrap.PreflightOff {
+		if err := promwrap.RunPreflight(cfg.Pushgateway); err != nil {
+			return fmt.Errorf("pushgateway preflight: %w", err)
+
+		}
+		startup.Done(phasePreflight)
+	}
+
+	if cfg.Database.DSN != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		db, err = openDB(ctx, cfg.Database.dsn())
+		if err != nil {
+			return fmt.Errorf("connecting to database: %w", err)
+
+		}
+		pg = fsetl.NewDB(m, db, cfg.Run.Batch, cfg.Database.StatementTimeout)
+		dbs.Add(controlDB, pg)
+		startup.Done(phaseDBConnect)
+
+		if !cfg.Database.SkipMigrations {
+			n, err := Migrate(ctx, db)
+			if err != nil {
+				return fmt.Errorf("migrating database: %w", err)
+
+			}
+			infof("Applied %d database migrations...\n", n)
+			startup.Done(phaseMigrate)
+		}
+	}
+	baseline = newBaselineStore(cfg.Baseline, db)
+
+	if len(cfg.Databases) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		for name, c := range cfg.Databases {
+			if err := dbs.Open(ctx, name, c, cfg.Database.appName(), cfg.Run.Batch); err != nil {
+				return fmt.Errorf("connecting to database: %w", err)
+
+			}
+		}
+
+		if err := dbs.checkReplicas(); err != nil {
+			return fmt.Errorf("invalid database config: %w", err)
+
+		}
+		startup.Done(phaseDatabases)
+	}
+	m.Register(newPoolCollector(dbs))
+
+	startup.Record(m)
+
+	return nil
+}
+
+// closeDatabases closes what setup opened.
+func closeDatabases() {
+
+	dbs.Close()
+	if db != nil {
+		db.Close()
+	}
+}
+
+// run runs what the command line and config ask for and returns the exit code.
+func run(cfg Config, flags *cliFlags, cal *Calendar, wrap *promwrap.Wrapper) int {
+
+	switch cmd, args := flags.subcommand(); cmd {
+	case "":
+		if cfg.ABTest.Enabled {
+			if err := runABTest(cfg); err != nil {
+				reportFailure("A/B run failed:", err)
+				return exitStartup
+
+			}
+			return exitSuccess
+
+		}
+
+	case "archive":
+		if err := runArchive(args, cfg.Archive); err != nil {
+			reportFailure("Archive failed:", err)
+			return exitStartup
+
+		}
+		return exitSuccess
+
+	case "backfill":
+		if err := runBackfill(context.Background(), args, db, cfg.RemoteWrite); err != nil {
+			reportFailure("Backfill failed:", err)
+			return exitStartup
+
+		}
+		return exitSuccess
+
+	case "lint-buckets":
+		if err := runLintBuckets(args, cfg.Archive, cfg.Run.Batch); err != nil {
+			reportFailure("Bucket lint failed:", err)
+			return exitStartup
+
+		}
+		return exitSuccess
+
+	case "calibrate":
+		if err := runCalibrate(args, cfg.Calibration); err != nil {
+			reportFailure("Calibration failed:", err)
+			return exitStartup
+
+		}
+		return exitSuccess
+
+	}
+
+	// relay mode takes the loaders' pushes instead of running batches, see promwrap/relay.go
+	if wrap.Relay != nil {
+		return runRelay(cfg, wrap)
+
+	}
+
+	if cfg.Daemon.Enabled {
+		return runDaemonMode(cfg, cal)
+
+	}
+
+	return runOnce(cfg, cal, wrap)
+}
+
+func runRelay(cfg Config, wrap *promwrap.Wrapper) int {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv, err := wrap.ServeRelay()
+	if err != nil {
+		reportFailure("Could not start the relay:", err)
+		return exitStartup
+
+	}
+	infof("Relaying pushes taken on %s to %s every %s...\n", srv.Addr(), cfg.Pushgateway.GatewayURL(), cfg.Pushgateway.Interval)
+
+	<-ctx.Done()
+	srv.Close()
+	pusher.Flush() // the loaders' last pushes
+	pusher.Close()
+
+	return exitSuccess
+}
+
+func runDaemonMode(cfg Config, cal *Calendar) int {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := runDaemon(ctx, cfg.Daemon, cfg.Database, promwrap.MetricsHandler(promwrap.Consistent(reg), cfg.Exposition), func(trigger string, scheduled time.Time) {
+		m.Inc(m.RunsTriggered, trigger)
+		run := cfg // scheduled_start is the daemon's schedule here
+		run.Run.ScheduledStart, run.Run.scheduled = "", scheduled
+		runBatch(cal, run)
+	})
+	pusher.Flush() // pushes in periodic mode
+	pusher.Close()
+	if err != nil {
+		reportFailure("Daemon failed:", err)
+		return exitStartup
+
+	}
+
+	return exitSuccess
+}
+
+// runOnce runs a single batch, serving /metrics meanwhile in pull mode, the daemon
+// serves /metrics anyway.
+func runOnce(cfg Config, cal *Calendar, wrap *promwrap.Wrapper) int {
+
+	srv, err := wrap.Serve(cfg.Exposition)
+	if err != nil {
+		reportFailure("Could not serve /metrics:", err)
+		return exitStartup
+
+	}
+	if srv != nil {
+		infof("Serving /metrics on %s...\n", srv.Addr())
+	}
+
+	stop := handleShutdown(cfg)
+	code := runBatch(cal, cfg).ExitCode()
+	stop()
+
+	if d := cfg.Pushgateway.CleanupAfter; d > 0 {
+		infof("Deleting the job's groups from the Pushgateway in %s...\n", d)
+		if err := pusher.Cleanup(d); err != nil {
+			reportFailure("Could not delete from Pushgateway:", err)
+		}
+	}
+	pusher.Close()
+	srv.Close()
+
+	return code
+}
@@ -0,0 +1,125 @@
+/*****************************************************************************
+*
+*	File			: shutdown.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Graceful shutdown of a single run on SIGTERM/SIGINT. A killed job used to
+*					: lose everything recorded since its last push, now the running batch is
+*					: moved to cancelled and its final state pushed before we exit, or with
+*					: shutdown.delete_group its groups are deleted from the gateway instead,
+*					: so an interrupted run doesn't show up as the last run.
+*
+*					: The final push gets shutdown.timeout, a second signal exits straight away.
+*					: Daemon mode has its own signal handling, see runDaemon.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+)
+
+type ShutdownConfig struct {
+	Timeout     time.Duration `yaml:"timeout"`      // for the final push or delete, default 10s
+	DeleteGroup bool          `yaml:"delete_group"` // delete the job's groups instead of the final push
+}
+
+// The batch in progress, cancelled on shutdown
+var running struct {
+	sync.Mutex
+	job *fsetl.Job
+}
+
+func setRunning(job *fsetl.Job) {
+
+	running.Lock()
+	defer running.Unlock()
+
+	running.job = job
+}
+
+// handleShutdown cancels the running batch and exits on SIGTERM/SIGINT, until stop is called.
+func handleShutdown(cfg Config) (stop func()) {
+
+	c := cfg.Shutdown
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-signals:
+			infof("Received %s, shutting down...\n", sig)
+
+			go func() {
+				<-signals
+				promwrap.Log.Warn("Received second signal, exiting")
+				os.Exit(exitInfraError)
+			}()
+			time.AfterFunc(c.Timeout, func() {
+				promwrap.Log.Error("Shutdown timed out", "timeout", c.Timeout)
+				os.Exit(exitInfraError)
+			})
+
+			shutdown(cfg, c.Timeout)
+			os.Exit(exitInfraError)
+
+		case <-done:
+
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func shutdown(cfg Config, timeout time.Duration) {
+
+	running.Lock()
+	job := running.job
+	running.Unlock()
+
+	if job != nil {
+		job.Transition(fsetl.StateCancelled)
+	}
+
+	if cfg.Shutdown.DeleteGroup {
+		if err := pusher.Delete(); err != nil {
+			reportFailure("Could not delete from Pushgateway:", err)
+		}
+
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		finalPush(ctx)
+		cancel()
+
+	}
+	pusher.Close()
+
+	if job != nil {
+		job.Seal()
+	}
+	writeTextfile(cfg.Exposition)
+}
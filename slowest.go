@@ -0,0 +1,121 @@
+/*****************************************************************************
+*
+*	File			: slowest.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: The slowest N records of a batch, run.slowest_records. The latency
+*					: histograms say the batch has a slow tail, these say which records
+*					: are in it and where their time went (api, work, wait). Kept in a
+*					: bounded min-heap, listed in the job report and, with a database,
+*					: written to fs_etl_slow_record.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"container/heap"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+type slowRecord struct {
+	ID       string
+	Duration time.Duration
+	Phases   map[string]time.Duration
+}
+
+// slowHeap is a min-heap on duration, the fastest of the slowest on top.
+type slowHeap []slowRecord
+
+func (h slowHeap) Len() int            { return len(h) }
+func (h slowHeap) Less(i, j int) bool  { return h[i].Duration < h[j].Duration }
+func (h slowHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *slowHeap) Push(x interface{}) { *h = append(*h, x.(slowRecord)) }
+func (h *slowHeap) Pop() interface{} {
+
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return r
+}
+
+// slowest keeps the n slowest records observed, nil keeps none.
+type slowest struct {
+	n int
+	h slowHeap
+}
+
+func newSlowest(n int) *slowest {
+
+	if n <= 0 {
+		return nil
+	}
+	return &slowest{n: n}
+}
+
+func (s *slowest) Observe(r slowRecord) {
+
+	if s == nil {
+		return
+	}
+
+	if len(s.h) < s.n {
+		heap.Push(&s.h, r)
+		return
+
+	}
+	if r.Duration > s.h[0].Duration {
+		s.h[0] = r
+		heap.Fix(&s.h, 0)
+	}
+}
+
+// Records returns the records kept, slowest first.
+func (s *slowest) Records() []slowRecord {
+
+	if s == nil {
+		return nil
+	}
+
+	recs := append([]slowRecord(nil), s.h...)
+	sort.Slice(recs, func(i, j int) bool { return recs[i].Duration > recs[j].Duration })
+
+	return recs
+}
+
+func writeSlowRecords(ctx context.Context, db *sql.DB, a runAudit, recs []slowRecord) error {
+
+	for _, r := range recs {
+		phases := make(map[string]float64, len(r.Phases))
+		for name, d := range r.Phases {
+			phases[name] = d.Seconds()
+		}
+		js, err := json.Marshal(phases)
+		if err != nil {
+			return err
+
+		}
+
+		if _, err := db.ExecContext(ctx, `INSERT INTO fs_etl_slow_record
+			(job, batch, started_at, record_id, duration_seconds, phases)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			a.Job, a.Batch, a.Started, r.ID, r.Duration.Seconds(), string(js)); err != nil {
+			return err
+
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+/*****************************************************************************
+*
+*	File			: startdelay.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: How late the batch started against its schedule, exported as
+*					: fs_etl_start_delay_seconds{batch}, so a run held up by an upstream
+*					: dependency (a late file, a busy scheduler) is told apart from a run
+*					: that is slow itself, fs_etl_duration_seconds only covers the latter.
+*
+*					: The scheduled start comes from, in order
+*
+*					:   daemon.interval		the tick of the schedule trigger that queued the run
+*					:   run.scheduled_start	a timestamp, eg. the scheduler's logical date
+*					:   				passed in PROMWRAP_SCHEDULED_START, or a daily
+*					:   				clock time like "02:00", the cron entry's time
+*
+*					: A run without a schedule, eg. an admin trigger, exports no delay.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// scheduledStart resolves run.scheduled_start at now, the zero time when it isn't set.
+// A clock time resolves to its last occurrence at or before now.
+func scheduledStart(s string, now time.Time) (time.Time, error) {
+
+	if s == "" {
+		return time.Time{}, nil
+
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+
+	}
+
+	clock, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("scheduled start %q, expected an RFC3339 timestamp or a clock time like 02:00", s)
+
+	}
+
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if at.After(now) {
+		at = at.AddDate(0, 0, -1)
+	}
+
+	return at, nil
+}
+
+// setStartDelay exports how long after its scheduled start the batch started.
+func setStartDelay(r RunConfig, batch string, started time.Time) {
+
+	at := r.scheduled
+	if at.IsZero() {
+		at, _ = scheduledStart(r.ScheduledStart, started) // validated at startup
+	}
+
+	if at.IsZero() {
+		m.StartDelay.DeleteLabelValues(batch)
+		return
+
+	}
+
+	delay := started.Sub(at)
+	infof("Started %s after the scheduled start %s...\n", delay.Round(time.Second), at.Format(time.RFC3339))
+	m.Set(m.StartDelay, delay.Seconds(), batch)
+}
@@ -0,0 +1,76 @@
+/*****************************************************************************
+*
+*	File			: startup.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Times the startup phases (config load, registry setup, DB connect,
+*					: migrations) into fs_etl_startup_phase_seconds{phase}, slow starts on
+*					: some hosts were invisible until now.
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"myapp/fsetl"
+)
+
+// Startup phases, used as the "phase" label on fs_etl_startup_phase_seconds
+const (
+	phaseConfig    = "config"
+	phaseRegistry  = "registry"
+	phasePreflight = "preflight"
+	phaseDBConnect = "db_connect"
+	phaseMigrate   = "migrate"
+	phaseDatabases = "databases"
+)
+
+type startupPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// startupTimer times consecutive phases, each phase runs from the previous Done().
+// The registry doesn't exist yet when we start, so phases are kept until Record().
+type startupTimer struct {
+	start  time.Time
+	last   time.Time
+	phases []startupPhase
+}
+
+func newStartupTimer() *startupTimer {
+
+	now := time.Now()
+
+	return &startupTimer{start: now, last: now}
+}
+
+func (s *startupTimer) Done(phase string) {
+
+	now := time.Now()
+	s.phases = append(s.phases, startupPhase{phase, now.Sub(s.last)})
+	s.last = now
+}
+
+// Record sets fs_etl_startup_phase_seconds and logs a startup summary.
+func (s *startupTimer) Record(m *fsetl.Metrics) {
+
+	parts := make([]string, 0, len(s.phases))
+	for _, p := range s.phases {
+		m.Set(m.StartupPhase, p.duration.Seconds(), p.name)
+		parts = append(parts, fmt.Sprintf("%s %s", p.name, p.duration.Round(time.Microsecond)))
+	}
+
+	fmt.Printf("Startup completed in %s (%s)...\n", time.Since(s.start).Round(time.Microsecond), strings.Join(parts, ", "))
+}
@@ -0,0 +1,337 @@
+/*****************************************************************************
+*
+*	File			: stress_test.go
+*
+* 	Created			: 15 October 2026
+*
+*	Description		: Concurrency stress tests, hundreds of goroutines updating, gathering and
+*					: pushing at the same time. Meant to be run with the race detector,
+*
+*					:   go test -race -run Stress ./...
+*
+*	Modified		: 15 October 2026	- Start
+*
+*	By			: George Leonard (georgelza@gmail.com)
+*
+*
+*
+*****************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/fsetl"
+	"myapp/promwrap"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	stressGoroutines = 200
+	stressIterations = 100
+)
+
+// value returns the value of the series of family name with the given label values, in label name order.
+func value(t *testing.T, g prometheus.Gatherer, name string, lvs ...string) float64 {
+
+	t.Helper()
+
+	mfs, err := g.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+
+	}
+
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+
+		}
+		for _, mt := range mf.GetMetric() {
+			if !labelsMatch(mt, lvs) {
+				continue
+
+			}
+			switch {
+			case mt.Counter != nil:
+				return mt.GetCounter().GetValue()
+
+			case mt.Gauge != nil:
+				return mt.GetGauge().GetValue()
+
+			case mt.Histogram != nil:
+				return float64(mt.GetHistogram().GetSampleCount())
+
+			}
+		}
+	}
+
+	t.Fatalf("no series %s%v", name, lvs)
+
+	return 0
+}
+
+func labelsMatch(mt *dto.Metric, lvs []string) bool {
+
+	if len(mt.GetLabel()) != len(lvs) {
+		return false
+
+	}
+	for i, lp := range mt.GetLabel() {
+		if lp.GetValue() != lvs[i] {
+			return false
+
+		}
+	}
+
+	return true
+}
+
+// stressSetup points the package m at a fresh registry.
+func stressSetup(t *testing.T) *prometheus.Registry {
+
+	t.Helper()
+
+	r := prometheus.NewRegistry()
+	mainM := m
+	m = fsetl.NewMetrics(promwrap.NewMetrics(r))
+	t.Cleanup(func() { m = mainM })
+
+	return r
+}
+
+func TestStressMetricUpdates(t *testing.T) {
+
+	r := stressSetup(t)
+	job := fsetl.NewJob(m, "eft")
+
+	stop := make(chan struct{})
+	var gathers sync.WaitGroup
+	gathers.Add(1)
+	go func() {
+		defer gathers.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+
+			default:
+				if _, err := (promwrap.Consistent(r)).Gather(); err != nil {
+					t.Errorf("gather: %v", err)
+					return
+
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			batch := fmt.Sprintf("batch %d", g%10) // needs sanitizing
+			for i := 0; i < stressIterations; i++ {
+				m.Inc(m.ReqProcessed, "eft")
+				m.Add(m.CPUSeconds, 0.5, batch)
+				m.Observe(m.APIDuration, time.Microsecond, "eft")
+				m.Set(m.Info, float64(i), batch)
+				m.SetGauge(m.Maintenance, float64(i%2))
+				job.Update(fsetl.Snapshot{Records: i, Duration: time.Millisecond})
+				promwrap.Transaction(func() {
+					m.Inc(m.RunsTriggered, triggerSchedule)
+					m.Observe(m.RecDuration, time.Millisecond, "eft")
+				})
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(stop)
+	gathers.Wait()
+
+	total := float64(stressGoroutines * stressIterations)
+	if v := value(t, r, "fs_etl_operations_total", "eft"); v != total {
+		t.Errorf("fs_etl_operations_total = %g, want %g", v, total)
+	}
+	if v := value(t, r, "fs_api_duration_seconds", "eft"); v != total {
+		t.Errorf("fs_api_duration_seconds count = %g, want %g", v, total)
+	}
+	if v := value(t, r, "fs_etl_runs_triggered_total", triggerSchedule); v != total {
+		t.Errorf("fs_etl_runs_triggered_total = %g, want %g", v, total)
+	}
+	if v := value(t, r, "fs_etl_cpu_seconds_total", "batch_3"); v != total/10*0.5 {
+		t.Errorf("fs_etl_cpu_seconds_total{batch_3} = %g, want %g", v, total/10*0.5)
+	}
+}
+
+func TestStressConsistentGather(t *testing.T) {
+
+	r := stressSetup(t)
+
+	mainConsistent := promwrap.ConsistentGather
+	promwrap.ConsistentGather = true
+	defer func() { promwrap.ConsistentGather = mainConsistent }()
+
+	// Updates keep txn_count equal to the operations, a gather may never see them differ.
+	m.Add(m.ReqProcessed, 0, "eft")
+	m.Set(m.Info, 0, "eft")
+
+	var wg sync.WaitGroup
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < stressIterations; i++ {
+				promwrap.Transaction(func() {
+					m.Inc(m.ReqProcessed, "eft")
+					m.Set(m.Info, value(t, r, "fs_etl_operations_total", "eft"), "eft")
+				})
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	cg := promwrap.Consistent(r)
+	for {
+		select {
+		case <-done:
+			return
+
+		default:
+			mfs, err := cg.Gather()
+			if err != nil {
+				t.Errorf("gather: %v", err)
+				<-done
+				return
+
+			}
+
+			var ops, info float64
+			for _, mf := range mfs {
+				switch mf.GetName() {
+				case "fs_etl_operations_total":
+					ops = mf.GetMetric()[0].GetCounter().GetValue()
+
+				case "txn_count":
+					info = mf.GetMetric()[0].GetGauge().GetValue()
+
+				}
+			}
+			if ops != info {
+				t.Errorf("inconsistent gather, fs_etl_operations_total %g, txn_count %g", ops, info)
+				<-done
+				return
+
+			}
+		}
+	}
+}
+
+func TestStressPush(t *testing.T) {
+
+	r := stressSetup(t)
+
+	var requests atomic.Int64
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(io.Discard, req.Body)
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gw.Close()
+
+	router, err := promwrap.NewPushRouter(promwrap.PushgatewayConfig{
+		URL:  gw.URL,
+		Jobs: map[string][]string{"fs_loader_api": {"fs_api_duration_seconds"}},
+	}, r)
+	if err != nil {
+		t.Fatal(err)
+
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < stressIterations/10; i++ {
+				m.Observe(m.APIDuration, time.Microsecond, "eft")
+				m.Inc(m.ReqProcessed, "eft")
+
+				push := router.Add
+				if g%2 == 1 {
+					push = router.Push
+				}
+				if err := push(); err != nil {
+					t.Errorf("push: %v", err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	pushes := int64(stressGoroutines * stressIterations / 10 * 2) // 2 jobs
+	stats := router.Stats()
+	if int64(stats.Attempts) != pushes || stats.Failures != 0 {
+		t.Errorf("stats %d attempts %d failures, want %d attempts", stats.Attempts, stats.Failures, pushes)
+	}
+	if requests.Load() != pushes {
+		t.Errorf("gateway got %d pushes, want %d", requests.Load(), pushes)
+	}
+}
+
+func TestStressJobLifecycle(t *testing.T) {
+
+	stressSetup(t)
+
+	for run := 0; run < stressIterations; run++ {
+		job := fsetl.NewJob(m, "eft")
+		job.Transition(fsetl.StateRunning)
+
+		// Everybody tries to finish the batch, exactly one may succeed.
+		var won atomic.Int64
+		var wg sync.WaitGroup
+		for g := 0; g < stressGoroutines/10; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+
+				to := fsetl.StateSucceeded
+				if g%2 == 1 {
+					to = fsetl.StateFailed
+				}
+				if job.Transition(to) == nil {
+					won.Add(1)
+				}
+				m.Inc(m.ReqProcessed, "eft") // some of these race the Seal below
+			}(g)
+		}
+		job.Seal()
+		wg.Wait()
+
+		if won.Load() != 1 {
+			t.Fatalf("run %d, %d goroutines finished the batch, want 1", run, won.Load())
+		}
+		if !job.State().Terminal() {
+			t.Fatalf("run %d, state %s after finishing", run, job.State())
+		}
+	}
+}